@@ -0,0 +1,113 @@
+package main
+
+import (
+	"blog-system/config"
+	"blog-system/internal/app"
+	"blog-system/pkg/database"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+)
+
+const configUsage = `usage:
+  config print [--redacted]  输出合并后生效的配置和每一项的来源，--redacted 隐藏密码和密钥`
+
+func runCommand(cfg *config.Config, args []string) error {
+	switch args[0] {
+	case "repair-counters":
+		return withApp(cfg, repairCounters)
+	case "purge":
+		return withApp(cfg, purge)
+	case "migrate":
+		return runMigrate(cfg, args[1:])
+	case "config":
+		return runConfig(cfg, args[1:])
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+// withApp 连接数据库并创建应用后执行命令，收到中断信号时取消正在执行的查询
+func withApp(cfg *config.Config, run func(ctx context.Context, a *app.App) error) error {
+	db, err := database.Open(cfg)
+	if err != nil {
+		return err
+	}
+	defer database.Close(db)
+
+	if err := prepareSchema(cfg, db); err != nil {
+		return err
+	}
+
+	a, err := app.New(cfg, db, app.Options{})
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	return run(ctx, a)
+}
+
+func repairCounters(ctx context.Context, a *app.App) error {
+	rows, err := a.Services.Comments.RepairPostStats(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Recounted comment stats for %d posts\n", rows)
+	return nil
+}
+
+// purge 彻底删除已删除超过 PURGE_POSTS_AFTER 的文章，以及上传或移出文章后超过 PURGE_UPLOADS_AFTER
+// 仍未关联文章的附件，可以由定时任务每天执行
+func purge(ctx context.Context, a *app.App) error {
+	now := a.Clock.Now()
+
+	posts, err := a.Services.Purge.PurgeDeletedPosts(ctx, now.Add(-a.Config.Purge.PostsAfter))
+	if err != nil {
+		return err
+	}
+
+	uploads, err := a.Services.Purge.PurgeOrphanUploads(ctx, now.Add(-a.Config.Purge.UploadsAfter))
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Purged %d deleted posts and %d unused uploads\n", posts, uploads)
+	return nil
+}
+
+// runConfig 输出合并后的配置，格式与 config.env 相同，行尾注释说明值的来源
+func runConfig(cfg *config.Config, args []string) error {
+	if len(args) == 0 || args[0] != "print" {
+		return errors.New(configUsage)
+	}
+
+	flags := flag.NewFlagSet("config print", flag.ContinueOnError)
+	redacted := flags.Bool("redacted", false, "隐藏密码和密钥")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return errors.New(configUsage)
+	}
+
+	for _, setting := range cfg.Settings() {
+		value := setting.Value
+		if *redacted && setting.Secret && value != "" {
+			value = "******"
+		}
+		fmt.Printf("%-50s # %s\n", setting.Key+"="+value, setting.Source)
+	}
+
+	// 只报告校验问题，不影响输出，方便在服务拒绝启动时排查
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "\nConfiguration is invalid, the server will refuse to start:\n%v\n", err)
+	}
+	return nil
+}
@@ -2,54 +2,122 @@ package main
 
 import (
 	"blog-system/config"
+	"blog-system/internal/app"
+	"blog-system/internal/middleware"
 	"blog-system/internal/routes"
 	"blog-system/pkg/database"
 	"blog-system/pkg/logger"
+	"blog-system/pkg/tracing"
+	"context"
 	"fmt"
-	"log"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 func main() {
 	logger.Init()
 
-	cfg := config.Load()
+	cfg, args, err := config.Load(os.Args[1:])
+	if err != nil {
+		logger.Error("Invalid configuration", "error", err)
+		os.Exit(1)
+	}
+	logger.SetDefault(logger.New(os.Stdout, logger.Options{Level: cfg.Log.Level, Format: cfg.Log.Format}))
+
+	if len(args) > 0 {
+		if err := runCommand(cfg, args); err != nil {
+			logger.Error("Command failed", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := cfg.Validate(); err != nil {
+		logger.Error("Invalid configuration", "error", err)
+		os.Exit(1)
+	}
 
 	gin.SetMode(cfg.Server.GinMode)
 
-	if err := database.Connect(cfg); err != nil {
-		logger.Error("Failed to connect to database:", err)
-		log.Fatal(err)
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing, os.Stdout)
+	if err != nil {
+		logger.Error("Failed to set up tracing", "error", err)
+		os.Exit(1)
+	}
+	defer func() {
+		// 退出前导出尚未发送的 span
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Error("Failed to flush traces", "error", err)
+		}
+	}()
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		logger.Error("Failed to connect to database", "error", err)
+		os.Exit(1)
+	}
+	defer database.Close(db)
+
+	if err := prepareSchema(cfg, db); err != nil {
+		logger.Error("Failed to migrate database", "error", err)
+		os.Exit(1)
 	}
-	defer database.Close()
 
-	if err := database.Migrate(); err != nil {
-		logger.Error("Failed to migrate database:", err)
-		log.Fatal(err)
+	a, err := app.New(cfg, db, app.Options{})
+	if err != nil {
+		logger.Error("Failed to create application", "error", err)
+		os.Exit(1)
 	}
+	defer a.Close()
 
 	r := gin.New()
 
-	routes.SetupRoutes(r, cfg.JWT.Secret)
+	routes.SetupRoutes(r, a)
 
 	port := ":" + cfg.Server.Port
-	logger.Info("Starting server on port", port)
+	logger.Info("Starting server", "port", cfg.Server.Port)
 
 	go func() {
 		if err := r.Run(port); err != nil {
-			logger.Error("Failed to start server:", err)
-			log.Fatal(err)
+			logger.Error("Failed to start server", "error", err)
+			os.Exit(1)
 		}
 	}()
 
+	// /metrics 只在内部端口上提供，这个端口不应对外开放
+	if a.Metrics != nil && cfg.Metrics.Port != "" {
+		metricsRouter := gin.New()
+		metricsRouter.Use(middleware.Recovery(a.Logger))
+		routes.SetupMetrics(metricsRouter, a)
+
+		logger.Info("Starting metrics server", "port", cfg.Metrics.Port)
+		go func() {
+			if err := metricsRouter.Run(":" + cfg.Metrics.Port); err != nil {
+				logger.Error("Failed to start metrics server", "error", err)
+				os.Exit(1)
+			}
+		}()
+	}
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	logger.Info("Shutting down server...")
+	logger.Info("Shutting down server")
 	fmt.Println("Server stopped")
 }
+
+// prepareSchema 按 DB_AUTO_MIGRATE 执行迁移，关闭时只检查是否有未执行的迁移
+func prepareSchema(cfg *config.Config, db *gorm.DB) error {
+	if cfg.Database.AutoMigrate {
+		return database.Migrate(db)
+	}
+	return database.CheckMigrations(db)
+}
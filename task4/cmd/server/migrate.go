@@ -0,0 +1,127 @@
+package main
+
+import (
+	"blog-system/config"
+	"blog-system/pkg/database"
+	"blog-system/pkg/migrate"
+	"errors"
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strconv"
+)
+
+const migrateUsage = `usage:
+  migrate up [--dry-run]        执行全部未执行的迁移
+  migrate down [N] [--dry-run]  回滚最近的 N 个迁移，默认 1 个
+  migrate status                查看迁移状态
+  migrate create NAME [--dir]   创建新的迁移文件，默认放在 DB_DRIVER 对应的目录`
+
+// runMigrate 处理 migrate 子命令，只连接数据库，不启动 HTTP 服务
+func runMigrate(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	flags := flag.NewFlagSet("migrate "+args[0], flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "只输出将要执行的 SQL")
+	dir := flags.String("dir", filepath.Join("pkg/database/migrations", cfg.Database.Driver), "迁移文件所在目录")
+	if err := flags.Parse(reorderFlags(args[1:])); err != nil {
+		return err
+	}
+
+	if args[0] == "create" {
+		if flags.NArg() != 1 {
+			return errors.New(migrateUsage)
+		}
+		paths, err := migrate.Create(*dir, flags.Arg(0))
+		for _, path := range paths {
+			fmt.Println("Created", path)
+		}
+		return err
+	}
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		return err
+	}
+	defer database.Close(db)
+
+	runner, err := database.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+	runner.DryRun = *dryRun
+
+	switch args[0] {
+	case "up":
+		applied, err := runner.Up()
+		if err != nil {
+			return err
+		}
+		if *dryRun {
+			fmt.Printf("%d migrations pending\n", applied)
+		} else {
+			fmt.Printf("Applied %d migrations\n", applied)
+		}
+		return nil
+
+	case "down":
+		steps := 1
+		if flags.NArg() > 0 {
+			if steps, err = strconv.Atoi(flags.Arg(0)); err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of migrations %q", flags.Arg(0))
+			}
+		}
+		rolledBack, err := runner.Down(steps)
+		if err != nil {
+			return err
+		}
+		if *dryRun {
+			fmt.Printf("%d migrations would be rolled back\n", rolledBack)
+		} else {
+			fmt.Printf("Rolled back %d migrations\n", rolledBack)
+		}
+		return nil
+
+	case "status":
+		statuses, err := runner.Status()
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.AppliedAt != nil {
+				state = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			if status.Up == "" {
+				state += " (missing file)"
+			}
+			fmt.Printf("%-40s %s\n", status.Migration, state)
+		}
+		return nil
+
+	default:
+		return errors.New(migrateUsage)
+	}
+}
+
+// reorderFlags 把参数中的选项移到前面，使 "down 2 --dry-run" 和 "down --dry-run 2" 都能解析
+func reorderFlags(args []string) []string {
+	var flagArgs, positional []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if len(arg) > 1 && arg[0] == '-' {
+			flagArgs = append(flagArgs, arg)
+			if arg == "--dir" || arg == "-dir" {
+				if i+1 < len(args) {
+					flagArgs = append(flagArgs, args[i+1])
+					i++
+				}
+			}
+			continue
+		}
+		positional = append(positional, arg)
+	}
+	return append(flagArgs, positional...)
+}
@@ -1,61 +1,374 @@
 package config
 
 import (
-	"log"
+	"errors"
+	"io/fs"
 	"os"
+	"strings"
+	"time"
 
-	"github.com/joho/godotenv"
+	"blog-system/pkg/logger"
 )
 
+// defaultFile 是没有指定配置文件时读取的文件，不存在时只使用环境变量
+const defaultFile = "config.env"
+
+// DefaultJWTSecret 只用于本地开发，release 模式下使用它时拒绝启动
+const DefaultJWTSecret = "insecure-development-secret-do-not-use"
+
 type Config struct {
-	Database DatabaseConfig
-	JWT      JWTConfig
-	Server   ServerConfig
+	Database  DatabaseConfig
+	JWT       JWTConfig
+	Server    ServerConfig
+	Log       LogConfig
+	Metrics   MetricsConfig
+	Tracing   TracingConfig
+	Comment   CommentConfig
+	Analytics AnalyticsConfig
+	Site      SiteConfig
+	Feed      FeedConfig
+	Sitemap   SitemapConfig
+	Robots    RobotsConfig
+	Upload    UploadConfig
+	Purge     PurgeConfig
+
+	settings []Setting
 }
 
 type DatabaseConfig struct {
+	// Driver 为 postgres、mysql 或 sqlite
+	Driver   string
 	Host     string
 	Port     string
 	User     string
 	Password string
 	Name     string
+	// Path 是 SQLite 数据库文件的路径，":memory:" 表示内存数据库
+	Path string
+	// SSLMode 只用于 PostgreSQL
+	SSLMode string
+	// TimeZone 是数据库会话的时区，MySQL 用它解析 DATETIME 字段
+	TimeZone string
+	// AutoMigrate 为 true 时启动时执行未执行的迁移，否则只检查，有未执行的迁移时拒绝启动
+	AutoMigrate bool
+
+	// MaxOpenConns 是连接池的最大连接数，0 表示不限制；MaxIdleConns 是保留的最大空闲连接数
+	MaxOpenConns int
+	MaxIdleConns int
+	// ConnMaxLifetime 和 ConnMaxIdleTime 之后连接会被关闭重建，0 表示不限制
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+	// StatementTimeout 是数据库端单条语句的执行时间上限，0 表示不限制，SQLite 不支持
+	StatementTimeout time.Duration
+	// ConnectTimeout 是启动时等待数据库可用的总时长，期间按退避间隔重试
+	ConnectTimeout time.Duration
+	// SlowQueryThreshold 是慢查询的阈值，超过的语句记为 warn 日志，0 表示不记录
+	SlowQueryThreshold time.Duration
+
+	// Replicas 是只读副本的地址，格式为 host[:port]，用户名、密码和库名与主库相同；SQLite 为文件路径
+	Replicas []string
+	// ReplicaCheckInterval 是副本健康检查的间隔
+	ReplicaCheckInterval time.Duration
+	// ReplicaMaxLag 是副本允许落后主库的最长时间，超过后不再分配查询，0 表示不限制
+	ReplicaMaxLag time.Duration
+	// ReplicaPinWindow 是用户写入之后固定读主库的时长，保证能读到自己刚写入的内容
+	ReplicaPinWindow time.Duration
 }
 
 type JWTConfig struct {
 	Secret string
+	// TTL 是令牌的有效期
+	TTL time.Duration
 }
 
 type ServerConfig struct {
 	Port    string
 	GinMode string
+	// RequestTimeout 是单个请求的处理时间上限，超时后取消数据库查询，0 表示不限制
+	RequestTimeout time.Duration
+}
+
+type LogConfig struct {
+	// Level 为 debug、info、warn 或 error，debug 级别会输出每条 SQL
+	Level string
+	// Format 为 text 或 json
+	Format string
+}
+
+type MetricsConfig struct {
+	// Enabled 为 false 时不提供 /metrics
+	Enabled bool
+	// Port 不为空时 /metrics 只在这个端口上提供，用于只对内网开放；为空时与 API 使用同一个端口
+	Port string
+	// Token 不为空时抓取需要带上 Authorization: Bearer <token>
+	Token string
+}
+
+type TracingConfig struct {
+	// Exporter 为 none、otlp 或 stdout，none 时不记录链路
+	Exporter string
+	// OTLPEndpoint 是 OTLP/HTTP 接收端的地址，https 地址使用 TLS
+	OTLPEndpoint string
+	// File 是 stdout 导出器写入的文件，为空时写到标准输出
+	File string
+	// SampleRatio 是没有上游采样决定时记录的请求比例，上游请求带有 traceparent 时沿用上游的决定
+	SampleRatio float64
+	// ServiceName 是链路中的服务名
+	ServiceName string
+}
+
+type CommentConfig struct {
+	MaxDepth int
+	// EditWindow 为评论发布后允许编辑的时长，0 表示不限制
+	EditWindow time.Duration
+	Moderation ModerationConfig
+}
+
+type ModerationConfig struct {
+	MaxLinks           int
+	BannedWords        []string
+	RateLimit          int
+	RateWindow         time.Duration
+	SpamReviewScore    float64
+	SpamRejectScore    float64
+	ReviewFirstComment bool
+}
+
+type AnalyticsConfig struct {
+	// DedupeWindow 内同一访客重复访问同一篇文章只计一次
+	DedupeWindow  time.Duration
+	FlushInterval time.Duration
+	MaxBuffer     int
+}
+
+type SiteConfig struct {
+	// URL 是前台网站的根地址，用于生成订阅源和站点地图中的链接
+	URL         string
+	Title       string
+	Description string
+}
+
+type FeedConfig struct {
+	Items int
+	// FullContent 为 false 时订阅源只输出摘要
+	FullContent   bool
+	ExcerptLength int
+}
+
+type SitemapConfig struct {
+	// RefreshInterval 内重复请求直接使用缓存，过期后只增量查询有变化的文章
+	RefreshInterval time.Duration
+	// MaxURLs 为单个站点地图文件的URL数量，超过后 /sitemap.xml 改为站点地图索引
+	MaxURLs int
+}
+
+type RobotsConfig struct {
+	Allow    []string
+	Disallow []string
+	// DisallowAll 用于测试环境，禁止所有爬虫抓取
+	DisallowAll bool
+}
+
+type UploadConfig struct {
+	// Driver 为 local 或 s3
+	Driver string
+	// LocalDir 和 PublicURL 用于本地存储，文件通过 /uploads 路径对外提供
+	LocalDir  string
+	PublicURL string
+	S3        S3Config
+
+	// MaxSize 为各角色单个文件的大小上限，单位为字节
+	MaxSize      map[string]int64
+	AllowedTypes []string
+	// MaxPixels 限制图片的像素数，防止解码时占用过多内存
+	MaxPixels     int
+	ThumbnailSize int
+	JPEGQuality   int
 }
 
-func Load() *Config {
-	if err := godotenv.Load("config.env"); err != nil {
-		log.Println("Warning: config.env file not found, using system environment variables")
+type S3Config struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	PathStyle bool
+	PublicURL string
+}
+
+type PurgeConfig struct {
+	// PostsAfter 为文章删除后保留的时长，超过后由 purge 命令彻底删除
+	PostsAfter time.Duration
+	// UploadsAfter 为没有关联文章的附件的保留时长，从上传或移出文章时开始计算
+	UploadsAfter time.Duration
+}
+
+// Load 按默认值、配置文件、环境变量、命令行参数的顺序合并配置，后面的覆盖前面的，
+// 返回配置和子命令之前的选项之后剩余的参数。只检查格式和未知的键，
+// 完整的校验由调用方在启动服务之前调用 Validate，子命令在弱密钥等配置下仍然可用。
+//
+// 配置文件由 --config 或 CONFIG_FILE 指定，支持 YAML、TOML 和 .env 格式，都没有指定时读取当前目录的 config.env。
+// 命令行选项写在子命令之前，--db-host=localhost 对应 DB_HOST
+func Load(args []string) (*Config, []string, error) {
+	configFile, flags, args, err := parseFlags(args)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var file map[string]string
+	if configFile == "" {
+		configFile = os.Getenv("CONFIG_FILE")
+	}
+	if configFile != "" {
+		if file, err = readFile(configFile); err != nil {
+			return nil, nil, err
+		}
+	} else if file, err = readFile(defaultFile); err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, nil, err
+		}
+		logger.Warn("config.env file not found, using system environment variables")
+	} else {
+		configFile = defaultFile
 	}
 
-	return &Config{
+	l := newLoader(file, flags, configFile)
+
+	driver := strings.ToLower(l.string("DB_DRIVER", "postgres"))
+	defaultPort, defaultUser := "5432", "postgres"
+	if driver == "mysql" {
+		defaultPort, defaultUser = "3306", "root"
+	}
+
+	cfg := &Config{
 		Database: DatabaseConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     getEnv("DB_PORT", "5432"),
-			User:     getEnv("DB_USER", "postgres"),
-			Password: getEnv("DB_PASSWORD", "123456"),
-			Name:     getEnv("DB_NAME", "blog"),
+			Driver:   driver,
+			Host:     l.string("DB_HOST", "localhost"),
+			Port:     l.string("DB_PORT", defaultPort),
+			User:     l.string("DB_USER", defaultUser),
+			Password: l.string("DB_PASSWORD", ""),
+			Name:     l.string("DB_NAME", "blog"),
+			Path:     l.string("DB_PATH", "blog.db"),
+			SSLMode:  l.string("DB_SSLMODE", "disable"),
+			TimeZone: l.string("DB_TIMEZONE", "UTC"),
+
+			AutoMigrate: l.bool("DB_AUTO_MIGRATE", true),
+
+			MaxOpenConns:     l.int("DB_MAX_OPEN_CONNS", 25),
+			MaxIdleConns:     l.int("DB_MAX_IDLE_CONNS", 10),
+			ConnMaxLifetime:  l.duration("DB_CONN_MAX_LIFETIME", 30*time.Minute),
+			ConnMaxIdleTime:  l.duration("DB_CONN_MAX_IDLE_TIME", 5*time.Minute),
+			StatementTimeout: l.duration("DB_STATEMENT_TIMEOUT", 30*time.Second),
+			ConnectTimeout:   l.duration("DB_CONNECT_TIMEOUT", time.Minute),
+
+			SlowQueryThreshold: l.duration("DB_SLOW_QUERY_THRESHOLD", 200*time.Millisecond),
+
+			Replicas:             l.list("DB_REPLICAS", ""),
+			ReplicaCheckInterval: l.duration("DB_REPLICA_CHECK_INTERVAL", 5*time.Second),
+			ReplicaMaxLag:        l.duration("DB_REPLICA_MAX_LAG", 10*time.Second),
+			ReplicaPinWindow:     l.duration("DB_REPLICA_PIN_WINDOW", 10*time.Second),
 		},
 		JWT: JWTConfig{
-			Secret: getEnv("JWT_SECRET", "ktjnCkMI6GgMN3w6Nein+BFSl7YThGzlmwuomDSvkzo="),
+			Secret: l.string("JWT_SECRET", DefaultJWTSecret),
+			TTL:    l.duration("JWT_TTL", 168*time.Hour),
 		},
 		Server: ServerConfig{
-			Port:    getEnv("SERVER_PORT", "8080"),
-			GinMode: getEnv("GIN_MODE", "debug"),
+			Port:           l.string("SERVER_PORT", "8080"),
+			GinMode:        l.string("GIN_MODE", "debug"),
+			RequestTimeout: l.duration("SERVER_REQUEST_TIMEOUT", 15*time.Second),
+		},
+		Log: LogConfig{
+			Level:  strings.ToLower(l.string("LOG_LEVEL", "info")),
+			Format: strings.ToLower(l.string("LOG_FORMAT", "text")),
+		},
+		Metrics: MetricsConfig{
+			Enabled: l.bool("METRICS_ENABLED", true),
+			Port:    l.string("METRICS_PORT", ""),
+			Token:   l.string("METRICS_TOKEN", ""),
+		},
+		Tracing: TracingConfig{
+			Exporter:     strings.ToLower(l.string("TRACING_EXPORTER", "none")),
+			OTLPEndpoint: l.string("TRACING_OTLP_ENDPOINT", "http://localhost:4318"),
+			File:         l.string("TRACING_FILE", ""),
+			SampleRatio:  l.float("TRACING_SAMPLE_RATIO", 1),
+			ServiceName:  l.string("TRACING_SERVICE_NAME", "blog-system"),
+		},
+		Comment: CommentConfig{
+			MaxDepth:   l.int("COMMENT_MAX_DEPTH", 5),
+			EditWindow: l.duration("COMMENT_EDIT_WINDOW", 15*time.Minute),
+			Moderation: ModerationConfig{
+				MaxLinks:           l.int("MODERATION_MAX_LINKS", 2),
+				BannedWords:        l.list("MODERATION_BANNED_WORDS", ""),
+				RateLimit:          l.int("MODERATION_RATE_LIMIT", 5),
+				RateWindow:         l.duration("MODERATION_RATE_WINDOW", time.Minute),
+				SpamReviewScore:    l.float("MODERATION_SPAM_REVIEW_SCORE", 0.5),
+				SpamRejectScore:    l.float("MODERATION_SPAM_REJECT_SCORE", 0.9),
+				ReviewFirstComment: l.bool("MODERATION_REVIEW_FIRST_COMMENT", true),
+			},
+		},
+		Analytics: AnalyticsConfig{
+			DedupeWindow:  l.duration("VIEW_DEDUPE_WINDOW", 30*time.Minute),
+			FlushInterval: l.duration("VIEW_FLUSH_INTERVAL", 10*time.Second),
+			MaxBuffer:     l.int("VIEW_BUFFER_SIZE", 1000),
+		},
+		Site: SiteConfig{
+			URL:         strings.TrimRight(l.string("SITE_URL", "http://localhost:8080"), "/"),
+			Title:       l.string("SITE_TITLE", "个人博客"),
+			Description: l.string("SITE_DESCRIPTION", "个人博客系统"),
+		},
+		Feed: FeedConfig{
+			Items:         l.int("FEED_ITEMS", 20),
+			FullContent:   l.bool("FEED_FULL_CONTENT", true),
+			ExcerptLength: l.int("FEED_EXCERPT_LENGTH", 300),
+		},
+		Sitemap: SitemapConfig{
+			RefreshInterval: l.duration("SITEMAP_REFRESH_INTERVAL", 5*time.Minute),
+			MaxURLs:         l.int("SITEMAP_MAX_URLS", 50000),
+		},
+		Robots: RobotsConfig{
+			Allow:       l.list("ROBOTS_ALLOW", ""),
+			Disallow:    l.list("ROBOTS_DISALLOW", "/api/"),
+			DisallowAll: l.bool("ROBOTS_DISALLOW_ALL", false),
+		},
+		Upload: UploadConfig{
+			Driver:    l.string("UPLOAD_DRIVER", "local"),
+			LocalDir:  l.string("UPLOAD_LOCAL_DIR", "uploads"),
+			PublicURL: strings.TrimRight(l.string("UPLOAD_PUBLIC_URL", "http://localhost:8080/uploads"), "/"),
+			S3: S3Config{
+				Endpoint:  l.string("S3_ENDPOINT", ""),
+				Region:    l.string("S3_REGION", "us-east-1"),
+				Bucket:    l.string("S3_BUCKET", ""),
+				AccessKey: l.string("S3_ACCESS_KEY", ""),
+				SecretKey: l.string("S3_SECRET_KEY", ""),
+				PathStyle: l.bool("S3_PATH_STYLE", false),
+				PublicURL: l.string("S3_PUBLIC_URL", ""),
+			},
+			MaxSize: map[string]int64{
+				"user":      l.size("UPLOAD_MAX_SIZE_USER", 5<<20),
+				"moderator": l.size("UPLOAD_MAX_SIZE_MODERATOR", 20<<20),
+				"admin":     l.size("UPLOAD_MAX_SIZE_ADMIN", 50<<20),
+			},
+			AllowedTypes:  l.list("UPLOAD_ALLOWED_TYPES", "image/jpeg,image/png,image/gif,image/webp,application/pdf,application/zip,text/plain"),
+			MaxPixels:     l.int("UPLOAD_MAX_PIXELS", 40000000),
+			ThumbnailSize: l.int("UPLOAD_THUMBNAIL_SIZE", 400),
+			JPEGQuality:   l.int("UPLOAD_JPEG_QUALITY", 85),
+		},
+		Purge: PurgeConfig{
+			PostsAfter:   l.duration("PURGE_POSTS_AFTER", 30*24*time.Hour),
+			UploadsAfter: l.duration("PURGE_UPLOADS_AFTER", 24*time.Hour),
 		},
 	}
-}
+	cfg.settings = l.settings
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+	l.unknown(configFile, file)
+	l.unknown("flags", flags)
+	if len(l.errs) > 0 {
+		return nil, nil, errors.Join(l.errs...)
 	}
-	return defaultValue
+	return cfg, args, nil
+}
+
+// Settings 返回全部配置项最终生效的值和来源，顺序与 config.env 一致
+func (c *Config) Settings() []Setting {
+	return c.settings
 }
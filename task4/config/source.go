@@ -0,0 +1,274 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Setting 是一个配置项最终生效的值和来源
+type Setting struct {
+	Key   string
+	Value string
+	// Source 为 default、配置文件路径、env 或 flag，值来自 *_FILE 时附带变量名
+	Source string
+	Secret bool
+}
+
+// layer 是一层配置来源，后面的层覆盖前面的层
+type layer struct {
+	name   string
+	lookup func(key string) string
+}
+
+// loader 依次在默认值、配置文件、环境变量和命令行参数中查找配置项，并记录读取过的键，
+// 配置文件和命令行参数中出现未知的键时报错，避免拼写错误被忽略
+type loader struct {
+	layers   []layer
+	known    map[string]bool
+	settings []Setting
+	errs     []error
+}
+
+func newLoader(file, flags map[string]string, fileName string) *loader {
+	l := &loader{known: make(map[string]bool)}
+	if file != nil {
+		l.layers = append(l.layers, layer{name: fileName, lookup: func(key string) string { return file[key] }})
+	}
+	l.layers = append(l.layers, layer{name: "env", lookup: os.Getenv})
+	l.layers = append(l.layers, layer{name: "flag", lookup: func(key string) string { return flags[key] }})
+	return l
+}
+
+// lookup 返回配置项的原始值，空值视为未设置。每一层都可以用 KEY_FILE 指定从文件读取，
+// 用于 Docker 和 Kubernetes 挂载的密钥
+func (l *loader) lookup(key, defaultValue string) string {
+	l.known[key] = true
+	l.known[key+"_FILE"] = true
+
+	value, source := defaultValue, "default"
+	for _, layer := range l.layers {
+		direct, path := layer.lookup(key), layer.lookup(key+"_FILE")
+		switch {
+		case direct != "" && path != "":
+			l.errs = append(l.errs, fmt.Errorf("%s and %s_FILE are both set in %s", key, key, layer.name))
+		case direct != "":
+			value, source = direct, layer.name
+		case path != "":
+			content, err := os.ReadFile(path)
+			if err != nil {
+				l.errs = append(l.errs, fmt.Errorf("read %s_FILE: %w", key, err))
+				continue
+			}
+			value, source = strings.TrimRight(string(content), "\r\n"), layer.name+" "+key+"_FILE"
+		}
+	}
+
+	l.settings = append(l.settings, Setting{Key: key, Value: value, Source: source, Secret: isSecret(key)})
+	return value
+}
+
+func (l *loader) invalid(key, kind, value string) {
+	l.errs = append(l.errs, fmt.Errorf("%s: invalid %s %q", key, kind, value))
+}
+
+func (l *loader) string(key, defaultValue string) string {
+	return l.lookup(key, defaultValue)
+}
+
+func (l *loader) int(key string, defaultValue int) int {
+	value := l.lookup(key, strconv.Itoa(defaultValue))
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		l.invalid(key, "integer", value)
+		return defaultValue
+	}
+	return i
+}
+
+func (l *loader) duration(key string, defaultValue time.Duration) time.Duration {
+	value := l.lookup(key, defaultValue.String())
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		l.invalid(key, "duration", value)
+		return defaultValue
+	}
+	return d
+}
+
+func (l *loader) float(key string, defaultValue float64) float64 {
+	value := l.lookup(key, strconv.FormatFloat(defaultValue, 'g', -1, 64))
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		l.invalid(key, "number", value)
+		return defaultValue
+	}
+	return f
+}
+
+func (l *loader) bool(key string, defaultValue bool) bool {
+	value := l.lookup(key, strconv.FormatBool(defaultValue))
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		l.invalid(key, "boolean", value)
+		return defaultValue
+	}
+	return b
+}
+
+// size 解析文件大小，支持 KB、MB、GB 后缀，没有后缀时单位为字节
+func (l *loader) size(key string, defaultValue int64) int64 {
+	raw := l.lookup(key, strconv.FormatInt(defaultValue, 10))
+	value := strings.ToUpper(strings.TrimSpace(raw))
+
+	multiplier := int64(1)
+	for suffix, m := range map[string]int64{"KB": 1 << 10, "MB": 1 << 20, "GB": 1 << 30} {
+		if strings.HasSuffix(value, suffix) {
+			value, multiplier = strings.TrimSpace(strings.TrimSuffix(value, suffix)), m
+			break
+		}
+	}
+
+	if size, err := strconv.ParseInt(value, 10, 64); err == nil && size > 0 {
+		return size * multiplier
+	}
+	l.invalid(key, "size", raw)
+	return defaultValue
+}
+
+func (l *loader) list(key, defaultValue string) []string {
+	return splitList(l.lookup(key, defaultValue))
+}
+
+// unknown 检查配置文件和命令行参数中是否有没有读取过的键
+func (l *loader) unknown(source string, values map[string]string) {
+	for key := range values {
+		if !l.known[key] {
+			l.errs = append(l.errs, fmt.Errorf("unknown configuration key %s in %s", key, source))
+		}
+	}
+}
+
+func isSecret(key string) bool {
+	for _, suffix := range []string{"PASSWORD", "SECRET", "SECRET_KEY", "ACCESS_KEY", "TOKEN"} {
+		if strings.HasSuffix(key, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// readFile 按扩展名读取 YAML、TOML 或 .env 格式的配置文件。
+// YAML 和 TOML 中嵌套的键用下划线连接后转为大写，与环境变量同名，
+// 例如 db.host 对应 DB_HOST，upload.max_size.user 对应 UPLOAD_MAX_SIZE_USER
+func readFile(path string) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := make(map[string]interface{})
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &tree)
+	case ".toml":
+		err = toml.Unmarshal(content, &tree)
+	default:
+		values, err := godotenv.Unmarshal(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+		return values, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	values := make(map[string]string)
+	if err := flatten(values, "", tree); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return values, nil
+}
+
+func flatten(values map[string]string, prefix string, value interface{}) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			name := strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+			if prefix != "" {
+				name = prefix + "_" + name
+			}
+			if err := flatten(values, name, child); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			if _, nested := item.(map[string]interface{}); nested {
+				return fmt.Errorf("%s: lists of tables are not supported", prefix)
+			}
+			items = append(items, fmt.Sprint(item))
+		}
+		values[prefix] = strings.Join(items, ",")
+	case nil:
+		values[prefix] = ""
+	default:
+		values[prefix] = fmt.Sprint(v)
+	}
+	return nil
+}
+
+// parseFlags 解析子命令之前的选项，--config 指定配置文件，其他选项如 --db-host 对应 DB_HOST，
+// 返回配置文件路径、选项和剩余的参数
+func parseFlags(args []string) (string, map[string]string, []string, error) {
+	configFile := ""
+	flags := make(map[string]string)
+
+	for len(args) > 0 {
+		arg := args[0]
+		if arg == "--" {
+			return configFile, flags, args[1:], nil
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			break
+		}
+		args = args[1:]
+
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !hasValue {
+			if len(args) == 0 {
+				return "", nil, nil, fmt.Errorf("flag %s needs a value", arg)
+			}
+			value, args = args[0], args[1:]
+		}
+		if name == "" {
+			return "", nil, nil, fmt.Errorf("invalid flag %q", arg)
+		}
+
+		if name == "config" {
+			configFile = value
+			continue
+		}
+		flags[strings.ToUpper(strings.ReplaceAll(name, "-", "_"))] = value
+	}
+	return configFile, flags, args, nil
+}
@@ -0,0 +1,151 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+)
+
+// insecureSecrets 是曾经作为默认值或示例出现在仓库中的密钥，release 模式下不允许使用
+var insecureSecrets = map[string]bool{
+	DefaultJWTSecret: true,
+	"ktjnCkMI6GgMN3w6Nein+BFSl7YThGzlmwuomDSvkzo=": true,
+	"123456": true,
+}
+
+// minJWTSecretLength 是 release 模式下 JWT 密钥的最小长度，HS256 的密钥不应短于 256 位
+const minJWTSecretLength = 32
+
+// Validate 检查配置是否完整有效，返回全部问题而不是遇到第一个就停止
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	switch c.Database.Driver {
+	case "sqlite":
+		check(c.Database.Path != "", "DB_PATH is required for sqlite")
+	case "postgres", "mysql":
+		check(c.Database.Host != "", "DB_HOST is required")
+		check(c.Database.Name != "", "DB_NAME is required")
+		check(validPort(c.Database.Port), "DB_PORT: invalid port %q", c.Database.Port)
+	default:
+		errs = append(errs, fmt.Errorf("DB_DRIVER: unsupported driver %q, expected postgres, mysql or sqlite", c.Database.Driver))
+	}
+
+	check(c.Database.MaxOpenConns >= 0, "DB_MAX_OPEN_CONNS must not be negative")
+	check(c.Database.MaxIdleConns >= 0, "DB_MAX_IDLE_CONNS must not be negative")
+	check(c.Database.MaxOpenConns == 0 || c.Database.MaxIdleConns <= c.Database.MaxOpenConns,
+		"DB_MAX_IDLE_CONNS must not be greater than DB_MAX_OPEN_CONNS")
+	check(c.Database.ConnMaxLifetime >= 0, "DB_CONN_MAX_LIFETIME must not be negative")
+	check(c.Database.ConnMaxIdleTime >= 0, "DB_CONN_MAX_IDLE_TIME must not be negative")
+	check(c.Database.StatementTimeout >= 0, "DB_STATEMENT_TIMEOUT must not be negative")
+	check(c.Database.ConnectTimeout >= 0, "DB_CONNECT_TIMEOUT must not be negative")
+	check(c.Database.ReplicaCheckInterval > 0, "DB_REPLICA_CHECK_INTERVAL must be positive")
+	check(c.Database.ReplicaMaxLag >= 0, "DB_REPLICA_MAX_LAG must not be negative")
+	check(c.Database.ReplicaPinWindow >= 0, "DB_REPLICA_PIN_WINDOW must not be negative")
+	if c.Database.Driver != "sqlite" {
+		for _, address := range c.Database.Replicas {
+			if _, port, err := net.SplitHostPort(address); err == nil && !validPort(port) {
+				errs = append(errs, fmt.Errorf("DB_REPLICAS: invalid port in %q", address))
+			}
+		}
+	}
+
+	check(c.JWT.Secret != "", "JWT_SECRET is required")
+	check(c.JWT.TTL > 0, "JWT_TTL must be positive")
+
+	check(validPort(c.Server.Port), "SERVER_PORT: invalid port %q", c.Server.Port)
+	check(c.Server.RequestTimeout >= 0, "SERVER_REQUEST_TIMEOUT must not be negative")
+	switch c.Log.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		errs = append(errs, fmt.Errorf("LOG_LEVEL: invalid level %q, expected debug, info, warn or error", c.Log.Level))
+	}
+	check(c.Log.Format == "text" || c.Log.Format == "json", "LOG_FORMAT: invalid format %q, expected text or json", c.Log.Format)
+	check(c.Database.SlowQueryThreshold >= 0, "DB_SLOW_QUERY_THRESHOLD must not be negative")
+	switch c.Server.GinMode {
+	case "debug", "release", "test":
+	default:
+		errs = append(errs, fmt.Errorf("GIN_MODE: invalid mode %q, expected debug, release or test", c.Server.GinMode))
+	}
+	switch c.Tracing.Exporter {
+	case "none", "stdout":
+	case "otlp":
+		if u, err := url.Parse(c.Tracing.OTLPEndpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("TRACING_OTLP_ENDPOINT: invalid http or https URL %q", c.Tracing.OTLPEndpoint))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("TRACING_EXPORTER: unsupported exporter %q, expected none, otlp or stdout", c.Tracing.Exporter))
+	}
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "TRACING_SAMPLE_RATIO must be between 0 and 1")
+	check(c.Tracing.ServiceName != "", "TRACING_SERVICE_NAME is required")
+	if c.Metrics.Enabled && c.Metrics.Port != "" {
+		check(validPort(c.Metrics.Port), "METRICS_PORT: invalid port %q", c.Metrics.Port)
+		check(c.Metrics.Port != c.Server.Port, "METRICS_PORT must differ from SERVER_PORT")
+	}
+
+	check(c.Comment.MaxDepth > 0, "COMMENT_MAX_DEPTH must be positive")
+	check(c.Comment.EditWindow >= 0, "COMMENT_EDIT_WINDOW must not be negative")
+	check(c.Comment.Moderation.RateWindow > 0, "MODERATION_RATE_WINDOW must be positive")
+	check(c.Comment.Moderation.SpamReviewScore <= c.Comment.Moderation.SpamRejectScore,
+		"MODERATION_SPAM_REVIEW_SCORE must not be greater than MODERATION_SPAM_REJECT_SCORE")
+
+	check(c.Analytics.FlushInterval > 0, "VIEW_FLUSH_INTERVAL must be positive")
+	check(c.Analytics.MaxBuffer > 0, "VIEW_BUFFER_SIZE must be positive")
+	check(c.Sitemap.RefreshInterval >= 0, "SITEMAP_REFRESH_INTERVAL must not be negative")
+
+	if u, err := url.Parse(c.Site.URL); err != nil || u.Scheme == "" || u.Host == "" {
+		errs = append(errs, fmt.Errorf("SITE_URL: invalid absolute URL %q", c.Site.URL))
+	}
+
+	switch c.Upload.Driver {
+	case "local":
+		check(c.Upload.LocalDir != "", "UPLOAD_LOCAL_DIR is required for the local upload driver")
+	case "s3":
+		check(c.Upload.S3.Bucket != "", "S3_BUCKET is required for the s3 upload driver")
+	default:
+		errs = append(errs, fmt.Errorf("UPLOAD_DRIVER: unsupported driver %q, expected local or s3", c.Upload.Driver))
+	}
+	check(c.Upload.JPEGQuality >= 1 && c.Upload.JPEGQuality <= 100, "UPLOAD_JPEG_QUALITY must be between 1 and 100")
+	check(c.Upload.MaxPixels > 0, "UPLOAD_MAX_PIXELS must be positive")
+	check(c.Upload.ThumbnailSize > 0, "UPLOAD_THUMBNAIL_SIZE must be positive")
+
+	check(c.Purge.PostsAfter > 0, "PURGE_POSTS_AFTER must be positive")
+	check(c.Purge.UploadsAfter > 0, "PURGE_UPLOADS_AFTER must be positive")
+
+	if c.Server.GinMode == "release" {
+		errs = append(errs, c.validateSecrets()...)
+	}
+	return errors.Join(errs...)
+}
+
+// validateSecrets 在 release 模式下拒绝默认或过短的密钥和没有保护的 /metrics，密钥可以通过 JWT_SECRET_FILE 等变量从文件读取
+func (c *Config) validateSecrets() []error {
+	var errs []error
+	if insecureSecrets[c.JWT.Secret] {
+		errs = append(errs, errors.New("JWT_SECRET uses a default value, set a random secret in release mode"))
+	} else if len(c.JWT.Secret) < minJWTSecretLength {
+		errs = append(errs, fmt.Errorf("JWT_SECRET must be at least %d bytes in release mode", minJWTSecretLength))
+	}
+
+	if c.Database.Driver != "sqlite" && (c.Database.Password == "" || insecureSecrets[c.Database.Password]) {
+		errs = append(errs, errors.New("DB_PASSWORD is empty or uses a default value, set a real password in release mode"))
+	}
+
+	// 与 API 共用端口时 /metrics 对外可见，需要令牌才能访问
+	if c.Metrics.Enabled && c.Metrics.Port == "" && c.Metrics.Token == "" {
+		errs = append(errs, errors.New("METRICS_TOKEN is required in release mode unless METRICS_PORT serves /metrics on an internal port"))
+	}
+	return errs
+}
+
+func validPort(port string) bool {
+	p, err := strconv.Atoi(port)
+	return err == nil && p > 0 && p <= 65535
+}
@@ -65,7 +65,7 @@ echo ""
 
 # 获取文章列表
 echo "6. 获取文章列表..."
-curl -s -X GET "$BASE_URL/posts?page=1&page_size=10" | jq .
+curl -s -X GET "$BASE_URL/posts?page_size=10" | jq .
 echo ""
 
 # 获取单个文章
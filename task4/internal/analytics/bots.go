@@ -0,0 +1,11 @@
+package analytics
+
+import "regexp"
+
+// botPattern 覆盖常见的搜索引擎、社交平台预览和命令行工具
+var botPattern = regexp.MustCompile(`(?i)bot|crawl|spider|slurp|facebookexternalhit|embedly|preview|monitor|headless|lighthouse|curl|wget|python-requests|go-http-client|okhttp|java/`)
+
+// IsBot 判断请求是否来自爬虫，没有 User-Agent 的请求也视为爬虫
+func IsBot(userAgent string) bool {
+	return userAgent == "" || botPattern.MatchString(userAgent)
+}
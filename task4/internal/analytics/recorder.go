@@ -0,0 +1,238 @@
+// Package analytics 在内存中汇总文章浏览量并批量写入存储
+package analytics
+
+import (
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"blog-system/pkg/logger"
+)
+
+// DirectReferrer 表示没有来源或来自本站的访问
+const DirectReferrer = "(direct)"
+
+const maxReferrerLength = 255
+
+// ViewCount 是一个批次中某篇文章某天来自某个来源的浏览量
+type ViewCount struct {
+	PostID   uint
+	Day      time.Time
+	Referrer string
+	Views    int64
+}
+
+// Store 持久化浏览量，由服务层基于数据库实现
+type Store interface {
+	SaveViews(counts []ViewCount) error
+}
+
+// View 是一次文章访问
+type View struct {
+	PostID    uint
+	AuthorID  uint
+	ViewerID  uint
+	IP        string
+	UserAgent string
+	Referer   string
+	Host      string
+}
+
+type Config struct {
+	// DedupeWindow 内同一访客重复访问同一篇文章只计一次
+	DedupeWindow  time.Duration
+	FlushInterval time.Duration
+	// MaxBuffer 是缓冲的统计条目上限，达到后立即写入
+	MaxBuffer int
+}
+
+type countKey struct {
+	postID   uint
+	day      time.Time
+	referrer string
+}
+
+// Recorder 过滤爬虫和重复访问，并定期把累计的浏览量写入 Store
+type Recorder struct {
+	cfg   Config
+	store Store
+
+	mu     sync.Mutex
+	seen   map[string]time.Time
+	counts map[countKey]int64
+
+	flushNow chan struct{}
+	done     chan struct{}
+	stopped  chan struct{}
+	once     sync.Once
+}
+
+func NewRecorder(cfg Config, store Store) *Recorder {
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = 10 * time.Second
+	}
+	if cfg.MaxBuffer <= 0 {
+		cfg.MaxBuffer = 1000
+	}
+
+	r := &Recorder{
+		cfg:      cfg,
+		store:    store,
+		seen:     make(map[string]time.Time),
+		counts:   make(map[countKey]int64),
+		flushNow: make(chan struct{}, 1),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	go r.loop()
+	return r
+}
+
+// visitorKey 登录用户按用户ID去重，匿名访客按 IP 和 User-Agent 去重
+func visitorKey(v *View) string {
+	if v.ViewerID != 0 {
+		return "u:" + strconv.FormatUint(uint64(v.ViewerID), 10)
+	}
+	return "a:" + v.IP + "|" + v.UserAgent
+}
+
+// normalizeReferrer 只保留来源域名，站内跳转和无法解析的来源记为直接访问
+func normalizeReferrer(referer, host string) string {
+	if referer == "" {
+		return DirectReferrer
+	}
+
+	u, err := url.Parse(referer)
+	if err != nil || u.Host == "" {
+		return DirectReferrer
+	}
+
+	refHost := strings.ToLower(u.Hostname())
+	if refHost == strings.ToLower(hostname(host)) {
+		return DirectReferrer
+	}
+
+	refHost = strings.TrimPrefix(refHost, "www.")
+	if len(refHost) > maxReferrerLength {
+		refHost = refHost[:maxReferrerLength]
+	}
+	return refHost
+}
+
+func hostname(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+	return host
+}
+
+// Record 记录一次访问，返回是否被计入。作者本人、爬虫和窗口内的重复访问不计入
+func (r *Recorder) Record(v *View) bool {
+	if v.ViewerID != 0 && v.ViewerID == v.AuthorID {
+		return false
+	}
+	if IsBot(v.UserAgent) {
+		return false
+	}
+
+	now := time.Now()
+	key := visitorKey(v) + "#" + strconv.FormatUint(uint64(v.PostID), 10)
+
+	r.mu.Lock()
+	if last, ok := r.seen[key]; ok && now.Sub(last) < r.cfg.DedupeWindow {
+		r.mu.Unlock()
+		return false
+	}
+	r.seen[key] = now
+
+	ck := countKey{
+		postID:   v.PostID,
+		day:      truncateDay(now),
+		referrer: normalizeReferrer(v.Referer, v.Host),
+	}
+	r.counts[ck]++
+	full := len(r.counts) >= r.cfg.MaxBuffer
+	r.mu.Unlock()
+
+	if full {
+		select {
+		case r.flushNow <- struct{}{}:
+		default:
+		}
+	}
+	return true
+}
+
+func truncateDay(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func (r *Recorder) loop() {
+	defer close(r.stopped)
+
+	ticker := time.NewTicker(r.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.done:
+			r.Flush()
+			return
+		case <-ticker.C:
+			r.Flush()
+		case <-r.flushNow:
+			r.Flush()
+		}
+	}
+}
+
+// Flush 写入当前缓冲的浏览量，写入失败的数据会放回缓冲等待下次重试
+func (r *Recorder) Flush() {
+	r.mu.Lock()
+	counts := r.counts
+	r.counts = make(map[countKey]int64)
+
+	cutoff := time.Now().Add(-r.cfg.DedupeWindow)
+	for key, last := range r.seen {
+		if last.Before(cutoff) {
+			delete(r.seen, key)
+		}
+	}
+	r.mu.Unlock()
+
+	if len(counts) == 0 {
+		return
+	}
+
+	batch := make([]ViewCount, 0, len(counts))
+	for key, views := range counts {
+		batch = append(batch, ViewCount{
+			PostID:   key.postID,
+			Day:      key.day,
+			Referrer: key.referrer,
+			Views:    views,
+		})
+	}
+
+	if err := r.store.SaveViews(batch); err != nil {
+		logger.Error("Failed to flush post views", "error", err)
+
+		r.mu.Lock()
+		for key, views := range counts {
+			r.counts[key] += views
+		}
+		r.mu.Unlock()
+	}
+}
+
+// Close 停止定时写入并写入剩余的浏览量
+func (r *Recorder) Close() {
+	r.once.Do(func() {
+		close(r.done)
+		<-r.stopped
+	})
+}
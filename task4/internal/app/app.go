@@ -0,0 +1,126 @@
+package app
+
+import (
+	"time"
+
+	"blog-system/config"
+	"blog-system/internal/analytics"
+	"blog-system/internal/events"
+	"blog-system/internal/metrics"
+	"blog-system/internal/repository"
+	"blog-system/internal/services"
+	"blog-system/internal/storage"
+	"blog-system/pkg/auth"
+	"blog-system/pkg/clock"
+	"blog-system/pkg/database"
+	"blog-system/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// Services 是应用用到的全部业务服务，由 New 按依赖顺序创建
+type Services struct {
+	Users         *services.UserService
+	Posts         *services.PostService
+	Comments      *services.CommentService
+	Moderation    *services.ModerationService
+	Notifications *services.NotificationService
+	Reactions     *services.ReactionService
+	Bookmarks     *services.BookmarkService
+	Analytics     *services.AnalyticsService
+	Feeds         *services.FeedService
+	Sitemap       *services.SitemapService
+	Uploads       *services.UploadService
+	Purge         *services.PurgeService
+}
+
+// App 持有一个应用实例的全部依赖，同一进程内可以创建多个使用不同数据库的实例
+type App struct {
+	Config  *config.Config
+	DB      *gorm.DB
+	Logger  *logger.Logger
+	Clock   clock.Clock
+	Tokens  *auth.TokenIssuer
+	Broker  events.Broker
+	Views   *analytics.Recorder
+	Storage storage.Storage
+	// Metrics 在 METRICS_ENABLED=false 时为 nil，记录指标的方法在 nil 上不做任何事
+	Metrics *metrics.Metrics
+
+	Repos    repository.Repositories
+	Services Services
+}
+
+// Options 覆盖 New 默认创建的依赖，零值字段使用默认实现
+type Options struct {
+	Logger  *logger.Logger
+	Clock   clock.Clock
+	Broker  events.Broker
+	Storage storage.Storage
+}
+
+// New 基于已连接的数据库创建应用，调用方负责在退出前 Close
+func New(cfg *config.Config, db *gorm.DB, opts Options) (*App, error) {
+	a := &App{
+		Config:  cfg,
+		Logger:  opts.Logger,
+		Clock:   opts.Clock,
+		Broker:  opts.Broker,
+		Storage: opts.Storage,
+	}
+	if a.Logger == nil {
+		a.Logger = logger.Default()
+	}
+	if a.Clock == nil {
+		a.Clock = clock.Real()
+	}
+	if a.Broker == nil {
+		a.Broker = events.NewMemoryBroker()
+	}
+	if a.Storage == nil {
+		store, err := services.NewStorage(cfg.Upload)
+		if err != nil {
+			return nil, err
+		}
+		a.Storage = store
+	}
+
+	// created_at 等自动时间戳也取自应用时钟
+	clk := a.Clock
+	a.DB = db.Session(&gorm.Session{NowFunc: func() time.Time { return clk.Now().Local() }})
+	a.Tokens = auth.NewTokenIssuer(cfg.JWT.Secret, cfg.JWT.TTL, a.Clock)
+	a.Views = services.NewViewRecorder(a.DB, cfg.Analytics)
+	if cfg.Metrics.Enabled {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, err
+		}
+		a.Metrics = metrics.New(sqlDB, database.ReplicasOf(db))
+	}
+
+	a.Repos = repository.NewGorm(a.DB)
+	activity := services.NewActivity(a.DB)
+
+	posts := services.NewPostService(a.Repos.Posts, activity)
+	a.Services = Services{
+		Users:         services.NewUserService(a.Repos.Users, a.Tokens),
+		Posts:         posts,
+		Comments:      services.NewCommentService(a.Repos, activity, a.Clock, cfg.Comment, a.Broker),
+		Moderation:    services.NewModerationService(a.Repos, activity, a.Broker),
+		Notifications: services.NewNotificationService(a.DB, a.Clock),
+		Reactions:     services.NewReactionService(a.DB, a.Clock),
+		Bookmarks:     services.NewBookmarkService(a.DB),
+		Analytics:     services.NewAnalyticsService(a.DB, a.Clock),
+		Feeds:         services.NewFeedService(a.DB, posts, cfg.Site, cfg.Feed),
+		Sitemap:       services.NewSitemapService(a.DB, cfg.Site, cfg.Sitemap),
+		Uploads:       services.NewUploadService(a.DB, cfg.Upload, a.Storage),
+		Purge:         services.NewPurgeService(a.DB, a.Storage),
+	}
+
+	return a, nil
+}
+
+// Close 写入尚未保存的浏览量，数据库连接由打开它的一方关闭
+func (a *App) Close() {
+	a.Views.Close()
+}
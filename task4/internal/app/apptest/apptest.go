@@ -0,0 +1,218 @@
+// Package apptest 在临时数据库上创建完整的应用，供 HTTP 级别的测试使用
+package apptest
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"blog-system/config"
+	"blog-system/internal/app"
+	"blog-system/internal/routes"
+	"blog-system/pkg/clock"
+	"blog-system/pkg/database"
+	"blog-system/pkg/logger"
+	"blog-system/pkg/tracing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Env 是连接临时数据库的应用实例
+type Env struct {
+	App    *app.App
+	Router *gin.Engine
+	// Clock 是应用使用的时钟，测试可以拨动它验证编辑时限、令牌过期等
+	Clock *clock.Fixed
+}
+
+// New 在临时数据库上执行迁移并创建应用，测试结束后删除数据库。
+//
+// TEST_DB_DRIVER 默认为 sqlite，数据库文件放在测试的临时目录中，不需要外部服务；
+// 为 postgres 或 mysql 时在 TEST_DB_HOST 指向的服务器上创建临时数据库，没有设置 TEST_DB_HOST 时跳过测试。
+// 连接参数来自 TEST_DB_HOST、TEST_DB_PORT、TEST_DB_USER、TEST_DB_PASSWORD，
+// TEST_DB_NAME 是用来创建临时数据库的已有数据库，PostgreSQL 默认 postgres，MySQL 默认 mysql。
+//
+// TEST_TRACING=stdout 时把请求、服务和 SQL 的 span 输出到测试日志，用 go test -v 查看
+func New(tb testing.TB) *Env {
+	tb.Helper()
+
+	cfg, _, err := config.Load(nil)
+	if err != nil {
+		tb.Fatalf("load config: %v", err)
+	}
+	cfg.Upload.Driver = "local"
+	cfg.Upload.LocalDir = tb.TempDir()
+	cfg.Analytics.FlushInterval = time.Hour
+
+	log := logger.New(testWriter{tb}, logger.Options{Level: "debug"})
+
+	if exporter := getEnv("TEST_TRACING", tracing.ExporterNone); exporter != tracing.ExporterNone {
+		cfg.Tracing.Exporter = exporter
+		cfg.Tracing.File = ""
+		shutdown, err := tracing.Setup(context.Background(), cfg.Tracing, testWriter{tb})
+		if err != nil {
+			tb.Fatalf("set up tracing: %v", err)
+		}
+		tb.Cleanup(func() { shutdown(context.Background()) })
+	}
+
+	driver := getEnv("TEST_DB_DRIVER", database.DriverSQLite)
+	if driver == database.DriverSQLite {
+		cfg.Database = config.DatabaseConfig{
+			Driver: driver,
+			Path:   filepath.Join(tb.TempDir(), "blog_test.db"),
+		}
+	} else {
+		cfg.Database = createDatabase(tb, cfg, driver)
+	}
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		tb.Fatalf("connect to test database: %v", err)
+	}
+	// Cleanup 按注册的逆序执行，连接在删除数据库之前关闭
+	tb.Cleanup(func() { database.Close(db) })
+
+	runner, err := database.NewMigrator(db)
+	if err != nil {
+		tb.Fatalf("create migrator: %v", err)
+	}
+	runner.Out = io.Discard
+	if _, err := runner.Up(); err != nil {
+		tb.Fatalf("migrate test database: %v", err)
+	}
+
+	clk := clock.NewFixed(time.Now().Truncate(time.Second))
+	a, err := app.New(cfg, db, app.Options{Logger: log, Clock: clk})
+	if err != nil {
+		tb.Fatalf("create application: %v", err)
+	}
+	tb.Cleanup(a.Close)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	routes.SetupRoutes(r, a)
+
+	return &Env{App: a, Router: r, Clock: clk}
+}
+
+// createDatabase 在 PostgreSQL 或 MySQL 服务器上创建随机命名的数据库，测试结束后删除
+func createDatabase(tb testing.TB, cfg *config.Config, driver string) config.DatabaseConfig {
+	tb.Helper()
+
+	host := os.Getenv("TEST_DB_HOST")
+	if host == "" {
+		tb.Skip("TEST_DB_HOST not set, skipping database test")
+	}
+
+	defaultPort, defaultUser, defaultName := "5432", "postgres", "postgres"
+	if driver == database.DriverMySQL {
+		defaultPort, defaultUser, defaultName = "3306", "root", "mysql"
+	}
+
+	adminCfg := *cfg
+	adminCfg.Database = config.DatabaseConfig{
+		Driver:   driver,
+		Host:     host,
+		Port:     getEnv("TEST_DB_PORT", defaultPort),
+		User:     getEnv("TEST_DB_USER", defaultUser),
+		Password: os.Getenv("TEST_DB_PASSWORD"),
+		Name:     getEnv("TEST_DB_NAME", defaultName),
+		SSLMode:  "disable",
+		TimeZone: "UTC",
+	}
+
+	admin, err := database.Open(&adminCfg)
+	if err != nil {
+		tb.Fatalf("connect to test database server: %v", err)
+	}
+	defer database.Close(admin)
+
+	name := "blog_test_" + randomSuffix(tb)
+	if err := admin.Exec("CREATE DATABASE " + name).Error; err != nil {
+		tb.Fatalf("create test database: %v", err)
+	}
+	tb.Cleanup(func() {
+		admin, err := database.Open(&adminCfg)
+		if err != nil {
+			tb.Errorf("connect to test database server: %v", err)
+			return
+		}
+		defer database.Close(admin)
+		if err := admin.Exec("DROP DATABASE IF EXISTS " + name).Error; err != nil {
+			tb.Errorf("drop test database %s: %v", name, err)
+		}
+	})
+
+	dbCfg := adminCfg.Database
+	dbCfg.Name = name
+	return dbCfg
+}
+
+// Do 发送请求并返回响应，body 不为 nil 时编码为 JSON，token 不为空时作为 Bearer 令牌
+func (e *Env) Do(method, path string, body any, token string) *httptest.ResponseRecorder {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			panic(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	e.Router.ServeHTTP(w, req)
+	return w
+}
+
+// Token 为用户签发访问令牌
+func (e *Env) Token(tb testing.TB, userID uint, username string) string {
+	tb.Helper()
+	token, err := e.App.Tokens.Issue(userID, username)
+	if err != nil {
+		tb.Fatalf("issue token: %v", err)
+	}
+	return token
+}
+
+// testWriter 把应用日志写到测试输出，只在测试失败或 -v 时显示
+type testWriter struct {
+	tb testing.TB
+}
+
+func (w testWriter) Write(p []byte) (int, error) {
+	w.tb.Log(strings.TrimRight(string(p), "\n"))
+	return len(p), nil
+}
+
+func randomSuffix(tb testing.TB) string {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		tb.Fatalf("generate database name: %v", err)
+	}
+	return hex.EncodeToString(b)
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,84 @@
+// Package events 负责把评论变更实时推送给正在阅读文章的客户端
+package events
+
+import (
+	"sync"
+
+	"blog-system/internal/models"
+	"blog-system/pkg/logger"
+)
+
+const (
+	CommentCreated = "comment_created"
+	CommentEdited  = "comment_edited"
+	CommentDeleted = "comment_deleted"
+)
+
+// subscriberBuffer 是每个订阅者的事件缓冲，消费过慢的订阅者会丢弃事件
+const subscriberBuffer = 32
+
+// Event 必须可以序列化为 JSON，以便跨进程的 Broker 实现传递
+type Event struct {
+	Type      string                  `json:"type"`
+	PostID    uint                    `json:"post_id"`
+	CommentID uint                    `json:"comment_id"`
+	Comment   *models.CommentResponse `json:"comment,omitempty"`
+}
+
+// Broker 按文章分发评论事件。多副本部署时可以用 PostgreSQL LISTEN/NOTIFY
+// 实现：Publish 执行 NOTIFY，每个副本 LISTEN 后再交给本地的 MemoryBroker 分发
+type Broker interface {
+	Publish(event Event) error
+	// Subscribe 返回事件通道和取消订阅函数，取消后通道会被关闭
+	Subscribe(postID uint) (<-chan Event, func())
+}
+
+// MemoryBroker 是进程内的 Broker，只能分发本进程发布的事件
+type MemoryBroker struct {
+	mu          sync.RWMutex
+	subscribers map[uint]map[chan Event]struct{}
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{
+		subscribers: make(map[uint]map[chan Event]struct{}),
+	}
+}
+
+func (b *MemoryBroker) Publish(event Event) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for ch := range b.subscribers[event.PostID] {
+		select {
+		case ch <- event:
+		default:
+			logger.Warn("Dropping comment event for slow subscriber", "post_id", event.PostID)
+		}
+	}
+	return nil
+}
+
+func (b *MemoryBroker) Subscribe(postID uint) (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	b.mu.Lock()
+	if b.subscribers[postID] == nil {
+		b.subscribers[postID] = make(map[chan Event]struct{})
+	}
+	b.subscribers[postID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers[postID], ch)
+			if len(b.subscribers[postID]) == 0 {
+				delete(b.subscribers, postID)
+			}
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+}
@@ -0,0 +1,81 @@
+// Package feed 把文章渲染为 RSS 2.0、Atom 1.0 和 JSON Feed 1.1
+package feed
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	FormatRSS  = "rss"
+	FormatAtom = "atom"
+	FormatJSON = "json"
+)
+
+var ContentTypes = map[string]string{
+	FormatRSS:  "application/rss+xml; charset=utf-8",
+	FormatAtom: "application/atom+xml; charset=utf-8",
+	FormatJSON: "application/feed+json; charset=utf-8",
+}
+
+type Feed struct {
+	Title       string
+	Description string
+	// Link 是网站首页，FeedURL 是订阅源自身的地址
+	Link    string
+	FeedURL string
+	Updated time.Time
+	Items   []Item
+}
+
+type Item struct {
+	ID         string
+	Title      string
+	Link       string
+	Author     string
+	Summary    string
+	Content    string
+	Categories []string
+	Published  time.Time
+	Updated    time.Time
+}
+
+// TagURI 生成 RFC 4151 格式的永久ID，网站地址路径变化时ID保持不变
+func TagURI(siteURL string, created time.Time, specific string) string {
+	host := siteURL
+	if u, err := url.Parse(siteURL); err == nil && u.Hostname() != "" {
+		host = u.Hostname()
+	}
+	return fmt.Sprintf("tag:%s,%s:%s", host, created.UTC().Format("2006-01-02"), specific)
+}
+
+// Excerpt 截取前 n 个字符作为摘要，优先在空白处截断
+func Excerpt(content string, n int) string {
+	content = strings.Join(strings.Fields(content), " ")
+	if utf8.RuneCountInString(content) <= n {
+		return content
+	}
+
+	runes := []rune(content)[:n]
+	cut := string(runes)
+	if i := strings.LastIndexByte(cut, ' '); i > len(cut)/2 {
+		cut = cut[:i]
+	}
+	return cut + "…"
+}
+
+func Render(format string, f *Feed) ([]byte, error) {
+	switch format {
+	case FormatRSS:
+		return renderRSS(f)
+	case FormatAtom:
+		return renderAtom(f)
+	case FormatJSON:
+		return renderJSON(f)
+	default:
+		return nil, fmt.Errorf("unknown feed format %q", format)
+	}
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"blog-system/internal/services"
+	"blog-system/internal/utils"
+	"blog-system/pkg/logger"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AnalyticsHandler struct {
+	analyticsService *services.AnalyticsService
+}
+
+func NewAnalyticsHandler(analyticsService *services.AnalyticsService) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		analyticsService: analyticsService,
+	}
+}
+
+func (h *AnalyticsHandler) GetAnalytics(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	days, _ := strconv.Atoi(c.Query("days"))
+
+	var postID uint64
+	if postIDStr := c.Query("post_id"); postIDStr != "" {
+		var err error
+		if postID, err = strconv.ParseUint(postIDStr, 10, 32); err != nil {
+			utils.BadRequest(c, "无效的文章ID")
+			return
+		}
+	}
+
+	result, err := h.analyticsService.GetAnalytics(c.Request.Context(), userID.(uint), uint(postID), days)
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "Get analytics failed", "error", err)
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.Success(c, result)
+}
@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"blog-system/internal/models"
+	"blog-system/internal/services"
+	"blog-system/internal/utils"
+	"blog-system/pkg/logger"
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BookmarkHandler struct {
+	bookmarkService *services.BookmarkService
+}
+
+func NewBookmarkHandler(bookmarkService *services.BookmarkService) *BookmarkHandler {
+	return &BookmarkHandler{
+		bookmarkService: bookmarkService,
+	}
+}
+
+func (h *BookmarkHandler) GetBookmarks(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	var listID uint64
+	if listIDStr := c.Query("list_id"); listIDStr != "" {
+		var err error
+		if listID, err = strconv.ParseUint(listIDStr, 10, 32); err != nil {
+			utils.BadRequest(c, "无效的书单ID")
+			return
+		}
+	}
+
+	bookmarks, page, err := h.bookmarkService.GetBookmarks(c.Request.Context(), userID.(uint), uint(listID), services.ParsePageQuery(c.Request.URL.Query()))
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "Get bookmarks failed", "error", err)
+		if errors.Is(err, services.ErrInvalidPageQuery) {
+			utils.BadRequest(c, err.Error())
+			return
+		}
+		utils.NotFound(c, err.Error())
+		return
+	}
+
+	utils.Success(c, gin.H{
+		"bookmarks": bookmarks,
+		"page":      page,
+	})
+}
+
+func (h *BookmarkHandler) AddBookmark(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	var req models.BookmarkCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.ErrorContext(c.Request.Context(), "Invalid request data", "error", err)
+		utils.BadRequest(c, "请求数据格式错误")
+		return
+	}
+
+	bookmark, err := h.bookmarkService.AddBookmark(c.Request.Context(), userID.(uint), &req)
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "Add bookmark failed", "error", err)
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "收藏成功", bookmark)
+}
+
+func (h *BookmarkHandler) RemoveBookmark(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "无效的收藏ID")
+		return
+	}
+
+	if err := h.bookmarkService.RemoveBookmark(c.Request.Context(), uint(id), userID.(uint)); err != nil {
+		logger.ErrorContext(c.Request.Context(), "Remove bookmark failed", "error", err)
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "已取消收藏", nil)
+}
+
+func (h *BookmarkHandler) GetMyReadingLists(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	lists, err := h.bookmarkService.GetReadingLists(c.Request.Context(), userID.(uint), userID.(uint))
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "Get reading lists failed", "error", err)
+		utils.InternalServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, lists)
+}
+
+func (h *BookmarkHandler) GetUserReadingLists(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "无效的用户ID")
+		return
+	}
+
+	lists, err := h.bookmarkService.GetReadingLists(c.Request.Context(), uint(id), currentUserID(c))
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "Get reading lists failed", "error", err)
+		utils.InternalServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, lists)
+}
+
+func (h *BookmarkHandler) GetReadingList(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "无效的书单ID")
+		return
+	}
+
+	list, bookmarks, page, err := h.bookmarkService.GetReadingListBookmarks(c.Request.Context(), uint(id), currentUserID(c), services.ParsePageQuery(c.Request.URL.Query()))
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "Get reading list failed", "error", err)
+		if errors.Is(err, services.ErrInvalidPageQuery) {
+			utils.BadRequest(c, err.Error())
+			return
+		}
+		utils.NotFound(c, err.Error())
+		return
+	}
+
+	utils.Success(c, gin.H{
+		"list":      list,
+		"bookmarks": bookmarks,
+		"page":      page,
+	})
+}
+
+func (h *BookmarkHandler) CreateReadingList(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	var req models.ReadingListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.ErrorContext(c.Request.Context(), "Invalid request data", "error", err)
+		utils.BadRequest(c, "请求数据格式错误")
+		return
+	}
+
+	list, err := h.bookmarkService.CreateReadingList(c.Request.Context(), userID.(uint), &req)
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "Create reading list failed", "error", err)
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "书单创建成功", list)
+}
+
+func (h *BookmarkHandler) UpdateReadingList(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "无效的书单ID")
+		return
+	}
+
+	var req models.ReadingListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.ErrorContext(c.Request.Context(), "Invalid request data", "error", err)
+		utils.BadRequest(c, "请求数据格式错误")
+		return
+	}
+
+	list, err := h.bookmarkService.UpdateReadingList(c.Request.Context(), uint(id), userID.(uint), &req)
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "Update reading list failed", "error", err)
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "书单更新成功", list)
+}
+
+func (h *BookmarkHandler) DeleteReadingList(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "无效的书单ID")
+		return
+	}
+
+	if err := h.bookmarkService.DeleteReadingList(c.Request.Context(), uint(id), userID.(uint)); err != nil {
+		logger.ErrorContext(c.Request.Context(), "Delete reading list failed", "error", err)
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "书单删除成功", nil)
+}
+
+func (h *BookmarkHandler) ReorderBookmarks(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "无效的书单ID")
+		return
+	}
+
+	var req models.BookmarkReorderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.ErrorContext(c.Request.Context(), "Invalid request data", "error", err)
+		utils.BadRequest(c, "请求数据格式错误")
+		return
+	}
+
+	if err := h.bookmarkService.ReorderBookmarks(c.Request.Context(), uint(id), userID.(uint), req.BookmarkIDs); err != nil {
+		logger.ErrorContext(c.Request.Context(), "Reorder bookmarks failed", "error", err)
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "顺序已更新", nil)
+}
@@ -1,10 +1,12 @@
 package handlers
 
 import (
+	"blog-system/internal/metrics"
 	"blog-system/internal/models"
 	"blog-system/internal/services"
 	"blog-system/internal/utils"
 	"blog-system/pkg/logger"
+	"errors"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -12,11 +14,13 @@ import (
 
 type CommentHandler struct {
 	commentService *services.CommentService
+	metrics        *metrics.Metrics
 }
 
-func NewCommentHandler() *CommentHandler {
+func NewCommentHandler(commentService *services.CommentService, m *metrics.Metrics) *CommentHandler {
 	return &CommentHandler{
-		commentService: services.NewCommentService(),
+		commentService: commentService,
+		metrics:        m,
 	}
 }
 
@@ -29,18 +33,24 @@ func (h *CommentHandler) CreateComment(c *gin.Context) {
 
 	var req models.CommentCreateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		logger.Error("Invalid request data:", err)
+		logger.ErrorContext(c.Request.Context(), "Invalid request data", "error", err)
 		utils.BadRequest(c, "请求数据格式错误")
 		return
 	}
 
-	comment, err := h.commentService.CreateComment(userID.(uint), &req)
+	comment, err := h.commentService.CreateComment(c.Request.Context(), userID.(uint), &req)
 	if err != nil {
-		logger.Error("Create comment failed:", err)
+		logger.ErrorContext(c.Request.Context(), "Create comment failed", "error", err)
 		utils.BadRequest(c, err.Error())
 		return
 	}
 
+	h.metrics.CommentCreated(comment.Status)
+	if comment.Status == models.CommentStatusPending {
+		utils.SuccessWithMessage(c, "评论已提交，等待审核", comment)
+		return
+	}
+
 	utils.SuccessWithMessage(c, "评论创建成功", comment)
 }
 
@@ -52,14 +62,76 @@ func (h *CommentHandler) GetCommentsByPostID(c *gin.Context) {
 		return
 	}
 
-	comments, err := h.commentService.GetCommentsByPostID(uint(postID))
+	comments, page, err := h.commentService.GetCommentsByPostID(c.Request.Context(), uint(postID), currentUserID(c), c.Query("view"), services.ParsePageQuery(c.Request.URL.Query()))
 	if err != nil {
-		logger.Error("Get comments failed:", err)
+		logger.ErrorContext(c.Request.Context(), "Get comments failed", "error", err)
+		if errors.Is(err, services.ErrInvalidPageQuery) {
+			utils.BadRequest(c, err.Error())
+			return
+		}
 		utils.NotFound(c, err.Error())
 		return
 	}
 
-	utils.Success(c, comments)
+	utils.Success(c, gin.H{
+		"comments": comments,
+		"page":     page,
+	})
+}
+
+func (h *CommentHandler) UpdateComment(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	commentIDStr := c.Param("id")
+	commentID, err := strconv.ParseUint(commentIDStr, 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "无效的评论ID")
+		return
+	}
+
+	var req models.CommentUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.ErrorContext(c.Request.Context(), "Invalid request data", "error", err)
+		utils.BadRequest(c, "请求数据格式错误")
+		return
+	}
+
+	comment, err := h.commentService.UpdateComment(c.Request.Context(), uint(commentID), userID.(uint), &req)
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "Update comment failed", "error", err)
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "评论更新成功", comment)
+}
+
+func (h *CommentHandler) GetCommentHistory(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	commentIDStr := c.Param("id")
+	commentID, err := strconv.ParseUint(commentIDStr, 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "无效的评论ID")
+		return
+	}
+
+	revisions, err := h.commentService.GetCommentHistory(c.Request.Context(), uint(commentID), userID.(uint))
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "Get comment history failed", "error", err)
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.Success(c, revisions)
 }
 
 func (h *CommentHandler) DeleteComment(c *gin.Context) {
@@ -76,12 +148,60 @@ func (h *CommentHandler) DeleteComment(c *gin.Context) {
 		return
 	}
 
-	err = h.commentService.DeleteComment(uint(commentID), userID.(uint))
+	err = h.commentService.DeleteComment(c.Request.Context(), uint(commentID), userID.(uint))
 	if err != nil {
-		logger.Error("Delete comment failed:", err)
+		logger.ErrorContext(c.Request.Context(), "Delete comment failed", "error", err)
 		utils.BadRequest(c, err.Error())
 		return
 	}
 
 	utils.SuccessWithMessage(c, "评论删除成功", nil)
 }
+
+func (h *CommentHandler) HideComment(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	commentIDStr := c.Param("id")
+	commentID, err := strconv.ParseUint(commentIDStr, 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "无效的评论ID")
+		return
+	}
+
+	comment, err := h.commentService.HideComment(c.Request.Context(), uint(commentID), userID.(uint))
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "Hide comment failed", "error", err)
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "评论已隐藏", comment)
+}
+
+func (h *CommentHandler) UnhideComment(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	commentIDStr := c.Param("id")
+	commentID, err := strconv.ParseUint(commentIDStr, 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "无效的评论ID")
+		return
+	}
+
+	comment, err := h.commentService.UnhideComment(c.Request.Context(), uint(commentID), userID.(uint))
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "Unhide comment failed", "error", err)
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "评论已取消隐藏", comment)
+}
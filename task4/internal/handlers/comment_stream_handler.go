@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"blog-system/internal/events"
+	"blog-system/internal/utils"
+	"blog-system/pkg/logger"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// streamHeartbeat 定期发送心跳，避免空闲连接被代理断开
+	streamHeartbeat  = 30 * time.Second
+	streamWriteWait  = 10 * time.Second
+	websocketMaxRead = 512
+)
+
+// 评论推送是只读的公开数据且不依赖 Cookie，与 CORS 设置一致允许任意来源
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+func (h *CommentHandler) subscribe(c *gin.Context) (<-chan events.Event, func(), bool) {
+	postIDStr := c.Param("id")
+	postID, err := strconv.ParseUint(postIDStr, 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "无效的文章ID")
+		return nil, nil, false
+	}
+
+	ch, cancel, err := h.commentService.SubscribeComments(c.Request.Context(), uint(postID))
+	if err != nil {
+		utils.NotFound(c, err.Error())
+		return nil, nil, false
+	}
+
+	return ch, cancel, true
+}
+
+// StreamComments 通过 Server-Sent Events 推送文章的评论变更
+func (h *CommentHandler) StreamComments(c *gin.Context) {
+	ch, cancel, ok := h.subscribe(c)
+	if !ok {
+		return
+	}
+	defer cancel()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-heartbeat.C:
+			_, err := io.WriteString(w, ": ping\n\n")
+			return err == nil
+		case event, ok := <-ch:
+			if !ok {
+				return false
+			}
+			c.SSEvent(event.Type, event)
+			return true
+		}
+	})
+}
+
+// CommentsWebSocket 通过 WebSocket 推送文章的评论变更，客户端发送的消息会被忽略
+func (h *CommentHandler) CommentsWebSocket(c *gin.Context) {
+	ch, cancel, ok := h.subscribe(c)
+	if !ok {
+		return
+	}
+	defer cancel()
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "WebSocket upgrade failed", "error", err)
+		return
+	}
+	defer conn.Close()
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		conn.SetReadLimit(websocketMaxRead)
+		conn.SetReadDeadline(time.Now().Add(streamHeartbeat * 2))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(streamHeartbeat * 2))
+		})
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteWait)); err != nil {
+				return
+			}
+		case event, ok := <-ch:
+			if !ok {
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(streamWriteWait))
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		}
+	}
+}
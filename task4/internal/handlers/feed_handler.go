@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"blog-system/internal/feed"
+	"blog-system/internal/services"
+	"blog-system/internal/utils"
+	"blog-system/pkg/logger"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FeedHandler struct {
+	feedService *services.FeedService
+}
+
+func NewFeedHandler(feedService *services.FeedService) *FeedHandler {
+	return &FeedHandler{
+		feedService: feedService,
+	}
+}
+
+// Serve 返回指定格式的订阅源，路径中的 username 和 slug 分别按作者和标签筛选，
+// 查询参数 content=full 或 content=excerpt 可以覆盖默认的全文设置
+func (h *FeedHandler) Serve(format string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		fullContent := h.feedService.FullContent()
+		switch c.Query("content") {
+		case "full":
+			fullContent = true
+		case "excerpt":
+			fullContent = false
+		}
+
+		filter := &services.PostQuery{
+			AuthorUsername: c.Param("username"),
+			Tag:            c.Param("slug"),
+		}
+
+		f, err := h.feedService.BuildFeed(c.Request.Context(), filter, fullContent, c.Request.URL.Path)
+		if err != nil {
+			logger.ErrorContext(c.Request.Context(), "Build feed failed", "error", err)
+			if errors.Is(err, services.ErrFeedNotFound) {
+				utils.NotFound(c, err.Error())
+				return
+			}
+			utils.InternalServerError(c, err.Error())
+			return
+		}
+
+		body, err := feed.Render(format, f)
+		if err != nil {
+			logger.ErrorContext(c.Request.Context(), "Render feed failed", "error", err)
+			utils.InternalServerError(c, "生成订阅源失败")
+			return
+		}
+
+		serveCached(c, feed.ContentTypes[format], body, f.Updated)
+	}
+}
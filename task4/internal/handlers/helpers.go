@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// currentUserID 返回可选认证下的当前用户ID，未登录时为0
+func currentUserID(c *gin.Context) uint {
+	if userID, exists := c.Get("user_id"); exists {
+		return userID.(uint)
+	}
+	return 0
+}
+
+// serveCached 输出可缓存的内容，ETag 由内容计算，If-None-Match 和
+// If-Modified-Since 命中时返回 304
+func serveCached(c *gin.Context, contentType string, body []byte, lastModified time.Time) {
+	sum := sha256.Sum256(body)
+	c.Header("Content-Type", contentType)
+	c.Header("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	c.Header("Cache-Control", "public, max-age=300")
+
+	http.ServeContent(c.Writer, c.Request, "", lastModified, bytes.NewReader(body))
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"blog-system/internal/models"
+	"blog-system/internal/services"
+	"blog-system/internal/utils"
+	"blog-system/pkg/logger"
+	"errors"
+	"io"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ModerationHandler struct {
+	moderationService *services.ModerationService
+}
+
+func NewModerationHandler(moderationService *services.ModerationService) *ModerationHandler {
+	return &ModerationHandler{
+		moderationService: moderationService,
+	}
+}
+
+func (h *ModerationHandler) GetQueue(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	comments, page, err := h.moderationService.GetQueue(c.Request.Context(), userID.(uint), c.Query("status"), services.ParsePageQuery(c.Request.URL.Query()))
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "Get moderation queue failed", "error", err)
+		if errors.Is(err, services.ErrInvalidPageQuery) {
+			utils.BadRequest(c, err.Error())
+			return
+		}
+		utils.InternalServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, gin.H{
+		"comments": comments,
+		"page":     page,
+	})
+}
+
+func (h *ModerationHandler) ApproveComment(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	commentIDStr := c.Param("id")
+	commentID, err := strconv.ParseUint(commentIDStr, 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "无效的评论ID")
+		return
+	}
+
+	comment, err := h.moderationService.ApproveComment(c.Request.Context(), uint(commentID), userID.(uint))
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "Approve comment failed", "error", err)
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "评论已通过审核", comment)
+}
+
+func (h *ModerationHandler) RejectComment(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	commentIDStr := c.Param("id")
+	commentID, err := strconv.ParseUint(commentIDStr, 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "无效的评论ID")
+		return
+	}
+
+	var req models.ModerationActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		logger.ErrorContext(c.Request.Context(), "Invalid request data", "error", err)
+		utils.BadRequest(c, "请求数据格式错误")
+		return
+	}
+
+	comment, err := h.moderationService.RejectComment(c.Request.Context(), uint(commentID), userID.(uint), req.Reason)
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "Reject comment failed", "error", err)
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "评论已拒绝", comment)
+}
+
+func (h *ModerationHandler) BanCommenter(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	commentIDStr := c.Param("id")
+	commentID, err := strconv.ParseUint(commentIDStr, 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "无效的评论ID")
+		return
+	}
+
+	var req models.ModerationActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		logger.ErrorContext(c.Request.Context(), "Invalid request data", "error", err)
+		utils.BadRequest(c, "请求数据格式错误")
+		return
+	}
+
+	ban, err := h.moderationService.BanCommenter(c.Request.Context(), uint(commentID), userID.(uint), req.Reason)
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "Ban commenter failed", "error", err)
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "已禁止该用户评论", ban)
+}
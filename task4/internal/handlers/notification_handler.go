@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"blog-system/internal/services"
+	"blog-system/internal/utils"
+	"blog-system/pkg/logger"
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationHandler struct {
+	notificationService *services.NotificationService
+}
+
+func NewNotificationHandler(notificationService *services.NotificationService) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+	}
+}
+
+func (h *NotificationHandler) GetNotifications(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	unreadOnly, _ := strconv.ParseBool(c.Query("unread"))
+
+	notifications, unread, page, err := h.notificationService.GetNotifications(c.Request.Context(), userID.(uint), unreadOnly, services.ParsePageQuery(c.Request.URL.Query()))
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "Get notifications failed", "error", err)
+		if errors.Is(err, services.ErrInvalidPageQuery) {
+			utils.BadRequest(c, err.Error())
+			return
+		}
+		utils.InternalServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, gin.H{
+		"notifications": notifications,
+		"unread_count":  unread,
+		"page":          page,
+	})
+}
+
+func (h *NotificationHandler) GetUnreadCount(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	unread, err := h.notificationService.CountUnread(c.Request.Context(), userID.(uint))
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "Get unread count failed", "error", err)
+		utils.InternalServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, gin.H{"unread_count": unread})
+}
+
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "无效的通知ID")
+		return
+	}
+
+	if err := h.notificationService.MarkRead(c.Request.Context(), uint(id), userID.(uint)); err != nil {
+		logger.ErrorContext(c.Request.Context(), "Mark notification read failed", "error", err)
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "已标记为已读", nil)
+}
+
+func (h *NotificationHandler) MarkAllRead(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	updated, err := h.notificationService.MarkAllRead(c.Request.Context(), userID.(uint))
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "Mark all notifications read failed", "error", err)
+		utils.InternalServerError(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "已全部标记为已读", gin.H{"updated": updated})
+}
+
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	preferences, err := h.notificationService.GetPreferences(c.Request.Context(), userID.(uint))
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "Get notification preferences failed", "error", err)
+		utils.InternalServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, preferences)
+}
+
+func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	var req map[string]bool
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.ErrorContext(c.Request.Context(), "Invalid request data", "error", err)
+		utils.BadRequest(c, "请求数据格式错误")
+		return
+	}
+
+	preferences, err := h.notificationService.UpdatePreferences(c.Request.Context(), userID.(uint), req)
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "Update notification preferences failed", "error", err)
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "通知设置已更新", preferences)
+}
@@ -1,10 +1,13 @@
 package handlers
 
 import (
+	"blog-system/internal/analytics"
+	"blog-system/internal/metrics"
 	"blog-system/internal/models"
 	"blog-system/internal/services"
 	"blog-system/internal/utils"
 	"blog-system/pkg/logger"
+	"errors"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -12,11 +15,15 @@ import (
 
 type PostHandler struct {
 	postService *services.PostService
+	views       *analytics.Recorder
+	metrics     *metrics.Metrics
 }
 
-func NewPostHandler() *PostHandler {
+func NewPostHandler(postService *services.PostService, views *analytics.Recorder, m *metrics.Metrics) *PostHandler {
 	return &PostHandler{
-		postService: services.NewPostService(),
+		postService: postService,
+		views:       views,
+		metrics:     m,
 	}
 }
 
@@ -29,18 +36,19 @@ func (h *PostHandler) CreatePost(c *gin.Context) {
 
 	var req models.PostCreateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		logger.Error("Invalid request data:", err)
+		logger.ErrorContext(c.Request.Context(), "Invalid request data", "error", err)
 		utils.BadRequest(c, "请求数据格式错误")
 		return
 	}
 
-	post, err := h.postService.CreatePost(userID.(uint), &req)
+	post, err := h.postService.CreatePost(c.Request.Context(), userID.(uint), &req)
 	if err != nil {
-		logger.Error("Create post failed:", err)
+		logger.ErrorContext(c.Request.Context(), "Create post failed", "error", err)
 		utils.BadRequest(c, err.Error())
 		return
 	}
 
+	h.metrics.PostCreated()
 	utils.SuccessWithMessage(c, "文章创建成功", post)
 }
 
@@ -52,43 +60,53 @@ func (h *PostHandler) GetPost(c *gin.Context) {
 		return
 	}
 
-	post, err := h.postService.GetPostByID(uint(id))
+	viewerID := currentUserID(c)
+	post, err := h.postService.GetPostByID(c.Request.Context(), uint(id), viewerID)
 	if err != nil {
-		logger.Error("Get post failed:", err)
+		logger.ErrorContext(c.Request.Context(), "Get post failed", "error", err)
 		utils.NotFound(c, err.Error())
 		return
 	}
 
+	if post.Status == models.PostStatusPublished {
+		h.views.Record(&analytics.View{
+			PostID:    post.ID,
+			AuthorID:  post.UserID,
+			ViewerID:  viewerID,
+			IP:        c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+			Referer:   c.Request.Referer(),
+			Host:      c.Request.Host,
+		})
+	}
+
 	utils.Success(c, post)
 }
 
 func (h *PostHandler) GetPosts(c *gin.Context) {
-	pageStr := c.DefaultQuery("page", "1")
-	pageSizeStr := c.DefaultQuery("page_size", "10")
-
-	page, err := strconv.Atoi(pageStr)
-	if err != nil || page < 1 {
-		page = 1
-	}
+	values := c.Request.URL.Query()
 
-	pageSize, err := strconv.Atoi(pageSizeStr)
-	if err != nil || pageSize < 1 || pageSize > 100 {
-		pageSize = 10
+	filter, err := services.ParsePostQuery(values)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
 	}
+	filter.ViewerID = currentUserID(c)
 
-	posts, total, err := h.postService.GetPosts(page, pageSize)
+	posts, page, err := h.postService.GetPosts(c.Request.Context(), filter, services.ParsePageQuery(values))
 	if err != nil {
-		logger.Error("Get posts failed:", err)
+		logger.ErrorContext(c.Request.Context(), "Get posts failed", "error", err)
+		if errors.Is(err, services.ErrInvalidPageQuery) || errors.Is(err, services.ErrInvalidPostQuery) {
+			utils.BadRequest(c, err.Error())
+			return
+		}
 		utils.InternalServerError(c, err.Error())
 		return
 	}
 
 	utils.Success(c, gin.H{
-		"posts":      posts,
-		"total":      total,
-		"page":       page,
-		"page_size":  pageSize,
-		"total_page": (total + int64(pageSize) - 1) / int64(pageSize),
+		"posts": posts,
+		"page":  page,
 	})
 }
 
@@ -108,14 +126,14 @@ func (h *PostHandler) UpdatePost(c *gin.Context) {
 
 	var req models.PostUpdateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		logger.Error("Invalid request data:", err)
+		logger.ErrorContext(c.Request.Context(), "Invalid request data", "error", err)
 		utils.BadRequest(c, "请求数据格式错误")
 		return
 	}
 
-	post, err := h.postService.UpdatePost(uint(id), userID.(uint), &req)
+	post, err := h.postService.UpdatePost(c.Request.Context(), uint(id), userID.(uint), &req)
 	if err != nil {
-		logger.Error("Update post failed:", err)
+		logger.ErrorContext(c.Request.Context(), "Update post failed", "error", err)
 		utils.BadRequest(c, err.Error())
 		return
 	}
@@ -137,9 +155,9 @@ func (h *PostHandler) DeletePost(c *gin.Context) {
 		return
 	}
 
-	err = h.postService.DeletePost(uint(id), userID.(uint))
+	err = h.postService.DeletePost(c.Request.Context(), uint(id), userID.(uint))
 	if err != nil {
-		logger.Error("Delete post failed:", err)
+		logger.ErrorContext(c.Request.Context(), "Delete post failed", "error", err)
 		utils.BadRequest(c, err.Error())
 		return
 	}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"blog-system/internal/models"
+	"blog-system/internal/services"
+	"blog-system/internal/utils"
+	"blog-system/pkg/logger"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReactionHandler struct {
+	reactionService *services.ReactionService
+}
+
+func NewReactionHandler(reactionService *services.ReactionService) *ReactionHandler {
+	return &ReactionHandler{
+		reactionService: reactionService,
+	}
+}
+
+func (h *ReactionHandler) toggle(c *gin.Context, targetType, invalidIDMessage string) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.BadRequest(c, invalidIDMessage)
+		return
+	}
+
+	var req models.ReactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.ErrorContext(c.Request.Context(), "Invalid request data", "error", err)
+		utils.BadRequest(c, "请求数据格式错误")
+		return
+	}
+
+	result, err := h.reactionService.ToggleReaction(c.Request.Context(), userID.(uint), targetType, uint(id), req.Type)
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "Toggle reaction failed", "error", err)
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.Success(c, result)
+}
+
+func (h *ReactionHandler) TogglePostReaction(c *gin.Context) {
+	h.toggle(c, models.ReactionTargetPost, "无效的文章ID")
+}
+
+func (h *ReactionHandler) ToggleCommentReaction(c *gin.Context) {
+	h.toggle(c, models.ReactionTargetComment, "无效的评论ID")
+}
+
+func (h *ReactionHandler) GetMostLiked(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	posts, err := h.reactionService.GetMostLiked(c.Request.Context(), currentUserID(c), limit)
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "Get most liked posts failed", "error", err)
+		utils.InternalServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, gin.H{"posts": posts})
+}
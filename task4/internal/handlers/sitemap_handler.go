@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"blog-system/config"
+	"blog-system/internal/services"
+	"blog-system/internal/utils"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const xmlContentType = "application/xml; charset=utf-8"
+
+type SitemapHandler struct {
+	sitemapService *services.SitemapService
+	robots         []byte
+}
+
+func NewSitemapHandler(sitemapService *services.SitemapService, robots config.RobotsConfig) *SitemapHandler {
+	return &SitemapHandler{
+		sitemapService: sitemapService,
+		robots:         sitemapService.RobotsTxt(robots),
+	}
+}
+
+// GetSitemap 返回站点地图，URL 数量超过上限时返回站点地图索引
+func (h *SitemapHandler) GetSitemap(c *gin.Context) {
+	file, err := h.sitemapService.Index(c.Request.Context())
+	if err != nil {
+		utils.InternalServerError(c, err.Error())
+		return
+	}
+
+	serveCached(c, xmlContentType, file.Body, file.LastModified)
+}
+
+// GetSitemapFile 返回站点地图索引中的分片，路径形如 /sitemaps/sitemap-1.xml
+func (h *SitemapHandler) GetSitemapFile(c *gin.Context) {
+	name := strings.TrimSuffix(strings.TrimPrefix(c.Param("name"), "sitemap-"), ".xml")
+	n, err := strconv.Atoi(name)
+	if err != nil {
+		utils.NotFound(c, services.ErrSitemapNotFound.Error())
+		return
+	}
+
+	file, err := h.sitemapService.File(c.Request.Context(), n)
+	if err != nil {
+		if errors.Is(err, services.ErrSitemapNotFound) {
+			utils.NotFound(c, err.Error())
+			return
+		}
+		utils.InternalServerError(c, err.Error())
+		return
+	}
+
+	serveCached(c, xmlContentType, file.Body, file.LastModified)
+}
+
+func (h *SitemapHandler) GetRobots(c *gin.Context) {
+	serveCached(c, "text/plain; charset=utf-8", h.robots, time.Time{})
+}
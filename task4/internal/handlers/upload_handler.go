@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"blog-system/internal/media"
+	"blog-system/internal/services"
+	"blog-system/internal/utils"
+	"blog-system/pkg/logger"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// multipartOverhead 为 multipart 请求中除文件内容外的边界和表单字段预留的空间
+const multipartOverhead = 1 << 20
+
+type UploadHandler struct {
+	uploadService *services.UploadService
+}
+
+func NewUploadHandler(uploadService *services.UploadService) *UploadHandler {
+	return &UploadHandler{
+		uploadService: uploadService,
+	}
+}
+
+// Upload 接收 multipart 表单中的 file 字段，可选的 post_id 字段会把附件直接关联到文章
+func (h *UploadHandler) Upload(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	limit, err := h.uploadService.MaxUploadSize(c.Request.Context(), userID.(uint))
+	if err != nil {
+		utils.InternalServerError(c, err.Error())
+		return
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit+multipartOverhead)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			utils.Error(c, http.StatusRequestEntityTooLarge, services.ErrUploadTooLarge.Error())
+			return
+		}
+		utils.BadRequest(c, "请选择要上传的文件")
+		return
+	}
+	if fileHeader.Size > limit {
+		utils.Error(c, http.StatusRequestEntityTooLarge, services.ErrUploadTooLarge.Error())
+		return
+	}
+
+	var postID *uint
+	if value := c.PostForm("post_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			utils.BadRequest(c, "无效的文章ID")
+			return
+		}
+		postID = new(uint)
+		*postID = uint(id)
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "Open upload failed", "error", err)
+		utils.BadRequest(c, "读取上传文件失败")
+		return
+	}
+	defer file.Close()
+
+	attachment, err := h.uploadService.Upload(c.Request.Context(), userID.(uint), limit, postID, fileHeader.Filename, file)
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "Upload failed", "error", err)
+		switch {
+		case errors.Is(err, services.ErrUploadTooLarge), errors.Is(err, media.ErrTooLarge):
+			utils.Error(c, http.StatusRequestEntityTooLarge, err.Error())
+		case errors.Is(err, services.ErrUnsupportedFileType), errors.Is(err, media.ErrUnsupported):
+			utils.Error(c, http.StatusUnsupportedMediaType, err.Error())
+		default:
+			utils.BadRequest(c, err.Error())
+		}
+		return
+	}
+
+	utils.SuccessWithMessage(c, "上传成功", attachment)
+}
+
+func (h *UploadHandler) DeleteAttachment(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "无效的附件ID")
+		return
+	}
+
+	if err := h.uploadService.DeleteAttachment(c.Request.Context(), uint(id), userID.(uint)); err != nil {
+		logger.ErrorContext(c.Request.Context(), "Delete attachment failed", "error", err)
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "附件删除成功", nil)
+}
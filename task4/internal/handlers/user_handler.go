@@ -1,57 +1,64 @@
 package handlers
 
 import (
+	"blog-system/internal/metrics"
 	"blog-system/internal/models"
 	"blog-system/internal/services"
 	"blog-system/internal/utils"
 	"blog-system/pkg/logger"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
 
 type UserHandler struct {
 	userService *services.UserService
+	metrics     *metrics.Metrics
 }
 
-func NewUserHandler() *UserHandler {
+func NewUserHandler(userService *services.UserService, m *metrics.Metrics) *UserHandler {
 	return &UserHandler{
-		userService: services.NewUserService(),
+		userService: userService,
+		metrics:     m,
 	}
 }
 
 func (h *UserHandler) Register(c *gin.Context) {
 	var req models.UserCreateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		logger.Error("Invalid request data:", err)
+		logger.ErrorContext(c.Request.Context(), "Invalid request data", "error", err)
 		utils.BadRequest(c, "请求数据格式错误")
 		return
 	}
 
-	user, err := h.userService.Register(&req)
+	user, err := h.userService.Register(c.Request.Context(), &req)
 	if err != nil {
-		logger.Error("Registration failed:", err)
+		logger.ErrorContext(c.Request.Context(), "Registration failed", "error", err)
 		utils.BadRequest(c, err.Error())
 		return
 	}
 
+	h.metrics.UserRegistered()
 	utils.SuccessWithMessage(c, "注册成功", user)
 }
 
 func (h *UserHandler) Login(c *gin.Context) {
 	var req models.UserLoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		logger.Error("Invalid request data:", err)
+		logger.ErrorContext(c.Request.Context(), "Invalid request data", "error", err)
 		utils.BadRequest(c, "请求数据格式错误")
 		return
 	}
 
-	user, token, err := h.userService.Login(&req)
+	user, token, err := h.userService.Login(c.Request.Context(), &req)
 	if err != nil {
-		logger.Error("Login failed:", err)
+		logger.ErrorContext(c.Request.Context(), "Login failed", "error", err)
+		h.metrics.Login(false)
 		utils.Unauthorized(c, err.Error())
 		return
 	}
 
+	h.metrics.Login(true)
 	utils.SuccessWithMessage(c, "登录成功", gin.H{
 		"user":  user,
 		"token": token,
@@ -65,12 +72,58 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.GetUserByID(userID.(uint))
+	user, err := h.userService.GetUserByID(c.Request.Context(), userID.(uint))
 	if err != nil {
-		logger.Error("Get profile failed:", err)
+		logger.ErrorContext(c.Request.Context(), "Get profile failed", "error", err)
 		utils.InternalServerError(c, err.Error())
 		return
 	}
 
 	utils.Success(c, user)
 }
+
+func (h *UserHandler) Follow(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "无效的用户ID")
+		return
+	}
+
+	if err := h.userService.Follow(c.Request.Context(), userID.(uint), uint(id)); err != nil {
+		logger.ErrorContext(c.Request.Context(), "Follow failed", "error", err)
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "关注成功", nil)
+}
+
+func (h *UserHandler) Unfollow(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "无效的用户ID")
+		return
+	}
+
+	if err := h.userService.Unfollow(c.Request.Context(), userID.(uint), uint(id)); err != nil {
+		logger.ErrorContext(c.Request.Context(), "Unfollow failed", "error", err)
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "已取消关注", nil)
+}
@@ -0,0 +1,157 @@
+// Package media 处理上传的图片：重新编码以去掉 EXIF 等元数据，并生成缩略图
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+const (
+	TypeJPEG = "image/jpeg"
+	TypePNG  = "image/png"
+	TypeGIF  = "image/gif"
+	TypeWebP = "image/webp"
+)
+
+var (
+	ErrUnsupported  = errors.New("不支持的图片格式")
+	ErrTooLarge     = errors.New("图片尺寸过大")
+	ErrInvalidImage = errors.New("图片已损坏")
+)
+
+type Options struct {
+	// MaxPixels 限制图片的像素数，防止解码时占用过多内存
+	MaxPixels int
+	// ThumbnailSize 为缩略图长边的像素数
+	ThumbnailSize int
+	JPEGQuality   int
+}
+
+type Result struct {
+	Data        []byte
+	ContentType string
+	Width       int
+	Height      int
+
+	Thumbnail     []byte
+	ThumbnailType string
+}
+
+func IsImage(contentType string) bool {
+	switch contentType {
+	case TypeJPEG, TypePNG, TypeGIF, TypeWebP:
+		return true
+	}
+	return false
+}
+
+// Process 解码图片并重新编码。JPEG 会先按 EXIF 方向旋转，WebP 转为 PNG，
+// GIF 保留全部帧
+func Process(data []byte, contentType string, opts Options) (*Result, error) {
+	if !IsImage(contentType) {
+		return nil, ErrUnsupported
+	}
+
+	var cfg image.Config
+	var err error
+	if contentType == TypeWebP {
+		cfg, err = webp.DecodeConfig(bytes.NewReader(data))
+	} else {
+		cfg, _, err = image.DecodeConfig(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	if opts.MaxPixels > 0 && cfg.Width*cfg.Height > opts.MaxPixels {
+		return nil, ErrTooLarge
+	}
+
+	result := &Result{ContentType: contentType}
+	var img image.Image
+	var buf bytes.Buffer
+
+	switch contentType {
+	case TypeGIF:
+		g, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return nil, ErrInvalidImage
+		}
+		if err := gif.EncodeAll(&buf, g); err != nil {
+			return nil, fmt.Errorf("encode gif: %w", err)
+		}
+		img = g.Image[0]
+
+	case TypeJPEG:
+		decoded, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, ErrInvalidImage
+		}
+		img = applyOrientation(decoded, jpegOrientation(data))
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: opts.JPEGQuality}); err != nil {
+			return nil, fmt.Errorf("encode jpeg: %w", err)
+		}
+
+	default:
+		if contentType == TypeWebP {
+			img, err = webp.Decode(bytes.NewReader(data))
+		} else {
+			img, err = png.Decode(bytes.NewReader(data))
+		}
+		if err != nil {
+			return nil, ErrInvalidImage
+		}
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, fmt.Errorf("encode png: %w", err)
+		}
+		result.ContentType = TypePNG
+	}
+
+	result.Data = buf.Bytes()
+	result.Width = img.Bounds().Dx()
+	result.Height = img.Bounds().Dy()
+
+	if err := result.makeThumbnail(img, opts); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// makeThumbnail 按长边等比缩放，原图不超过缩略图尺寸时不放大
+func (r *Result) makeThumbnail(img image.Image, opts Options) error {
+	width, height := r.Width, r.Height
+	if size := opts.ThumbnailSize; size > 0 && (width > size || height > size) {
+		if width >= height {
+			height = max(1, height*size/width)
+			width = size
+		} else {
+			width = max(1, width*size/height)
+			height = size
+		}
+	}
+
+	thumb := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(thumb, thumb.Bounds(), img, img.Bounds(), draw.Src, nil)
+
+	var buf bytes.Buffer
+	if r.ContentType == TypeJPEG {
+		r.ThumbnailType = TypeJPEG
+		if err := jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: opts.JPEGQuality}); err != nil {
+			return fmt.Errorf("encode thumbnail: %w", err)
+		}
+	} else {
+		r.ThumbnailType = TypePNG
+		if err := png.Encode(&buf, thumb); err != nil {
+			return fmt.Errorf("encode thumbnail: %w", err)
+		}
+	}
+	r.Thumbnail = buf.Bytes()
+	return nil
+}
@@ -0,0 +1,179 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+var (
+	red  = color.RGBA{R: 255, A: 255}
+	blue = color.RGBA{B: 255, A: 255}
+)
+
+// halves 返回左半边红色、右半边蓝色的图片
+func halves(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if x < width/2 {
+				img.Set(x, y, red)
+			} else {
+				img.Set(x, y, blue)
+			}
+		}
+	}
+	return img
+}
+
+// withOrientation 在 JPEG 的 SOI 之后插入只含方向标记的 EXIF 段
+func withOrientation(t *testing.T, data []byte, orientation uint16) []byte {
+	t.Helper()
+
+	var tiff bytes.Buffer
+	tiff.WriteString("II")
+	binary.Write(&tiff, binary.LittleEndian, uint16(42))
+	binary.Write(&tiff, binary.LittleEndian, uint32(8))
+	binary.Write(&tiff, binary.LittleEndian, uint16(1))
+	binary.Write(&tiff, binary.LittleEndian, uint16(exifOrientationTag))
+	binary.Write(&tiff, binary.LittleEndian, uint16(3)) // SHORT
+	binary.Write(&tiff, binary.LittleEndian, uint32(1))
+	binary.Write(&tiff, binary.LittleEndian, orientation)
+	binary.Write(&tiff, binary.LittleEndian, uint16(0))
+	binary.Write(&tiff, binary.LittleEndian, uint32(0))
+
+	segment := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	var out bytes.Buffer
+	out.Write(data[:2])
+	out.Write([]byte{0xFF, 0xE1})
+	binary.Write(&out, binary.BigEndian, uint16(len(segment)+2))
+	out.Write(segment)
+	out.Write(data[2:])
+	return out.Bytes()
+}
+
+func isRed(c color.Color) bool {
+	r, g, b, _ := c.RGBA()
+	return r > 0xC000 && g < 0x4000 && b < 0x4000
+}
+
+func isBlue(c color.Color) bool {
+	r, g, b, _ := c.RGBA()
+	return b > 0xC000 && r < 0x4000 && g < 0x4000
+}
+
+func TestProcessJPEGAppliesAndStripsOrientation(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, halves(32, 16), &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatal(err)
+	}
+	data := withOrientation(t, buf.Bytes(), 6)
+	if got := jpegOrientation(data); got != 6 {
+		t.Fatalf("jpegOrientation = %d, want 6", got)
+	}
+
+	result, err := Process(data, TypeJPEG, Options{JPEGQuality: 95})
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	// 方向 6 需要顺时针旋转 90 度，宽高交换，原来的左半边到了上半边
+	if result.Width != 16 || result.Height != 32 {
+		t.Fatalf("size = %dx%d, want 16x32", result.Width, result.Height)
+	}
+	if bytes.Contains(result.Data, []byte("Exif")) {
+		t.Error("EXIF segment was not stripped")
+	}
+	if got := jpegOrientation(result.Data); got != 1 {
+		t.Errorf("orientation after processing = %d, want 1", got)
+	}
+
+	img, err := jpeg.Decode(bytes.NewReader(result.Data))
+	if err != nil {
+		t.Fatalf("decode result: %v", err)
+	}
+	if c := img.At(8, 4); !isRed(c) {
+		t.Errorf("top half = %v, want red", c)
+	}
+	if c := img.At(8, 27); !isBlue(c) {
+		t.Errorf("bottom half = %v, want blue", c)
+	}
+}
+
+func TestApplyOrientation(t *testing.T) {
+	// 2x1 的图片，左边红色右边蓝色
+	src := halves(2, 1)
+	tests := []struct {
+		orientation   int
+		width, height int
+		red           image.Point
+	}{
+		{1, 2, 1, image.Pt(0, 0)},
+		{2, 2, 1, image.Pt(1, 0)},
+		{3, 2, 1, image.Pt(1, 0)},
+		{4, 2, 1, image.Pt(0, 0)},
+		{5, 1, 2, image.Pt(0, 0)},
+		{6, 1, 2, image.Pt(0, 0)},
+		{7, 1, 2, image.Pt(0, 1)},
+		{8, 1, 2, image.Pt(0, 1)},
+	}
+	for _, tt := range tests {
+		img := applyOrientation(src, tt.orientation)
+		if img.Bounds().Dx() != tt.width || img.Bounds().Dy() != tt.height {
+			t.Errorf("orientation %d: size = %dx%d, want %dx%d", tt.orientation,
+				img.Bounds().Dx(), img.Bounds().Dy(), tt.width, tt.height)
+			continue
+		}
+		if c := img.At(tt.red.X, tt.red.Y); !isRed(c) {
+			t.Errorf("orientation %d: pixel %v = %v, want red", tt.orientation, tt.red, c)
+		}
+	}
+}
+
+func TestProcessThumbnailBounds(t *testing.T) {
+	tests := []struct {
+		name                    string
+		width, height           int
+		thumbWidth, thumbHeight int
+	}{
+		{"wide", 400, 100, 100, 25},
+		{"tall", 30, 300, 10, 100},
+		{"thin", 1000, 2, 100, 1},
+		{"small image is not enlarged", 50, 30, 50, 30},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := png.Encode(&buf, halves(tt.width, tt.height)); err != nil {
+				t.Fatal(err)
+			}
+			result, err := Process(buf.Bytes(), TypePNG, Options{ThumbnailSize: 100})
+			if err != nil {
+				t.Fatalf("Process: %v", err)
+			}
+			if result.ThumbnailType != TypePNG {
+				t.Errorf("thumbnail type = %q, want %q", result.ThumbnailType, TypePNG)
+			}
+			cfg, err := png.DecodeConfig(bytes.NewReader(result.Thumbnail))
+			if err != nil {
+				t.Fatalf("decode thumbnail: %v", err)
+			}
+			if cfg.Width != tt.thumbWidth || cfg.Height != tt.thumbHeight {
+				t.Errorf("thumbnail = %dx%d, want %dx%d", cfg.Width, cfg.Height, tt.thumbWidth, tt.thumbHeight)
+			}
+		})
+	}
+}
+
+func TestProcessRejectsOversizedImage(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, halves(100, 100)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Process(buf.Bytes(), TypePNG, Options{MaxPixels: 9999}); err != ErrTooLarge {
+		t.Errorf("err = %v, want ErrTooLarge", err)
+	}
+}
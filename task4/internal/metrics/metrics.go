@@ -0,0 +1,144 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"blog-system/pkg/database"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// unmatchedRoute 是没有匹配到路由的请求使用的 route 标签，避免把任意路径作为标签值
+const unmatchedRoute = "unmatched"
+
+// Metrics 是一个应用实例的 Prometheus 指标，每个实例使用自己的 Registry，
+// 同一进程中的多个实例互不影响。方法在 nil 上调用时什么都不做
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+
+	logins          *prometheus.CounterVec
+	usersRegistered prometheus.Counter
+	postsCreated    prometheus.Counter
+	commentsCreated *prometheus.CounterVec
+}
+
+// New 创建指标并注册 Go 运行时、进程和数据库连接池的指标。
+// replicas 为 nil 时不输出只读副本的指标
+func New(db *sql.DB, replicas *database.Replicas) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "HTTP requests by route template, method and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request latency by route template, method and status code.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "blog_logins_total",
+			Help: "Login attempts by result.",
+		}, []string{"result"}),
+		usersRegistered: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "blog_users_registered_total",
+			Help: "Users registered.",
+		}),
+		postsCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "blog_posts_created_total",
+			Help: "Posts created.",
+		}),
+		commentsCreated: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "blog_comments_created_total",
+			Help: "Comments created by moderation status.",
+		}, []string{"status"}),
+	}
+
+	// 登录结果预先初始化，还没有失败时也能输出 0，方便计算失败率
+	m.logins.WithLabelValues("success")
+	m.logins.WithLabelValues("failure")
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.logins,
+		m.usersRegistered,
+		m.postsCreated,
+		m.commentsCreated,
+	)
+	if db != nil {
+		m.registry.MustRegister(collectors.NewDBStatsCollector(db, "primary"))
+	}
+	if replicas != nil {
+		m.registry.MustRegister(replicaCollector{replicas})
+	}
+	return m
+}
+
+// Handler 返回以 Prometheus 文本格式输出指标的 http.Handler
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveRequest 记录一个 HTTP 请求，route 为路由模板，为空时表示没有匹配到路由
+func (m *Metrics) ObserveRequest(method, route string, status int, seconds float64) {
+	if m == nil {
+		return
+	}
+	if route == "" {
+		route = unmatchedRoute
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions:
+	default:
+		method = "OTHER"
+	}
+	code := strconv.Itoa(status)
+	m.httpRequests.WithLabelValues(method, route, code).Inc()
+	m.httpDuration.WithLabelValues(method, route, code).Observe(seconds)
+}
+
+// Login 记录一次登录，success 为是否登录成功
+func (m *Metrics) Login(success bool) {
+	if m == nil {
+		return
+	}
+	if success {
+		m.logins.WithLabelValues("success").Inc()
+	} else {
+		m.logins.WithLabelValues("failure").Inc()
+	}
+}
+
+// UserRegistered 记录一个注册成功的用户
+func (m *Metrics) UserRegistered() {
+	if m == nil {
+		return
+	}
+	m.usersRegistered.Inc()
+}
+
+// PostCreated 记录一篇创建成功的文章
+func (m *Metrics) PostCreated() {
+	if m == nil {
+		return
+	}
+	m.postsCreated.Inc()
+}
+
+// CommentCreated 记录一条创建成功的评论，status 为审核后的状态
+func (m *Metrics) CommentCreated(status string) {
+	if m == nil {
+		return
+	}
+	m.commentsCreated.WithLabelValues(status).Inc()
+}
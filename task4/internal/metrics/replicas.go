@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"blog-system/pkg/database"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	replicaHealthyDesc = prometheus.NewDesc(
+		"blog_db_replica_healthy",
+		"Whether the read replica passed its last health check.",
+		[]string{"replica"}, nil,
+	)
+	replicaLagDesc = prometheus.NewDesc(
+		"blog_db_replica_lag_seconds",
+		"Replication lag of the read replica at its last health check.",
+		[]string{"replica"}, nil,
+	)
+)
+
+// replicaCollector 在每次抓取时输出只读副本最近一次健康检查的结果
+type replicaCollector struct {
+	replicas *database.Replicas
+}
+
+func (c replicaCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- replicaHealthyDesc
+	ch <- replicaLagDesc
+}
+
+func (c replicaCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, status := range c.replicas.Status() {
+		healthy := 0.0
+		if status.Healthy {
+			healthy = 1
+		}
+		ch <- prometheus.MustNewConstMetric(replicaHealthyDesc, prometheus.GaugeValue, healthy, status.Name)
+		ch <- prometheus.MustNewConstMetric(replicaLagDesc, prometheus.GaugeValue, status.Lag, status.Name)
+	}
+}
@@ -8,11 +8,11 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
+func AuthMiddleware(tokens *auth.TokenIssuer) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			logger.Error("Authorization header is required")
+			logger.WarnContext(c.Request.Context(), "Authorization header is required")
 			c.JSON(401, gin.H{"code": 401, "message": "Authorization header is required"})
 			c.Abort()
 			return
@@ -20,15 +20,15 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 		if tokenString == authHeader {
-			logger.Error("Invalid authorization header format")
+			logger.WarnContext(c.Request.Context(), "Invalid authorization header format")
 			c.JSON(401, gin.H{"code": 401, "message": "Invalid authorization header format"})
 			c.Abort()
 			return
 		}
 
-		claims, err := auth.ValidateToken(tokenString, jwtSecret)
+		claims, err := tokens.Validate(tokenString)
 		if err != nil {
-			logger.Error("Invalid token:", err)
+			logger.WarnContext(c.Request.Context(), "Invalid token", "error", err)
 			c.JSON(401, gin.H{"code": 401, "message": "Invalid token"})
 			c.Abort()
 			return
@@ -40,7 +40,7 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 	}
 }
 
-func OptionalAuthMiddleware(jwtSecret string) gin.HandlerFunc {
+func OptionalAuthMiddleware(tokens *auth.TokenIssuer) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -54,7 +54,7 @@ func OptionalAuthMiddleware(jwtSecret string) gin.HandlerFunc {
 			return
 		}
 
-		claims, err := auth.ValidateToken(tokenString, jwtSecret)
+		claims, err := tokens.Validate(tokenString)
 		if err != nil {
 			c.Next()
 			return
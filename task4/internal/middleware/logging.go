@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"runtime/debug"
+	"time"
+
+	"blog-system/internal/utils"
+	"blog-system/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+// validRequestID 限制客户端传入的请求 ID，避免把任意内容写进日志
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestID 为每个请求分配 ID 并写入响应头 X-Request-ID，请求中已有合法的 X-Request-ID 时沿用。
+// ID 放在请求的 context 中，用它记录的日志都会带上 request_id
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIDHeader)
+		if !validRequestID.MatchString(id) {
+			b := make([]byte, 16)
+			rand.Read(b)
+			id = hex.EncodeToString(b)
+		}
+
+		c.Header(requestIDHeader, id)
+		c.Request = c.Request.WithContext(logger.WithRequestID(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// AccessLog 在请求结束后记录一条访问日志，route 为路由模板而不是实际路径，
+// 不记录查询参数，服务端错误记为 error
+func AccessLog(l *logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("route", c.FullPath()),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("client_ip", c.ClientIP()),
+			slog.Int("bytes", c.Writer.Size()),
+		}
+		if userID, exists := c.Get("user_id"); exists {
+			attrs = append(attrs, slog.Any("user_id", userID))
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("errors", c.Errors.String()))
+		}
+		l.LogAttrs(c.Request.Context(), level, "HTTP request", attrs...)
+	}
+}
+
+// Recovery 捕获处理器中的 panic，记录堆栈后返回 500
+func Recovery(l *logger.Logger) gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(nil, func(c *gin.Context, err any) {
+		l.ErrorContext(c.Request.Context(), "Panic recovered", "panic", err, "stack", string(debug.Stack()))
+		utils.InternalServerError(c, "服务器内部错误")
+		c.Abort()
+	})
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"strings"
+	"time"
+
+	"blog-system/internal/metrics"
+	"blog-system/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics 在请求结束后按路由模板、方法和状态码记录请求数和耗时。
+// 路由模板来自 FullPath，/posts/1 和 /posts/2 计入同一个 /api/v1/posts/:id
+func Metrics(m *metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		m.ObserveRequest(c.Request.Method, c.FullPath(), c.Writer.Status(), time.Since(start).Seconds())
+	}
+}
+
+// MetricsToken 要求请求带上 Authorization: Bearer <token>，token 为空时不限制
+func MetricsToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.Next()
+			return
+		}
+
+		given := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			logger.WarnContext(c.Request.Context(), "Invalid metrics token")
+			c.JSON(401, gin.H{"code": 401, "message": "Invalid token"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
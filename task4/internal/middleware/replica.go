@@ -0,0 +1,191 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"blog-system/pkg/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// primaryPinCookie 记录写入之后固定读主库的截止时间，由服务端签名，
+// 多实例部署时请求落到任何一个实例上都能识别
+const primaryPinCookie = "blog_primary_until"
+
+// ReadReplica 让 GET 和 HEAD 请求中的查询走只读副本，其他请求全部使用主库。
+// 请求成功并且确实修改了数据之后的 window 时间内，同一客户端的读取也固定使用主库，避免副本还没有同步时
+// 读不到自己刚写入的内容：响应中设置签名的 cookie，所有实例都会识别；
+// 不保存 cookie 的客户端按用户和 IP 记录在当前进程中。登录这类没有写入的请求不会固定。
+// cookie 的签名密钥由 secret 派生，不直接使用 secret。需要放在认证中间件之后
+func ReadReplica(window time.Duration, secret string) gin.HandlerFunc {
+	pins := &pinSet{until: make(map[string]time.Time)}
+	cookie := pinCookie{key: pinKey(secret), window: window}
+
+	return func(c *gin.Context) {
+		keys := []string{"ip:" + c.ClientIP()}
+		if userID, exists := c.Get("user_id"); exists {
+			keys = append(keys, "user:"+strconv.FormatUint(uint64(userID.(uint)), 10))
+		}
+
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			if window <= 0 {
+				c.Next()
+				return
+			}
+
+			// cookie 要在响应头写出之前设置，此时才能确定写入是否成功
+			ctx := database.TrackWrites(c.Request.Context())
+			c.Request = c.Request.WithContext(ctx)
+			w := &pinWriter{ResponseWriter: c.Writer, pin: func(status int) {
+				if status < http.StatusBadRequest && database.Wrote(ctx) {
+					until := time.Now().Add(window)
+					pins.pin(keys, until)
+					cookie.set(c.Writer.Header(), c.Request, until)
+				}
+			}}
+			c.Writer = w
+			c.Next()
+			w.beforeHeader()
+			return
+		}
+
+		now := time.Now()
+		if !cookie.pinned(c.Request, now) && !pins.pinned(keys, now) {
+			c.Request = c.Request.WithContext(database.PreferReplica(c.Request.Context()))
+		}
+		c.Next()
+	}
+}
+
+// pinKey 从 secret 派生 cookie 的签名密钥，和 JWT 使用不同的密钥
+func pinKey(secret string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("blog-system read replica pin"))
+	return mac.Sum(nil)
+}
+
+// pinCookie 签发和校验固定读主库的 cookie，值为截止时间的毫秒时间戳和它的 HMAC 签名
+type pinCookie struct {
+	key    []byte
+	window time.Duration
+}
+
+func (p pinCookie) sign(value string) string {
+	mac := hmac.New(sha256.New, p.key)
+	mac.Write([]byte(primaryPinCookie + ":" + value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (p pinCookie) set(header http.Header, r *http.Request, until time.Time) {
+	value := strconv.FormatInt(until.UnixMilli(), 10)
+	cookie := &http.Cookie{
+		Name:     primaryPinCookie,
+		Value:    value + "." + p.sign(value),
+		Path:     "/",
+		MaxAge:   int((p.window + time.Second - 1) / time.Second),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	}
+	header.Add("Set-Cookie", cookie.String())
+}
+
+// pinned 校验请求中的 cookie，截止时间超过 now+window 的视为无效，调小窗口后旧的 cookie 不会延长固定时间
+func (p pinCookie) pinned(r *http.Request, now time.Time) bool {
+	cookie, err := r.Cookie(primaryPinCookie)
+	if err != nil {
+		return false
+	}
+	value, signature, ok := strings.Cut(cookie.Value, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(p.sign(value))) {
+		return false
+	}
+	millis, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return false
+	}
+	until := time.UnixMilli(millis)
+	return until.After(now) && !until.After(now.Add(p.window))
+}
+
+// pinWriter 在响应头第一次写出之前调用 pin，gin 的 WriteHeader 只记录状态码，
+// 没有响应体时由中间件在处理结束后调用 beforeHeader
+type pinWriter struct {
+	gin.ResponseWriter
+	pin  func(status int)
+	done bool
+}
+
+func (w *pinWriter) beforeHeader() {
+	if w.done || w.ResponseWriter.Written() {
+		return
+	}
+	w.done = true
+	w.pin(w.ResponseWriter.Status())
+}
+
+func (w *pinWriter) WriteHeaderNow() {
+	w.beforeHeader()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *pinWriter) Write(data []byte) (int, error) {
+	w.beforeHeader()
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *pinWriter) WriteString(s string) (int, error) {
+	w.beforeHeader()
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *pinWriter) Flush() {
+	w.beforeHeader()
+	w.ResponseWriter.Flush()
+}
+
+// pinSet 记录固定读主库的截止时间，只保存在当前进程中
+type pinSet struct {
+	mu        sync.Mutex
+	until     map[string]time.Time
+	lastSweep time.Time
+}
+
+func (p *pinSet) pin(keys []string, until time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, key := range keys {
+		p.until[key] = until
+	}
+
+	// 定期清理已经过期的记录
+	now := time.Now()
+	if now.Sub(p.lastSweep) > time.Minute {
+		for key, t := range p.until {
+			if t.Before(now) {
+				delete(p.until, key)
+			}
+		}
+		p.lastSweep = now
+	}
+}
+
+func (p *pinSet) pinned(keys []string, now time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, key := range keys {
+		if p.until[key].After(now) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"blog-system/config"
+	"blog-system/pkg/database"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// openReplicaDB 打开把自己配置为副本的 SQLite 数据库，写入会被记录
+func openReplicaDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	cfg, _, err := config.Load(nil)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "replica_test.db")
+	cfg.Database = config.DatabaseConfig{
+		Driver:               database.DriverSQLite,
+		Path:                 path,
+		Replicas:             []string{path},
+		ReplicaCheckInterval: time.Minute,
+	}
+	db, err := database.Open(cfg)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { database.Close(db) })
+	if err := db.Exec("CREATE TABLE items (id integer PRIMARY KEY)").Error; err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// newReplicaRouter 模拟一个应用实例，GET /read 返回请求是否被标记为读副本
+func newReplicaRouter(db *gorm.DB, secret string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set(originalContextKey, c.Request.Context()) })
+	r.Use(ReadReplica(10*time.Second, secret))
+
+	insert := func(c *gin.Context) {
+		if err := db.WithContext(c.Request.Context()).Exec("INSERT INTO items DEFAULT VALUES").Error; err != nil {
+			c.AbortWithStatus(http.StatusInternalServerError)
+		}
+	}
+
+	r.GET("/read", func(c *gin.Context) {
+		// PreferReplica 会替换请求的 context
+		replica := c.Request.Context() != c.Value(originalContextKey)
+		c.String(http.StatusOK, strconv.FormatBool(replica))
+	})
+	r.POST("/write", insert, func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"ok": true}) })
+	r.POST("/empty", insert, func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	r.POST("/fail", insert, func(c *gin.Context) { c.JSON(http.StatusBadRequest, gin.H{"ok": false}) })
+	// /login 只读取数据，和登录一样不应固定读主库
+	r.POST("/login", func(c *gin.Context) {
+		var count int64
+		db.WithContext(c.Request.Context()).Raw("SELECT COUNT(*) FROM items").Scan(&count)
+		c.JSON(http.StatusOK, gin.H{"count": count})
+	})
+	return r
+}
+
+const originalContextKey = "original_context"
+
+func readsReplica(t *testing.T, r *gin.Engine, cookies ...*http.Cookie) bool {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/read", nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Body.String() == "true"
+}
+
+func write(t *testing.T, r *gin.Engine, path string) *http.Cookie {
+	t.Helper()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, nil))
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == primaryPinCookie {
+			return cookie
+		}
+	}
+	return nil
+}
+
+func TestReadReplicaPinCookieWorksAcrossInstances(t *testing.T) {
+	db := openReplicaDB(t)
+	first := newReplicaRouter(db, "secret")
+	second := newReplicaRouter(db, "secret")
+
+	if !readsReplica(t, second) {
+		t.Fatal("read without a pin does not use the replica")
+	}
+
+	cookie := write(t, first, "/write")
+	if cookie == nil {
+		t.Fatal("successful write did not set the pin cookie")
+	}
+	if !cookie.HttpOnly || cookie.MaxAge != 10 {
+		t.Errorf("cookie = %+v, want HttpOnly with MaxAge 10", cookie)
+	}
+	// 另一个实例没有这个客户端的记录，只能依靠 cookie
+	if readsReplica(t, second, cookie) {
+		t.Error("read after a write on another instance uses the replica")
+	}
+
+	if write(t, first, "/empty") == nil {
+		t.Error("successful write without a body did not set the pin cookie")
+	}
+	if write(t, first, "/fail") != nil {
+		t.Error("failed write set the pin cookie")
+	}
+	if write(t, first, "/login") != nil {
+		t.Error("request without writes set the pin cookie")
+	}
+}
+
+func TestReadReplicaRejectsInvalidPinCookie(t *testing.T) {
+	db := openReplicaDB(t)
+	r := newReplicaRouter(db, "secret")
+	cookie := write(t, newReplicaRouter(db, "secret"), "/write")
+	value, signature, _ := strings.Cut(cookie.Value, ".")
+
+	// 把截止时间改晚一小时，签名不再匹配
+	millis, _ := strconv.ParseInt(value, 10, 64)
+	tampered := strconv.FormatInt(millis+time.Hour.Milliseconds(), 10) + "." + signature
+	otherSecret := write(t, newReplicaRouter(db, "other"), "/write")
+
+	farFuture := strconv.FormatInt(time.Now().Add(time.Hour).UnixMilli(), 10)
+	tooLong := pinCookie{key: pinKey("secret"), window: 10 * time.Second}
+
+	for name, value := range map[string]string{
+		"tampered":      tampered,
+		"other secret":  otherSecret.Value,
+		"no signature":  value,
+		"beyond window": farFuture + "." + tooLong.sign(farFuture),
+	} {
+		if !readsReplica(t, r, &http.Cookie{Name: primaryPinCookie, Value: value}) {
+			t.Errorf("%s cookie pinned the read to the primary", name)
+		}
+	}
+
+	expired := strconv.FormatInt(time.Now().Add(-time.Second).UnixMilli(), 10)
+	if !readsReplica(t, r, &http.Cookie{Name: primaryPinCookie, Value: expired + "." + tooLong.sign(expired)}) {
+		t.Error("expired cookie pinned the read to the primary")
+	}
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Timeout 为请求的 context 设置截止时间。服务层用它执行数据库查询，
+// 超时或客户端断开时查询随之取消。skip 为不受限制的长连接路由，例如评论推送
+func Timeout(timeout time.Duration, skip ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if timeout <= 0 || slices.Contains(skip, c.FullPath()) {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
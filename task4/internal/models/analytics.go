@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// PostDailyView 按天汇总的文章浏览量
+type PostDailyView struct {
+	PostID uint      `json:"post_id" gorm:"primaryKey"`
+	Day    time.Time `json:"day" gorm:"primaryKey;type:date;index"`
+	Views  int64     `json:"views" gorm:"not null;default:0"`
+}
+
+// PostReferrerView 按天和来源域名汇总的文章浏览量
+type PostReferrerView struct {
+	PostID   uint      `json:"post_id" gorm:"primaryKey"`
+	Day      time.Time `json:"day" gorm:"primaryKey;type:date;index"`
+	Referrer string    `json:"referrer" gorm:"primaryKey;size:255"`
+	Views    int64     `json:"views" gorm:"not null;default:0"`
+}
+
+type DailyViews struct {
+	Date  string `json:"date"`
+	Views int64  `json:"views"`
+}
+
+type ReferrerViews struct {
+	Referrer string `json:"referrer"`
+	Views    int64  `json:"views"`
+}
+
+type TopPost struct {
+	PostID uint   `json:"post_id"`
+	Title  string `json:"title"`
+	Views  int64  `json:"views"`
+}
+
+type AnalyticsResponse struct {
+	From       string          `json:"from"`
+	To         string          `json:"to"`
+	PostID     *uint           `json:"post_id,omitempty"`
+	TotalViews int64           `json:"total_views"`
+	Daily      []DailyViews    `json:"daily"`
+	Referrers  []ReferrerViews `json:"referrers"`
+	TopPosts   []TopPost       `json:"top_posts"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Attachment 是上传的图片或附件，PostID 为空表示还没有关联文章
+type Attachment struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	UserID       uint      `json:"user_id" gorm:"not null;index"`
+	PostID       *uint     `json:"post_id" gorm:"index"`
+	Filename     string    `json:"filename" gorm:"not null;size:255"`
+	ContentType  string    `json:"content_type" gorm:"not null;size:100"`
+	Size         int64     `json:"size" gorm:"not null"`
+	Width        int       `json:"width,omitempty"`
+	Height       int       `json:"height,omitempty"`
+	Key          string    `json:"-" gorm:"not null;size:255;uniqueIndex"`
+	URL          string    `json:"url" gorm:"not null;size:500"`
+	ThumbnailKey string    `json:"-" gorm:"size:255"`
+	ThumbnailURL string    `json:"thumbnail_url,omitempty" gorm:"size:500"`
+	CreatedAt    time.Time `json:"created_at" gorm:"index"`
+	// DetachedAt 是附件被移出文章的时间，未使用附件的保留时长从这个时间开始计算
+	DetachedAt *time.Time `json:"-" gorm:"index"`
+}
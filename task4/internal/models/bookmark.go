@@ -0,0 +1,97 @@
+package models
+
+import "time"
+
+// DefaultReadingListName 收藏时未指定书单则放入该私有书单，不存在时自动创建
+const DefaultReadingListName = "稍后阅读"
+
+type ReadingList struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_reading_lists_user_name"`
+	Name      string    `json:"name" gorm:"not null;size:100;uniqueIndex:idx_reading_lists_user_name"`
+	IsPublic  bool      `json:"is_public" gorm:"not null;default:false"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Bookmark 的 Position 决定书单内的顺序，文章被删除后收藏仍然保留
+type Bookmark struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;index"`
+	ListID    uint      `json:"list_id" gorm:"not null;uniqueIndex:idx_bookmarks_list_post"`
+	PostID    uint      `json:"post_id" gorm:"not null;uniqueIndex:idx_bookmarks_list_post;index"`
+	Position  int64     `json:"position" gorm:"not null;default:0"`
+	CreatedAt time.Time `json:"created_at"`
+
+	Post Post `json:"-" gorm:"foreignKey:PostID"`
+}
+
+type ReadingListRequest struct {
+	Name     string `json:"name" binding:"required,max=100"`
+	IsPublic bool   `json:"is_public"`
+}
+
+type BookmarkCreateRequest struct {
+	PostID uint  `json:"post_id" binding:"required"`
+	ListID *uint `json:"list_id"`
+}
+
+type BookmarkReorderRequest struct {
+	BookmarkIDs []uint `json:"bookmark_ids" binding:"required"`
+}
+
+type ReadingListResponse struct {
+	ID            uint      `json:"id"`
+	UserID        uint      `json:"user_id"`
+	Name          string    `json:"name"`
+	IsPublic      bool      `json:"is_public"`
+	BookmarkCount int64     `json:"bookmark_count"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// BookmarkResponse 中文章已删除或不可见时 Available 为 false 且不返回文章内容
+type BookmarkResponse struct {
+	ID        uint              `json:"id"`
+	ListID    uint              `json:"list_id"`
+	PostID    uint              `json:"post_id"`
+	Position  int64             `json:"position"`
+	Available bool              `json:"available"`
+	Post      *PostListResponse `json:"post"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+func (l *ReadingList) ToResponse(bookmarkCount int64) ReadingListResponse {
+	return ReadingListResponse{
+		ID:            l.ID,
+		UserID:        l.UserID,
+		Name:          l.Name,
+		IsPublic:      l.IsPublic,
+		BookmarkCount: bookmarkCount,
+		CreatedAt:     l.CreatedAt,
+		UpdatedAt:     l.UpdatedAt,
+	}
+}
+
+func (b *Bookmark) ToResponse(viewerID uint) BookmarkResponse {
+	response := BookmarkResponse{
+		ID:        b.ID,
+		ListID:    b.ListID,
+		PostID:    b.PostID,
+		Position:  b.Position,
+		CreatedAt: b.CreatedAt,
+	}
+
+	post := &b.Post
+	if post.ID == 0 || post.DeletedAt.Valid {
+		return response
+	}
+	if post.Status != PostStatusPublished && post.UserID != viewerID {
+		return response
+	}
+
+	listResponse := post.ToListResponse()
+	response.Available = true
+	response.Post = &listResponse
+	return response
+}
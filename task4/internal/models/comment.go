@@ -1,37 +1,93 @@
 package models
 
 import (
+	"fmt"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+const (
+	// CommentPathWidth 是评论路径中每一级ID的固定宽度
+	CommentPathWidth = 10
+	// CommentMaxDepthLimit 受 path 字段长度限制
+	CommentMaxDepthLimit = 20
+
+	DeletedCommentContent = "[deleted]"
+
+	CommentStatusPending  = "pending"
+	CommentStatusApproved = "approved"
+	CommentStatusRejected = "rejected"
+	CommentStatusHidden   = "hidden"
+)
+
 type Comment struct {
 	ID        uint           `json:"id" gorm:"primaryKey"`
 	Content   string         `json:"content" gorm:"not null;type:text" binding:"required"`
 	UserID    uint           `json:"user_id" gorm:"not null"`
-	PostID    uint           `json:"post_id" gorm:"not null"`
+	PostID    uint           `json:"post_id" gorm:"not null;index"`
+	ParentID  *uint          `json:"parent_id" gorm:"index"`
+	Depth     int            `json:"depth" gorm:"not null;default:0"`
+	Path      string         `json:"-" gorm:"not null;size:255;default:'';index"`
+	IsDeleted bool           `json:"is_deleted" gorm:"not null;default:false"`
+	EditedAt  *time.Time     `json:"edited_at"`
+	Status    string         `json:"status" gorm:"not null;size:20;default:approved;index"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 
+	ModerationReason string `json:"-" gorm:"size:255"`
+
 	User User `json:"user,omitempty" gorm:"foreignKey:UserID"`
 	Post Post `json:"post,omitempty" gorm:"foreignKey:PostID"`
+
+	Reactions []ReactionSummary `json:"-" gorm:"-"`
 }
 
 type CommentCreateRequest struct {
+	Content  string `json:"content" binding:"required"`
+	PostID   uint   `json:"post_id" binding:"required"`
+	ParentID *uint  `json:"parent_id"`
+}
+
+type CommentUpdateRequest struct {
 	Content string `json:"content" binding:"required"`
-	PostID  uint   `json:"post_id" binding:"required"`
 }
 
 type CommentResponse struct {
-	ID        uint         `json:"id"`
-	Content   string       `json:"content"`
-	UserID    uint         `json:"user_id"`
-	PostID    uint         `json:"post_id"`
-	User      UserResponse `json:"user,omitempty"`
-	CreatedAt time.Time    `json:"created_at"`
-	UpdatedAt time.Time    `json:"updated_at"`
+	ID        uint              `json:"id"`
+	Content   string            `json:"content"`
+	UserID    uint              `json:"user_id"`
+	PostID    uint              `json:"post_id"`
+	ParentID  *uint             `json:"parent_id"`
+	Depth     int               `json:"depth"`
+	IsDeleted bool              `json:"is_deleted"`
+	User      UserResponse      `json:"user,omitempty"`
+	Replies   []CommentResponse `json:"replies,omitempty"`
+	EditedAt  *time.Time        `json:"edited_at"`
+	Status    string            `json:"status"`
+	Reactions []ReactionSummary `json:"reactions"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// CommentRevision 保存评论被编辑前的内容，仅对版主可见
+type CommentRevision struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CommentID uint      `json:"comment_id" gorm:"not null;index"`
+	Content   string    `json:"content" gorm:"not null;type:text"`
+	EditorID  uint      `json:"editor_id" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BuildPath 生成物化路径，按路径排序即为楼中楼的展示顺序
+func (c *Comment) BuildPath(parent *Comment) {
+	segment := fmt.Sprintf("%0*d", CommentPathWidth, c.ID)
+	if parent == nil {
+		c.Path = segment
+		return
+	}
+	c.Path = parent.Path + "/" + segment
 }
 
 func (c *Comment) ToResponse() CommentResponse {
@@ -40,10 +96,22 @@ func (c *Comment) ToResponse() CommentResponse {
 		Content:   c.Content,
 		UserID:    c.UserID,
 		PostID:    c.PostID,
+		ParentID:  c.ParentID,
+		Depth:     c.Depth,
+		IsDeleted: c.IsDeleted,
+		EditedAt:  c.EditedAt,
+		Status:    c.Status,
+		Reactions: c.Reactions,
 		CreatedAt: c.CreatedAt,
 		UpdatedAt: c.UpdatedAt,
 	}
 
+	if c.IsDeleted {
+		response.Content = DeletedCommentContent
+		response.UserID = 0
+		return response
+	}
+
 	if c.User.ID != 0 {
 		response.User = c.User.ToResponse()
 	}
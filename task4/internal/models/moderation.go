@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// CommentBan 禁止用户评论，OwnerID 为 0 表示全站禁止，否则只禁止评论该作者的文章
+type CommentBan struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_comment_bans_user_owner"`
+	OwnerID   uint      `json:"owner_id" gorm:"not null;default:0;uniqueIndex:idx_comment_bans_user_owner"`
+	BannedBy  uint      `json:"banned_by" gorm:"not null"`
+	Reason    string    `json:"reason" gorm:"size:255"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ModerationActionRequest struct {
+	Reason string `json:"reason" binding:"max=255"`
+}
+
+type ModerationCommentResponse struct {
+	CommentResponse
+	PostTitle        string `json:"post_title"`
+	ModerationReason string `json:"moderation_reason"`
+}
+
+func (c *Comment) ToModerationResponse() ModerationCommentResponse {
+	return ModerationCommentResponse{
+		CommentResponse:  c.ToResponse(),
+		PostTitle:        c.Post.Title,
+		ModerationReason: c.ModerationReason,
+	}
+}
@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+const (
+	NotificationTypeComment = "comment"
+	NotificationTypeReply   = "reply"
+	NotificationTypeMention = "mention"
+)
+
+var NotificationTypes = []string{
+	NotificationTypeComment,
+	NotificationTypeReply,
+	NotificationTypeMention,
+}
+
+type Notification struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	ActorID   uint       `json:"actor_id" gorm:"not null"`
+	Type      string     `json:"type" gorm:"not null;size:20"`
+	PostID    uint       `json:"post_id" gorm:"not null"`
+	CommentID *uint      `json:"comment_id"`
+	ReadAt    *time.Time `json:"read_at" gorm:"index"`
+	CreatedAt time.Time  `json:"created_at"`
+
+	Actor User `json:"actor,omitempty" gorm:"foreignKey:ActorID"`
+}
+
+// NotificationPreference 没有记录的通知类型默认开启
+type NotificationPreference struct {
+	UserID    uint      `json:"user_id" gorm:"primaryKey"`
+	Type      string    `json:"type" gorm:"primaryKey;size:20"`
+	Enabled   bool      `json:"enabled" gorm:"not null"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type NotificationResponse struct {
+	ID        uint         `json:"id"`
+	Type      string       `json:"type"`
+	PostID    uint         `json:"post_id"`
+	CommentID *uint        `json:"comment_id"`
+	Actor     UserResponse `json:"actor"`
+	Read      bool         `json:"read"`
+	ReadAt    *time.Time   `json:"read_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+func (n *Notification) ToResponse() NotificationResponse {
+	response := NotificationResponse{
+		ID:        n.ID,
+		Type:      n.Type,
+		PostID:    n.PostID,
+		CommentID: n.CommentID,
+		Read:      n.ReadAt != nil,
+		ReadAt:    n.ReadAt,
+		CreatedAt: n.CreatedAt,
+	}
+
+	if n.Actor.ID != 0 {
+		response.Actor = n.Actor.ToResponse()
+	}
+
+	return response
+}
@@ -6,27 +6,66 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	PostStatusDraft     = "draft"
+	PostStatusPublished = "published"
+
+	CommentPolicyEveryone  = "everyone"
+	CommentPolicyFollowers = "followers"
+)
+
 type Post struct {
 	ID        uint           `json:"id" gorm:"primaryKey"`
 	Title     string         `json:"title" gorm:"not null;size:200" binding:"required,max=200"`
 	Content   string         `json:"content" gorm:"not null;type:text" binding:"required"`
 	UserID    uint           `json:"user_id" gorm:"not null"`
+	Status    string         `json:"status" gorm:"not null;size:20;default:published;index"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 
+	CommentCount    int64      `json:"comment_count" gorm:"not null;default:0"`
+	LastCommentedAt *time.Time `json:"last_commented_at"`
+	ViewCount       int64      `json:"view_count" gorm:"not null;default:0"`
+
+	CommentsLocked bool   `json:"comments_locked" gorm:"not null;default:false"`
+	CommentPolicy  string `json:"comment_policy" gorm:"not null;size:20;default:everyone"`
+
 	User     User      `json:"user,omitempty" gorm:"foreignKey:UserID"`
 	Comments []Comment `json:"comments,omitempty" gorm:"foreignKey:PostID"`
+	Tags     []Tag     `json:"tags,omitempty" gorm:"many2many:post_tags"`
+
+	Attachments []Attachment `json:"attachments,omitempty" gorm:"foreignKey:PostID"`
+
+	Reactions     []ReactionSummary `json:"-" gorm:"-"`
+	BookmarkCount *int64            `json:"-" gorm:"-"`
 }
 
 type PostCreateRequest struct {
 	Title   string `json:"title" binding:"required,max=200"`
 	Content string `json:"content" binding:"required"`
+	Status  string `json:"status" binding:"omitempty,oneof=draft published"`
+
+	CommentsLocked bool   `json:"comments_locked"`
+	CommentPolicy  string `json:"comment_policy" binding:"omitempty,oneof=everyone followers"`
+
+	Tags []string `json:"tags" binding:"max=10,dive,max=50"`
+
+	AttachmentIDs []uint `json:"attachment_ids" binding:"max=50"`
 }
 
 type PostUpdateRequest struct {
 	Title   string `json:"title" binding:"max=200"`
 	Content string `json:"content"`
+	Status  string `json:"status" binding:"omitempty,oneof=draft published"`
+
+	CommentsLocked *bool  `json:"comments_locked"`
+	CommentPolicy  string `json:"comment_policy" binding:"omitempty,oneof=everyone followers"`
+
+	// Tags 为 nil 时不修改标签，空数组表示清空
+	Tags *[]string `json:"tags" binding:"omitempty,max=10,dive,max=50"`
+	// AttachmentIDs 为 nil 时不修改附件，移出的附件会在一段时间后被清理
+	AttachmentIDs *[]uint `json:"attachment_ids" binding:"omitempty,max=50"`
 }
 
 type PostResponse struct {
@@ -34,10 +73,23 @@ type PostResponse struct {
 	Title     string            `json:"title"`
 	Content   string            `json:"content"`
 	UserID    uint              `json:"user_id"`
+	Status    string            `json:"status"`
 	User      UserResponse      `json:"user,omitempty"`
 	Comments  []CommentResponse `json:"comments,omitempty"`
 	CreatedAt time.Time         `json:"created_at"`
 	UpdatedAt time.Time         `json:"updated_at"`
+
+	CommentCount    int64      `json:"comment_count"`
+	LastCommentedAt *time.Time `json:"last_commented_at"`
+	ViewCount       int64      `json:"view_count"`
+	CommentsLocked  bool       `json:"comments_locked"`
+	CommentPolicy   string     `json:"comment_policy"`
+	Tags            []string   `json:"tags"`
+
+	Attachments []Attachment `json:"attachments"`
+
+	Reactions     []ReactionSummary `json:"reactions"`
+	BookmarkCount *int64            `json:"bookmark_count,omitempty"`
 }
 
 type PostListResponse struct {
@@ -45,9 +97,20 @@ type PostListResponse struct {
 	Title     string       `json:"title"`
 	Content   string       `json:"content"`
 	UserID    uint         `json:"user_id"`
+	Status    string       `json:"status"`
 	User      UserResponse `json:"user,omitempty"`
 	CreatedAt time.Time    `json:"created_at"`
 	UpdatedAt time.Time    `json:"updated_at"`
+
+	CommentCount    int64      `json:"comment_count"`
+	LastCommentedAt *time.Time `json:"last_commented_at"`
+	ViewCount       int64      `json:"view_count"`
+	CommentsLocked  bool       `json:"comments_locked"`
+	CommentPolicy   string     `json:"comment_policy"`
+	Tags            []string   `json:"tags"`
+
+	Reactions     []ReactionSummary `json:"reactions"`
+	BookmarkCount *int64            `json:"bookmark_count,omitempty"`
 }
 
 func (p *Post) ToResponse() PostResponse {
@@ -56,8 +119,24 @@ func (p *Post) ToResponse() PostResponse {
 		Title:     p.Title,
 		Content:   p.Content,
 		UserID:    p.UserID,
+		Status:    p.Status,
 		CreatedAt: p.CreatedAt,
 		UpdatedAt: p.UpdatedAt,
+
+		CommentCount:    p.CommentCount,
+		LastCommentedAt: p.LastCommentedAt,
+		ViewCount:       p.ViewCount,
+		CommentsLocked:  p.CommentsLocked,
+		CommentPolicy:   p.CommentPolicy,
+		Tags:            tagNames(p.Tags),
+
+		Attachments: p.Attachments,
+
+		Reactions:     p.Reactions,
+		BookmarkCount: p.BookmarkCount,
+	}
+	if response.Attachments == nil {
+		response.Attachments = []Attachment{}
 	}
 
 	if p.User.ID != 0 {
@@ -80,8 +159,19 @@ func (p *Post) ToListResponse() PostListResponse {
 		Title:     p.Title,
 		Content:   p.Content,
 		UserID:    p.UserID,
+		Status:    p.Status,
 		CreatedAt: p.CreatedAt,
 		UpdatedAt: p.UpdatedAt,
+
+		CommentCount:    p.CommentCount,
+		LastCommentedAt: p.LastCommentedAt,
+		ViewCount:       p.ViewCount,
+		CommentsLocked:  p.CommentsLocked,
+		CommentPolicy:   p.CommentPolicy,
+		Tags:            tagNames(p.Tags),
+
+		Reactions:     p.Reactions,
+		BookmarkCount: p.BookmarkCount,
 	}
 
 	if p.User.ID != 0 {
@@ -90,3 +180,9 @@ func (p *Post) ToListResponse() PostListResponse {
 
 	return response
 }
+
+// PopularPostResponse 是点赞排行中的文章，WeeklyLikes 为统计周期内新增的点赞数
+type PopularPostResponse struct {
+	PostListResponse
+	WeeklyLikes int64 `json:"weekly_likes"`
+}
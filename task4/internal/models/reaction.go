@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+const (
+	ReactionTargetPost    = "post"
+	ReactionTargetComment = "comment"
+
+	ReactionLike     = "like"
+	ReactionHeart    = "heart"
+	ReactionLaugh    = "laugh"
+	ReactionHooray   = "hooray"
+	ReactionConfused = "confused"
+	ReactionRocket   = "rocket"
+	ReactionEyes     = "eyes"
+)
+
+// ReactionTypes 是固定的表情集合，也是返回时的排列顺序
+var ReactionTypes = []string{
+	ReactionLike,
+	ReactionHeart,
+	ReactionLaugh,
+	ReactionHooray,
+	ReactionConfused,
+	ReactionRocket,
+	ReactionEyes,
+}
+
+// Reaction 每个用户对同一对象的每种表情最多一条
+type Reaction struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	UserID     uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_reactions_unique"`
+	TargetType string    `json:"target_type" gorm:"not null;size:20;uniqueIndex:idx_reactions_unique;index:idx_reactions_target"`
+	TargetID   uint      `json:"target_id" gorm:"not null;uniqueIndex:idx_reactions_unique;index:idx_reactions_target"`
+	Type       string    `json:"type" gorm:"not null;size:20;uniqueIndex:idx_reactions_unique"`
+	CreatedAt  time.Time `json:"created_at" gorm:"index"`
+}
+
+type ReactionRequest struct {
+	Type string `json:"type" binding:"required"`
+}
+
+// ReactionSummary 是某种表情的数量以及当前用户是否使用过
+type ReactionSummary struct {
+	Type    string `json:"type"`
+	Count   int64  `json:"count"`
+	Reacted bool   `json:"reacted"`
+}
+
+type ReactionToggleResponse struct {
+	Type      string            `json:"type"`
+	Reacted   bool              `json:"reacted"`
+	Reactions []ReactionSummary `json:"reactions"`
+}
+
+func IsValidReactionType(reactionType string) bool {
+	for _, t := range ReactionTypes {
+		if t == reactionType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	MaxTagsPerPost = 10
+	MaxTagLength   = 50
+)
+
+type Tag struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"not null;size:50"`
+	Slug      string    `json:"slug" gorm:"uniqueIndex;not null;size:50"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TagSlug 生成用于 URL 和去重的标签标识：转小写，空白替换为连字符
+func TagSlug(name string) string {
+	fields := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return unicode.IsSpace(r) || r == '/' || r == '?' || r == '#'
+	})
+	return strings.Join(fields, "-")
+}
+
+func tagNames(tags []Tag) []string {
+	names := make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = tag.Name
+	}
+	return names
+}
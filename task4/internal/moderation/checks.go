@@ -0,0 +1,111 @@
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// History 提供评论者的历史记录，由服务层基于数据库实现
+type History interface {
+	CountRecentComments(ctx context.Context, userID uint, since time.Time) (int64, error)
+	CountApprovedComments(ctx context.Context, userID uint) (int64, error)
+}
+
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+`)
+
+func countLinks(content string) int {
+	return len(linkPattern.FindAllStringIndex(content, -1))
+}
+
+// LinkLimit 链接数超过上限的评论进入人工审核
+type LinkLimit struct {
+	Max int
+}
+
+func (c *LinkLimit) Name() string { return "link_limit" }
+
+func (c *LinkLimit) Check(ctx context.Context, in *Input) (Verdict, string, error) {
+	if n := countLinks(in.Content); n > c.Max {
+		return Review, fmt.Sprintf("包含%d个链接，超过上限%d", n, c.Max), nil
+	}
+	return Approve, "", nil
+}
+
+// BannedWords 包含违禁词的评论直接拒绝，匹配不区分大小写
+type BannedWords struct {
+	words []string
+}
+
+func NewBannedWords(words []string) *BannedWords {
+	c := &BannedWords{}
+	for _, word := range words {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			c.words = append(c.words, word)
+		}
+	}
+	return c
+}
+
+func (c *BannedWords) Name() string { return "banned_words" }
+
+func (c *BannedWords) Check(ctx context.Context, in *Input) (Verdict, string, error) {
+	content := strings.ToLower(in.Content)
+	for _, word := range c.words {
+		if strings.Contains(content, word) {
+			return Reject, "包含违禁词", nil
+		}
+	}
+	return Approve, "", nil
+}
+
+// RateLimit 限制每个用户在时间窗口内的评论数
+type RateLimit struct {
+	Max     int
+	Window  time.Duration
+	History History
+	// Now 为空时使用 time.Now
+	Now func() time.Time
+}
+
+func (c *RateLimit) Name() string { return "rate_limit" }
+
+func (c *RateLimit) Check(ctx context.Context, in *Input) (Verdict, string, error) {
+	if c.Max <= 0 {
+		return Approve, "", nil
+	}
+
+	now := time.Now
+	if c.Now != nil {
+		now = c.Now
+	}
+
+	n, err := c.History.CountRecentComments(ctx, in.UserID, now().Add(-c.Window))
+	if err != nil {
+		return Approve, "", err
+	}
+	if n >= int64(c.Max) {
+		return Reject, fmt.Sprintf("评论过于频繁，%s内最多%d条", c.Window, c.Max), nil
+	}
+	return Approve, "", nil
+}
+
+// FirstTimeCommenter 没有通过审核的历史评论的用户需要人工审核
+type FirstTimeCommenter struct {
+	History History
+}
+
+func (c *FirstTimeCommenter) Name() string { return "first_time_commenter" }
+
+func (c *FirstTimeCommenter) Check(ctx context.Context, in *Input) (Verdict, string, error) {
+	n, err := c.History.CountApprovedComments(ctx, in.UserID)
+	if err != nil {
+		return Approve, "", err
+	}
+	if n == 0 {
+		return Review, "首次评论需要审核", nil
+	}
+	return Approve, "", nil
+}
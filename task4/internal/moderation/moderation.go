@@ -0,0 +1,84 @@
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+type Verdict int
+
+const (
+	Approve Verdict = iota
+	Review
+	Reject
+)
+
+func (v Verdict) String() string {
+	switch v {
+	case Review:
+		return "review"
+	case Reject:
+		return "reject"
+	default:
+		return "approve"
+	}
+}
+
+// Input 是待审核的评论
+type Input struct {
+	UserID  uint
+	PostID  uint
+	Content string
+}
+
+type Result struct {
+	Verdict Verdict
+	Reasons []string
+}
+
+func (r Result) Reason() string {
+	return strings.Join(r.Reasons, "; ")
+}
+
+// Check 是一个可插拔的审核规则
+type Check interface {
+	Name() string
+	Check(ctx context.Context, in *Input) (Verdict, string, error)
+}
+
+type Pipeline struct {
+	checks []Check
+}
+
+func NewPipeline(checks ...Check) *Pipeline {
+	return &Pipeline{checks: checks}
+}
+
+// Run 依次执行所有规则，结果取最严重的一项，遇到拒绝时立即返回
+func (p *Pipeline) Run(ctx context.Context, in *Input) (Result, error) {
+	result := Result{Verdict: Approve}
+
+	for _, check := range p.checks {
+		verdict, reason, err := check.Check(ctx, in)
+		if err != nil {
+			return Result{}, fmt.Errorf("moderation check %s: %w", check.Name(), err)
+		}
+		if verdict == Approve {
+			continue
+		}
+
+		if verdict > result.Verdict {
+			result.Verdict = verdict
+			result.Reasons = nil
+		}
+		if verdict == result.Verdict {
+			result.Reasons = append(result.Reasons, reason)
+		}
+		if verdict == Reject {
+			break
+		}
+	}
+
+	return result, nil
+}
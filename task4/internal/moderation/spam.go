@@ -0,0 +1,102 @@
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+var spamPhrases = []string{
+	"buy now",
+	"click here",
+	"free money",
+	"earn money",
+	"work from home",
+	"casino",
+	"viagra",
+	"crypto giveaway",
+	"加微信",
+	"代开发票",
+	"刷单",
+	"兼职日结",
+}
+
+// SpamScore 计算 0 到 1 之间的启发式垃圾评分
+func SpamScore(content string) float64 {
+	score := 0.0
+	lower := strings.ToLower(content)
+
+	links := countLinks(content)
+	score += minFloat(float64(links)*0.2, 0.6)
+	if links > 0 && len([]rune(linkPattern.ReplaceAllString(content, ""))) < 20 {
+		score += 0.2
+	}
+
+	for _, phrase := range spamPhrases {
+		if strings.Contains(lower, phrase) {
+			score += 0.3
+		}
+	}
+
+	var letters, upper int
+	for _, r := range content {
+		if unicode.IsLetter(r) && r < unicode.MaxASCII {
+			letters++
+			if unicode.IsUpper(r) {
+				upper++
+			}
+		}
+	}
+	if letters >= 20 && float64(upper)/float64(letters) > 0.6 {
+		score += 0.3
+	}
+
+	if hasRepeatedRun(content, 6) {
+		score += 0.2
+	}
+
+	return minFloat(score, 1)
+}
+
+func hasRepeatedRun(content string, n int) bool {
+	var prev rune
+	run := 0
+	for _, r := range content {
+		if r == prev {
+			run++
+		} else {
+			prev, run = r, 1
+		}
+		if run >= n && !unicode.IsSpace(r) {
+			return true
+		}
+	}
+	return false
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Spam 按评分阈值决定进入审核或直接拒绝
+type Spam struct {
+	ReviewScore float64
+	RejectScore float64
+}
+
+func (c *Spam) Name() string { return "spam_score" }
+
+func (c *Spam) Check(ctx context.Context, in *Input) (Verdict, string, error) {
+	score := SpamScore(in.Content)
+	switch {
+	case score >= c.RejectScore:
+		return Reject, fmt.Sprintf("垃圾评论评分%.2f", score), nil
+	case score >= c.ReviewScore:
+		return Review, fmt.Sprintf("垃圾评论评分%.2f", score), nil
+	}
+	return Approve, "", nil
+}
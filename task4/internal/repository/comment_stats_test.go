@@ -0,0 +1,67 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"blog-system/internal/app/apptest"
+	"blog-system/internal/models"
+	"blog-system/internal/repository"
+	"blog-system/pkg/clock"
+)
+
+// 审核通过一条排队较久的评论时，文章的最后评论时间不能倒退
+func TestApproveOldCommentKeepsLastCommentedAt(t *testing.T) {
+	env := apptest.New(t)
+	memClock := clock.NewFixed(env.Clock.Now())
+
+	for name, tc := range map[string]struct {
+		repos repository.Repositories
+		clock *clock.Fixed
+	}{
+		"gorm":   {env.App.Repos, env.Clock},
+		"memory": {repository.NewMemory(memClock), memClock},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			repos := tc.repos
+
+			user := &models.User{Username: "alice", Email: "alice@example.com", Password: "x", Role: models.RoleUser}
+			if err := repos.Users.Create(ctx, user); err != nil {
+				t.Fatalf("create user: %v", err)
+			}
+			post := &models.Post{Title: "title", Content: "content", UserID: user.ID, Status: models.PostStatusPublished}
+			if err := repos.Posts.Create(ctx, post, nil, nil); err != nil {
+				t.Fatalf("create post: %v", err)
+			}
+
+			queued := &models.Comment{Content: "queued", UserID: user.ID, PostID: post.ID, Status: models.CommentStatusPending}
+			if err := repos.Comments.Create(ctx, queued, nil); err != nil {
+				t.Fatalf("create queued comment: %v", err)
+			}
+
+			tc.clock.Advance(time.Hour)
+			latest := &models.Comment{Content: "latest", UserID: user.ID, PostID: post.ID, Status: models.CommentStatusApproved}
+			if err := repos.Comments.Create(ctx, latest, nil); err != nil {
+				t.Fatalf("create approved comment: %v", err)
+			}
+
+			tc.clock.Advance(time.Hour)
+			if err := repos.Comments.SetStatus(ctx, queued, models.CommentStatusApproved, ""); err != nil {
+				t.Fatalf("approve queued comment: %v", err)
+			}
+
+			got, err := repos.Posts.FindByID(ctx, post.ID)
+			if err != nil {
+				t.Fatalf("find post: %v", err)
+			}
+			if got.CommentCount != 2 {
+				t.Errorf("comment_count = %d, want 2", got.CommentCount)
+			}
+			if got.LastCommentedAt == nil || !got.LastCommentedAt.Equal(latest.CreatedAt) {
+				t.Errorf("last_commented_at = %v, want %v", got.LastCommentedAt, latest.CreatedAt)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// NewGorm 返回基于 GORM 的仓储，表结构由 pkg/database 的迁移维护
+func NewGorm(db *gorm.DB) Repositories {
+	return Repositories{
+		Users:    &gormUsers{db: db},
+		Posts:    &gormPosts{db: db},
+		Comments: &gormComments{db: db},
+	}
+}
+
+// notFound 把 gorm.ErrRecordNotFound 转换为 ErrNotFound
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
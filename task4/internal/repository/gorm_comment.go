@@ -0,0 +1,280 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"blog-system/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormComments struct {
+	db *gorm.DB
+}
+
+func (r *gormComments) CountRecentComments(ctx context.Context, userID uint, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Comment{}).
+		Where("user_id = ? AND created_at >= ?", userID, since).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *gormComments) CountApprovedComments(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Comment{}).
+		Where("user_id = ? AND status = ?", userID, models.CommentStatusApproved).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *gormComments) Create(ctx context.Context, comment *models.Comment, parent *models.Comment) error {
+	db := r.db.WithContext(ctx)
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(comment).Error; err != nil {
+			return err
+		}
+		comment.BuildPath(parent)
+		if err := tx.Model(comment).UpdateColumn("path", comment.Path).Error; err != nil {
+			return err
+		}
+		if comment.Status != models.CommentStatusApproved {
+			return nil
+		}
+		return incrementCommentStats(tx, comment.PostID, comment.CreatedAt)
+	}); err != nil {
+		return err
+	}
+
+	return db.Preload("User").First(comment, comment.ID).Error
+}
+
+func (r *gormComments) FindByID(ctx context.Context, id uint) (*models.Comment, error) {
+	var comment models.Comment
+	if err := r.db.WithContext(ctx).Preload("Post").Preload("User").First(&comment, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &comment, nil
+}
+
+func (r *gormComments) FindUnscoped(ctx context.Context, id uint) (*models.Comment, error) {
+	var comment models.Comment
+	if err := r.db.WithContext(ctx).Unscoped().First(&comment, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &comment, nil
+}
+
+func (r *gormComments) Edit(ctx context.Context, comment *models.Comment, editorID uint, content string, editedAt time.Time, status, reason string) error {
+	db := r.db.WithContext(ctx)
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		revision := &models.CommentRevision{
+			CommentID: comment.ID,
+			Content:   comment.Content,
+			EditorID:  editorID,
+		}
+		if err := tx.Create(revision).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(comment).Updates(map[string]interface{}{
+			"content":   content,
+			"edited_at": editedAt,
+		}).Error; err != nil {
+			return err
+		}
+		if status != "" {
+			return setCommentStatus(tx, comment, status, reason)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return db.Preload("User").First(comment, comment.ID).Error
+}
+
+func (r *gormComments) SetStatus(ctx context.Context, comment *models.Comment, status, reason string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return setCommentStatus(tx, comment, status, reason)
+	})
+}
+
+func (r *gormComments) Remove(ctx context.Context, comment *models.Comment) error {
+	counted := comment.Status == models.CommentStatusApproved
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := removeComment(tx, comment); err != nil {
+			return err
+		}
+		if !counted {
+			return nil
+		}
+		return decrementCommentStats(tx, comment.PostID)
+	})
+}
+
+func (r *gormComments) Revisions(ctx context.Context, commentID uint) ([]models.CommentRevision, error) {
+	var revisions []models.CommentRevision
+	err := r.db.WithContext(ctx).Where("comment_id = ?", commentID).
+		Order("created_at ASC").
+		Find(&revisions).Error
+	return revisions, err
+}
+
+// commentSortColumns 是评论列表允许的排序字段
+var commentSortColumns = map[string]string{
+	"created_at": "comments.created_at",
+	"updated_at": "comments.updated_at",
+}
+
+func (r *gormComments) ListThreads(ctx context.Context, postID uint, page Page) ([]models.Comment, error) {
+	query, err := applyPage(r.db.WithContext(ctx).Preload("User"), page, commentSortColumns, "comments.id")
+	if err != nil {
+		return nil, err
+	}
+
+	var roots []models.Comment
+	err = query.Where("post_id = ? AND depth = 0 AND status = ?", postID, models.CommentStatusApproved).
+		Find(&roots).Error
+	return roots, err
+}
+
+func (r *gormComments) CountThreads(ctx context.Context, postID uint) (int64, error) {
+	var total int64
+	err := r.db.WithContext(ctx).Model(&models.Comment{}).
+		Where("post_id = ? AND depth = 0 AND status = ?", postID, models.CommentStatusApproved).
+		Count(&total).Error
+	return total, err
+}
+
+func (r *gormComments) ListReplies(ctx context.Context, postID uint, roots []models.Comment) ([]models.Comment, error) {
+	if len(roots) == 0 {
+		return nil, nil
+	}
+	prefixes := make([]string, len(roots))
+	for i := range roots {
+		prefixes[i] = roots[i].Path
+	}
+
+	var replies []models.Comment
+	err := r.db.WithContext(ctx).Preload("User").
+		Where("post_id = ? AND depth > 0 AND status = ? AND SUBSTR(path, 1, ?) IN ?",
+			postID, models.CommentStatusApproved, models.CommentPathWidth, prefixes).
+		Order("path ASC").
+		Find(&replies).Error
+	return replies, err
+}
+
+func (r *gormComments) ListByStatus(ctx context.Context, status string, ownerID uint, page Page) ([]models.Comment, error) {
+	query := r.db.WithContext(ctx).Preload("User").Preload("Post").Where("status = ?", status)
+	if ownerID != 0 {
+		query = query.Where("post_id IN (SELECT id FROM posts WHERE user_id = ? AND deleted_at IS NULL)", ownerID)
+	}
+	query, err := applyPage(query, page, map[string]string{"created_at": "comments.created_at"}, "comments.id")
+	if err != nil {
+		return nil, err
+	}
+
+	var comments []models.Comment
+	err = query.Find(&comments).Error
+	return comments, err
+}
+
+func (r *gormComments) IsBanned(ctx context.Context, userID, postOwnerID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.CommentBan{}).
+		Where("user_id = ? AND owner_id IN ?", userID, []uint{0, postOwnerID}).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *gormComments) Ban(ctx context.Context, ban *models.CommentBan, reject *models.Comment) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if reject != nil {
+			if err := setCommentStatus(tx, reject, models.CommentStatusRejected, ban.Reason); err != nil {
+				return err
+			}
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(ban).Error
+	})
+}
+
+func (r *gormComments) RecountPostStats(ctx context.Context) (int64, error) {
+	return recountCommentStats(r.db.WithContext(ctx))
+}
+
+// setCommentStatus 修改评论状态并同步文章的评论计数
+func setCommentStatus(tx *gorm.DB, comment *models.Comment, status, reason string) error {
+	previous := comment.Status
+	if err := tx.Model(comment).Updates(map[string]interface{}{
+		"status":            status,
+		"moderation_reason": reason,
+	}).Error; err != nil {
+		return err
+	}
+
+	switch {
+	case previous != models.CommentStatusApproved && status == models.CommentStatusApproved:
+		return incrementCommentStats(tx, comment.PostID, comment.CreatedAt)
+	case previous == models.CommentStatusApproved && status != models.CommentStatusApproved:
+		return decrementCommentStats(tx, comment.PostID)
+	}
+	return nil
+}
+
+// removeComment 有回复的评论保留为 "[deleted]" 占位，否则直接删除，
+// 并向上清理已经没有回复的占位评论
+func removeComment(tx *gorm.DB, comment *models.Comment) error {
+	var replies int64
+	if err := countLiveReplies(tx, comment.ID, &replies); err != nil {
+		return err
+	}
+
+	if replies > 0 {
+		if err := tx.Model(comment).Updates(map[string]interface{}{
+			"is_deleted": true,
+			"content":    "",
+		}).Error; err != nil {
+			return err
+		}
+		comment.IsDeleted = true
+		comment.Content = ""
+		return nil
+	}
+
+	if err := tx.Delete(comment).Error; err != nil {
+		return err
+	}
+
+	for parentID := comment.ParentID; parentID != nil; {
+		var parent models.Comment
+		if err := tx.Where("is_deleted = ?", true).First(&parent, *parentID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		if err := countLiveReplies(tx, parent.ID, &replies); err != nil {
+			return err
+		}
+		if replies > 0 {
+			return nil
+		}
+
+		if err := tx.Delete(&parent).Error; err != nil {
+			return err
+		}
+		parentID = parent.ParentID
+	}
+
+	return nil
+}
+
+func countLiveReplies(tx *gorm.DB, commentID uint, count *int64) error {
+	return tx.Model(&models.Comment{}).
+		Where("parent_id = ? AND status <> ?", commentID, models.CommentStatusRejected).
+		Count(count).Error
+}
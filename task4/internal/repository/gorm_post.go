@@ -0,0 +1,218 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"blog-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type gormPosts struct {
+	db *gorm.DB
+}
+
+func (r *gormPosts) Create(ctx context.Context, post *models.Post, tags []string, attachmentIDs []uint) error {
+	db := r.db.WithContext(ctx)
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		resolved, err := resolveTags(tx, tags)
+		if err != nil {
+			return err
+		}
+		post.Tags = resolved
+		if err := tx.Create(post).Error; err != nil {
+			return err
+		}
+		return linkAttachments(tx, post, attachmentIDs)
+	}); err != nil {
+		return err
+	}
+
+	return db.Preload("User").Preload("Tags").Preload("Attachments").First(post, post.ID).Error
+}
+
+func (r *gormPosts) FindByID(ctx context.Context, id uint) (*models.Post, error) {
+	var post models.Post
+	if err := r.db.WithContext(ctx).First(&post, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &post, nil
+}
+
+func (r *gormPosts) FindDetail(ctx context.Context, id uint) (*models.Post, error) {
+	var post models.Post
+	if err := r.db.WithContext(ctx).Preload("User").
+		Preload("Tags").
+		Preload("Attachments").
+		Preload("Comments", "status = ?", models.CommentStatusApproved).
+		Preload("Comments.User").
+		First(&post, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &post, nil
+}
+
+func (r *gormPosts) Update(ctx context.Context, post *models.Post, changes PostChanges) error {
+	db := r.db.WithContext(ctx)
+	updates := make(map[string]interface{})
+	if changes.Title != nil {
+		updates["title"] = *changes.Title
+	}
+	if changes.Content != nil {
+		updates["content"] = *changes.Content
+	}
+	if changes.Status != nil {
+		updates["status"] = *changes.Status
+	}
+	if changes.CommentsLocked != nil {
+		updates["comments_locked"] = *changes.CommentsLocked
+	}
+	if changes.CommentPolicy != nil {
+		updates["comment_policy"] = *changes.CommentPolicy
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(post).Updates(updates).Error; err != nil {
+			return err
+		}
+		if changes.AttachmentIDs != nil {
+			if err := linkAttachments(tx, post, *changes.AttachmentIDs); err != nil {
+				return err
+			}
+		}
+		if changes.Tags == nil {
+			return nil
+		}
+
+		tags, err := resolveTags(tx, *changes.Tags)
+		if err != nil {
+			return err
+		}
+		return tx.Model(post).Association("Tags").Replace(tags)
+	}); err != nil {
+		return err
+	}
+
+	return db.Preload("User").Preload("Tags").Preload("Attachments").First(post, post.ID).Error
+}
+
+func (r *gormPosts) Delete(ctx context.Context, post *models.Post) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("post_id = ?", post.ID).Delete(&models.Comment{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(post).Error
+	})
+}
+
+// postSortColumns 是文章列表允许的排序字段
+var postSortColumns = map[string]string{
+	"created_at":    "posts.created_at",
+	"updated_at":    "posts.updated_at",
+	"title":         "posts.title",
+	"comment_count": "posts.comment_count",
+	"view_count":    "posts.view_count",
+}
+
+func (r *gormPosts) List(ctx context.Context, filter PostFilter, page Page) ([]models.Post, error) {
+	query, err := applyPage(filterPosts(r.db.WithContext(ctx), filter), page, postSortColumns, "posts.id")
+	if err != nil {
+		return nil, err
+	}
+
+	var posts []models.Post
+	if err := query.Preload("User").Preload("Tags").Find(&posts).Error; err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+func (r *gormPosts) Count(ctx context.Context, filter PostFilter) (int64, error) {
+	var total int64
+	err := filterPosts(r.db.WithContext(ctx), filter).Count(&total).Error
+	return total, err
+}
+
+func filterPosts(db *gorm.DB, filter PostFilter) *gorm.DB {
+	db = db.Model(&models.Post{}).Where("posts.status = ?", filter.Status)
+
+	if filter.OwnerID != 0 {
+		db = db.Where("posts.user_id = ?", filter.OwnerID)
+	}
+	if filter.AuthorID != 0 {
+		db = db.Where("posts.user_id = ?", filter.AuthorID)
+	}
+	if filter.AuthorUsername != "" {
+		db = db.Where("posts.user_id IN (SELECT id FROM users WHERE username = ? AND deleted_at IS NULL)", filter.AuthorUsername)
+	}
+	if filter.CreatedAfter != nil {
+		db = db.Where("posts.created_at >= ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		db = db.Where("posts.created_at < ?", *filter.CreatedBefore)
+	}
+	if filter.UpdatedAfter != nil {
+		db = db.Where("posts.updated_at >= ?", *filter.UpdatedAfter)
+	}
+	if filter.UpdatedBefore != nil {
+		db = db.Where("posts.updated_at < ?", *filter.UpdatedBefore)
+	}
+	if filter.Title != "" {
+		db = db.Where(titleMatch(db), "%"+escapeLike(filter.Title)+"%")
+	}
+	if filter.Tag != "" {
+		db = db.Where("posts.id IN (SELECT post_tags.post_id FROM post_tags JOIN tags ON tags.id = post_tags.tag_id WHERE tags.slug = ?)", models.TagSlug(filter.Tag))
+	}
+	if filter.HasComments != nil {
+		if *filter.HasComments {
+			db = db.Where("posts.comment_count > 0")
+		} else {
+			db = db.Where("posts.comment_count = 0")
+		}
+	}
+	return db
+}
+
+// titleMatch 返回不区分大小写的标题匹配条件。PostgreSQL 使用 ILIKE，
+// 其他数据库退回到 LOWER 比较，SQLite 的 LOWER 只转换 ASCII 字母
+func titleMatch(db *gorm.DB) string {
+	if db.Dialector.Name() == "postgres" {
+		return "posts.title ILIKE ? ESCAPE '!'"
+	}
+	return "LOWER(posts.title) LIKE LOWER(?) ESCAPE '!'"
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
+}
+
+// linkAttachments 把当前用户上传的附件关联到文章，并解除不在列表中的附件，
+// 已关联到其他文章的附件不能使用。解除的附件记录移出时间，清理时从这个时间开始计算保留时长
+func linkAttachments(tx *gorm.DB, post *models.Post, ids []uint) error {
+	ids = slices.Compact(slices.Sorted(slices.Values(ids)))
+
+	if len(ids) > 0 {
+		var count int64
+		if err := tx.Model(&models.Attachment{}).
+			Where("id IN ? AND user_id = ? AND (post_id IS NULL OR post_id = ?)", ids, post.UserID, post.ID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count != int64(len(ids)) {
+			return ErrInvalidAttachment
+		}
+
+		if err := tx.Model(&models.Attachment{}).Where("id IN ?", ids).
+			Updates(map[string]interface{}{"post_id": post.ID, "detached_at": nil}).Error; err != nil {
+			return err
+		}
+	}
+
+	query := tx.Model(&models.Attachment{}).Where("post_id = ?", post.ID)
+	if len(ids) > 0 {
+		query = query.Where("id NOT IN ?", ids)
+	}
+	return query.Updates(map[string]interface{}{"post_id": nil, "detached_at": tx.NowFunc()}).Error
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"blog-system/internal/models"
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormUsers struct {
+	db *gorm.DB
+}
+
+func (r *gormUsers) Create(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Create(user).Error
+}
+
+func (r *gormUsers) FindByID(ctx context.Context, id uint) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).First(&user, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
+}
+
+func (r *gormUsers) FindByUsername(ctx context.Context, username string) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).Where("username = ?", username).First(&user).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
+}
+
+func (r *gormUsers) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
+}
+
+func (r *gormUsers) Follow(ctx context.Context, followerID, followeeID uint) error {
+	follow := &models.Follow{
+		FollowerID: followerID,
+		FolloweeID: followeeID,
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(follow).Error
+}
+
+func (r *gormUsers) Unfollow(ctx context.Context, followerID, followeeID uint) error {
+	return r.db.WithContext(ctx).Where("follower_id = ? AND followee_id = ?", followerID, followeeID).
+		Delete(&models.Follow{}).Error
+}
+
+func (r *gormUsers) IsFollowing(ctx context.Context, followerID, followeeID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Follow{}).
+		Where("follower_id = ? AND followee_id = ?", followerID, followeeID).
+		Count(&count).Error
+	return count > 0, err
+}
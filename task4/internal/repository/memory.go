@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"blog-system/internal/models"
+	"blog-system/pkg/clock"
+
+	"gorm.io/gorm"
+)
+
+// memoryStore 是内存仓储共用的数据，三个仓储通过同一把锁访问，
+// 读写的都是副本，调用方修改返回的记录不会影响存储的数据
+type memoryStore struct {
+	mu    sync.RWMutex
+	clock clock.Clock
+
+	nextID    map[string]uint
+	users     map[uint]models.User
+	follows   map[[2]uint]time.Time
+	posts     map[uint]models.Post
+	postTags  map[uint][]uint
+	tags      map[uint]models.Tag
+	comments  map[uint]models.Comment
+	revisions []models.CommentRevision
+	bans      []models.CommentBan
+}
+
+// NewMemory 返回线程安全的内存仓储，时间戳取自 clk。
+// 内存中没有附件，关联附件总是返回 ErrInvalidAttachment
+func NewMemory(clk clock.Clock) Repositories {
+	s := &memoryStore{
+		clock:    clk,
+		nextID:   make(map[string]uint),
+		users:    make(map[uint]models.User),
+		follows:  make(map[[2]uint]time.Time),
+		posts:    make(map[uint]models.Post),
+		postTags: make(map[uint][]uint),
+		tags:     make(map[uint]models.Tag),
+		comments: make(map[uint]models.Comment),
+	}
+	return Repositories{
+		Users:    &memoryUsers{s},
+		Posts:    &memoryPosts{s},
+		Comments: &memoryComments{s},
+	}
+}
+
+func (s *memoryStore) newID(table string) uint {
+	s.nextID[table]++
+	return s.nextID[table]
+}
+
+func (s *memoryStore) now() time.Time {
+	return s.clock.Now()
+}
+
+func deletedAt(t time.Time) gorm.DeletedAt {
+	return gorm.DeletedAt{Time: t, Valid: true}
+}
+
+// user 返回未删除的用户，不存在时返回零值，和 Preload 的行为一致
+func (s *memoryStore) user(id uint) models.User {
+	if user, ok := s.users[id]; ok && !user.DeletedAt.Valid {
+		return user
+	}
+	return models.User{}
+}
+
+// post 返回带有作者和标签的文章
+func (s *memoryStore) post(id uint) (models.Post, bool) {
+	post, ok := s.posts[id]
+	if !ok || post.DeletedAt.Valid {
+		return models.Post{}, false
+	}
+
+	post.User = s.user(post.UserID)
+	post.Tags = []models.Tag{}
+	for _, tagID := range s.postTags[id] {
+		post.Tags = append(post.Tags, s.tags[tagID])
+	}
+	post.Attachments = []models.Attachment{}
+	return post, true
+}
+
+// resolveTags 按 slug 查找或创建标签
+func (s *memoryStore) resolveTags(names []string) ([]uint, error) {
+	tags, err := normalizeTags(names)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, 0, len(tags))
+	for _, tag := range tags {
+		id := s.tagBySlug(tag.Slug)
+		if id == 0 {
+			tag.ID = s.newID("tags")
+			tag.CreatedAt = s.now()
+			s.tags[tag.ID] = tag
+			id = tag.ID
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (s *memoryStore) tagBySlug(slug string) uint {
+	for id, tag := range s.tags {
+		if tag.Slug == slug {
+			return id
+		}
+	}
+	return 0
+}
+
+// liveComments 返回文章下未删除的评论，按 ID 排序
+func (s *memoryStore) liveComments(filter func(*models.Comment) bool) []models.Comment {
+	var comments []models.Comment
+	for _, comment := range s.comments {
+		if !comment.DeletedAt.Valid && filter(&comment) {
+			comments = append(comments, comment)
+		}
+	}
+	sort.Slice(comments, func(i, j int) bool { return comments[i].ID < comments[j].ID })
+	return comments
+}
+
+// recountPost 重新计算文章的评论数和最后评论时间
+func (s *memoryStore) recountPost(postID uint) {
+	post, ok := s.posts[postID]
+	if !ok {
+		return
+	}
+
+	post.CommentCount = 0
+	post.LastCommentedAt = nil
+	for _, comment := range s.liveComments(func(c *models.Comment) bool {
+		return c.PostID == postID && !c.IsDeleted && c.Status == models.CommentStatusApproved
+	}) {
+		post.CommentCount++
+		if post.LastCommentedAt == nil || comment.CreatedAt.After(*post.LastCommentedAt) {
+			createdAt := comment.CreatedAt
+			post.LastCommentedAt = &createdAt
+		}
+	}
+	s.posts[postID] = post
+}
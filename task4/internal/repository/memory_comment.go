@@ -0,0 +1,319 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"blog-system/internal/models"
+)
+
+type memoryComments struct {
+	s *memoryStore
+}
+
+func (r *memoryComments) CountRecentComments(ctx context.Context, userID uint, since time.Time) (int64, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	return int64(len(r.s.liveComments(func(c *models.Comment) bool {
+		return c.UserID == userID && !c.CreatedAt.Before(since)
+	}))), nil
+}
+
+func (r *memoryComments) CountApprovedComments(ctx context.Context, userID uint) (int64, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	return int64(len(r.s.liveComments(func(c *models.Comment) bool {
+		return c.UserID == userID && c.Status == models.CommentStatusApproved
+	}))), nil
+}
+
+func (r *memoryComments) Create(ctx context.Context, comment *models.Comment, parent *models.Comment) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.posts[comment.PostID]; !ok {
+		return ErrNotFound
+	}
+
+	comment.ID = r.s.newID("comments")
+	comment.CreatedAt = r.s.now()
+	comment.UpdatedAt = comment.CreatedAt
+	if comment.Status == "" {
+		comment.Status = models.CommentStatusApproved
+	}
+	comment.BuildPath(parent)
+	r.s.save(comment)
+	r.s.recountPost(comment.PostID)
+
+	comment.User = r.s.user(comment.UserID)
+	return nil
+}
+
+func (r *memoryComments) FindByID(ctx context.Context, id uint) (*models.Comment, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	comment, ok := r.s.comments[id]
+	if !ok || comment.DeletedAt.Valid {
+		return nil, ErrNotFound
+	}
+	comment.User = r.s.user(comment.UserID)
+	comment.Post, _ = r.s.post(comment.PostID)
+	return &comment, nil
+}
+
+func (r *memoryComments) FindUnscoped(ctx context.Context, id uint) (*models.Comment, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	comment, ok := r.s.comments[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &comment, nil
+}
+
+func (r *memoryComments) Edit(ctx context.Context, comment *models.Comment, editorID uint, content string, editedAt time.Time, status, reason string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	stored, ok := r.s.comments[comment.ID]
+	if !ok || stored.DeletedAt.Valid {
+		return ErrNotFound
+	}
+
+	r.s.revisions = append(r.s.revisions, models.CommentRevision{
+		ID:        r.s.newID("comment_revisions"),
+		CommentID: stored.ID,
+		Content:   stored.Content,
+		EditorID:  editorID,
+		CreatedAt: r.s.now(),
+	})
+
+	stored.Content = content
+	stored.EditedAt = &editedAt
+	if status != "" {
+		stored.Status = status
+		stored.ModerationReason = reason
+	}
+	stored.UpdatedAt = r.s.now()
+	r.s.save(&stored)
+	r.s.recountPost(stored.PostID)
+
+	stored.User = r.s.user(stored.UserID)
+	stored.Post = comment.Post
+	*comment = stored
+	return nil
+}
+
+func (r *memoryComments) SetStatus(ctx context.Context, comment *models.Comment, status, reason string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	return r.s.setStatus(comment, status, reason)
+}
+
+func (r *memoryComments) Remove(ctx context.Context, comment *models.Comment) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	stored, ok := r.s.comments[comment.ID]
+	if !ok || stored.DeletedAt.Valid {
+		return ErrNotFound
+	}
+	defer r.s.recountPost(stored.PostID)
+
+	if r.s.liveReplies(stored.ID) > 0 {
+		stored.IsDeleted = true
+		stored.Content = ""
+		r.s.save(&stored)
+		comment.IsDeleted = true
+		comment.Content = ""
+		return nil
+	}
+
+	now := r.s.now()
+	stored.DeletedAt = deletedAt(now)
+	r.s.save(&stored)
+
+	for parentID := stored.ParentID; parentID != nil; {
+		parent, ok := r.s.comments[*parentID]
+		if !ok || parent.DeletedAt.Valid || !parent.IsDeleted || r.s.liveReplies(parent.ID) > 0 {
+			return nil
+		}
+		parent.DeletedAt = deletedAt(now)
+		r.s.save(&parent)
+		parentID = parent.ParentID
+	}
+	return nil
+}
+
+func (r *memoryComments) Revisions(ctx context.Context, commentID uint) ([]models.CommentRevision, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var revisions []models.CommentRevision
+	for _, revision := range r.s.revisions {
+		if revision.CommentID == commentID {
+			revisions = append(revisions, revision)
+		}
+	}
+	sort.SliceStable(revisions, func(i, j int) bool { return revisions[i].CreatedAt.Before(revisions[j].CreatedAt) })
+	return revisions, nil
+}
+
+func (r *memoryComments) ListThreads(ctx context.Context, postID uint, page Page) ([]models.Comment, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	roots, err := pageRows(r.s.threads(postID), page, func(c *models.Comment, sort string) (any, uint, bool) {
+		switch sort {
+		case "created_at":
+			return c.CreatedAt, c.ID, true
+		case "updated_at":
+			return c.UpdatedAt, c.ID, true
+		}
+		return nil, 0, false
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range roots {
+		roots[i].User = r.s.user(roots[i].UserID)
+	}
+	return roots, nil
+}
+
+func (r *memoryComments) CountThreads(ctx context.Context, postID uint) (int64, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	return int64(len(r.s.threads(postID))), nil
+}
+
+func (r *memoryComments) ListReplies(ctx context.Context, postID uint, roots []models.Comment) ([]models.Comment, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	prefixes := make(map[string]bool, len(roots))
+	for _, root := range roots {
+		prefixes[root.Path] = true
+	}
+	replies := r.s.liveComments(func(c *models.Comment) bool {
+		return c.PostID == postID && c.Depth > 0 && c.Status == models.CommentStatusApproved &&
+			prefixes[c.Path[:models.CommentPathWidth]]
+	})
+	sort.Slice(replies, func(i, j int) bool { return replies[i].Path < replies[j].Path })
+	for i := range replies {
+		replies[i].User = r.s.user(replies[i].UserID)
+	}
+	return replies, nil
+}
+
+func (r *memoryComments) ListByStatus(ctx context.Context, status string, ownerID uint, page Page) ([]models.Comment, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	comments, err := pageRows(r.s.liveComments(func(c *models.Comment) bool {
+		if c.Status != status {
+			return false
+		}
+		post, ok := r.s.post(c.PostID)
+		return ownerID == 0 || (ok && post.UserID == ownerID)
+	}), page, func(c *models.Comment, sort string) (any, uint, bool) {
+		return c.CreatedAt, c.ID, sort == "created_at"
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range comments {
+		comments[i].User = r.s.user(comments[i].UserID)
+		comments[i].Post, _ = r.s.post(comments[i].PostID)
+	}
+	return comments, nil
+}
+
+func (r *memoryComments) IsBanned(ctx context.Context, userID, postOwnerID uint) (bool, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	for _, ban := range r.s.bans {
+		if ban.UserID == userID && (ban.OwnerID == 0 || ban.OwnerID == postOwnerID) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memoryComments) Ban(ctx context.Context, ban *models.CommentBan, reject *models.Comment) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if reject != nil {
+		if err := r.s.setStatus(reject, models.CommentStatusRejected, ban.Reason); err != nil {
+			return err
+		}
+	}
+
+	for _, existing := range r.s.bans {
+		if existing.UserID == ban.UserID && existing.OwnerID == ban.OwnerID {
+			return nil
+		}
+	}
+	ban.ID = r.s.newID("comment_bans")
+	ban.CreatedAt = r.s.now()
+	r.s.bans = append(r.s.bans, *ban)
+	return nil
+}
+
+func (r *memoryComments) RecountPostStats(ctx context.Context) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for id := range r.s.posts {
+		r.s.recountPost(id)
+	}
+	return int64(len(r.s.posts)), nil
+}
+
+// threads 返回文章下已通过审核的顶层评论
+func (s *memoryStore) threads(postID uint) []models.Comment {
+	return s.liveComments(func(c *models.Comment) bool {
+		return c.PostID == postID && c.Depth == 0 && c.Status == models.CommentStatusApproved
+	})
+}
+
+// save 保存评论本身，不保存作者和文章
+func (s *memoryStore) save(comment *models.Comment) {
+	stored := *comment
+	stored.User, stored.Post, stored.Reactions = models.User{}, models.Post{}, nil
+	s.comments[stored.ID] = stored
+}
+
+func (s *memoryStore) setStatus(comment *models.Comment, status, reason string) error {
+	stored, ok := s.comments[comment.ID]
+	if !ok || stored.DeletedAt.Valid {
+		return ErrNotFound
+	}
+
+	stored.Status = status
+	stored.ModerationReason = reason
+	stored.UpdatedAt = s.now()
+	s.save(&stored)
+	s.recountPost(stored.PostID)
+
+	comment.Status = status
+	comment.ModerationReason = reason
+	comment.UpdatedAt = stored.UpdatedAt
+	return nil
+}
+
+// liveReplies 统计未被拒绝的直接回复，和 countLiveReplies 的条件一致
+func (s *memoryStore) liveReplies(commentID uint) int {
+	return len(s.liveComments(func(c *models.Comment) bool {
+		return c.ParentID != nil && *c.ParentID == commentID && c.Status != models.CommentStatusRejected
+	}))
+}
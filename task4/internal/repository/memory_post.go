@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"blog-system/internal/models"
+	"context"
+	"slices"
+	"strings"
+)
+
+type memoryPosts struct {
+	s *memoryStore
+}
+
+func (r *memoryPosts) Create(ctx context.Context, post *models.Post, tags []string, attachmentIDs []uint) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if len(attachmentIDs) > 0 {
+		return ErrInvalidAttachment
+	}
+	tagIDs, err := r.s.resolveTags(tags)
+	if err != nil {
+		return err
+	}
+
+	stored := *post
+	stored.ID = r.s.newID("posts")
+	stored.CreatedAt = r.s.now()
+	stored.UpdatedAt = stored.CreatedAt
+	if stored.Status == "" {
+		stored.Status = models.PostStatusPublished
+	}
+	if stored.CommentPolicy == "" {
+		stored.CommentPolicy = models.CommentPolicyEveryone
+	}
+	stored.User, stored.Tags, stored.Comments, stored.Attachments = models.User{}, nil, nil, nil
+	r.s.posts[stored.ID] = stored
+	r.s.postTags[stored.ID] = tagIDs
+
+	*post, _ = r.s.post(stored.ID)
+	return nil
+}
+
+func (r *memoryPosts) FindByID(ctx context.Context, id uint) (*models.Post, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	post, ok := r.s.posts[id]
+	if !ok || post.DeletedAt.Valid {
+		return nil, ErrNotFound
+	}
+	return &post, nil
+}
+
+func (r *memoryPosts) FindDetail(ctx context.Context, id uint) (*models.Post, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	post, ok := r.s.post(id)
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	post.Comments = r.s.liveComments(func(c *models.Comment) bool {
+		return c.PostID == id && c.Status == models.CommentStatusApproved
+	})
+	for i := range post.Comments {
+		post.Comments[i].User = r.s.user(post.Comments[i].UserID)
+	}
+	return &post, nil
+}
+
+func (r *memoryPosts) Update(ctx context.Context, post *models.Post, changes PostChanges) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	stored, ok := r.s.posts[post.ID]
+	if !ok || stored.DeletedAt.Valid {
+		return ErrNotFound
+	}
+	if changes.AttachmentIDs != nil && len(*changes.AttachmentIDs) > 0 {
+		return ErrInvalidAttachment
+	}
+
+	var tagIDs []uint
+	if changes.Tags != nil {
+		var err error
+		if tagIDs, err = r.s.resolveTags(*changes.Tags); err != nil {
+			return err
+		}
+	}
+
+	if changes.Title != nil {
+		stored.Title = *changes.Title
+	}
+	if changes.Content != nil {
+		stored.Content = *changes.Content
+	}
+	if changes.Status != nil {
+		stored.Status = *changes.Status
+	}
+	if changes.CommentsLocked != nil {
+		stored.CommentsLocked = *changes.CommentsLocked
+	}
+	if changes.CommentPolicy != nil {
+		stored.CommentPolicy = *changes.CommentPolicy
+	}
+	stored.UpdatedAt = r.s.now()
+	r.s.posts[post.ID] = stored
+	if changes.Tags != nil {
+		r.s.postTags[post.ID] = tagIDs
+	}
+
+	*post, _ = r.s.post(post.ID)
+	return nil
+}
+
+func (r *memoryPosts) Delete(ctx context.Context, post *models.Post) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	stored, ok := r.s.posts[post.ID]
+	if !ok || stored.DeletedAt.Valid {
+		return nil
+	}
+
+	now := r.s.now()
+	for id, comment := range r.s.comments {
+		if comment.PostID == post.ID && !comment.DeletedAt.Valid {
+			comment.DeletedAt = deletedAt(now)
+			r.s.comments[id] = comment
+		}
+	}
+	stored.DeletedAt = deletedAt(now)
+	r.s.posts[post.ID] = stored
+	post.DeletedAt = stored.DeletedAt
+	return nil
+}
+
+func (r *memoryPosts) List(ctx context.Context, filter PostFilter, page Page) ([]models.Post, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	return pageRows(r.s.filterPosts(filter), page, func(p *models.Post, sort string) (any, uint, bool) {
+		switch sort {
+		case "created_at":
+			return p.CreatedAt, p.ID, true
+		case "updated_at":
+			return p.UpdatedAt, p.ID, true
+		case "title":
+			return p.Title, p.ID, true
+		case "comment_count":
+			return p.CommentCount, p.ID, true
+		case "view_count":
+			return p.ViewCount, p.ID, true
+		}
+		return nil, 0, false
+	})
+}
+
+func (r *memoryPosts) Count(ctx context.Context, filter PostFilter) (int64, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	return int64(len(r.s.filterPosts(filter))), nil
+}
+
+// filterPosts 返回符合筛选条件、带有作者和标签的文章
+func (s *memoryStore) filterPosts(filter PostFilter) []models.Post {
+	title := strings.ToLower(filter.Title)
+	tag := models.TagSlug(filter.Tag)
+
+	var posts []models.Post
+	for id := range s.posts {
+		post, ok := s.post(id)
+		if !ok || post.Status != filter.Status {
+			continue
+		}
+		if (filter.OwnerID != 0 && post.UserID != filter.OwnerID) ||
+			(filter.AuthorID != 0 && post.UserID != filter.AuthorID) ||
+			(filter.AuthorUsername != "" && post.User.Username != filter.AuthorUsername) {
+			continue
+		}
+		if (filter.CreatedAfter != nil && post.CreatedAt.Before(*filter.CreatedAfter)) ||
+			(filter.CreatedBefore != nil && !post.CreatedAt.Before(*filter.CreatedBefore)) ||
+			(filter.UpdatedAfter != nil && post.UpdatedAt.Before(*filter.UpdatedAfter)) ||
+			(filter.UpdatedBefore != nil && !post.UpdatedAt.Before(*filter.UpdatedBefore)) {
+			continue
+		}
+		if title != "" && !strings.Contains(strings.ToLower(post.Title), title) {
+			continue
+		}
+		if tag != "" && !slices.ContainsFunc(post.Tags, func(t models.Tag) bool { return t.Slug == tag }) {
+			continue
+		}
+		if filter.HasComments != nil && (post.CommentCount > 0) != *filter.HasComments {
+			continue
+		}
+		posts = append(posts, post)
+	}
+	return posts
+}
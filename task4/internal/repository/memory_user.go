@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"blog-system/internal/models"
+)
+
+type memoryUsers struct {
+	s *memoryStore
+}
+
+func (r *memoryUsers) Create(ctx context.Context, user *models.User) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, existing := range r.s.users {
+		if existing.Username == user.Username || existing.Email == user.Email {
+			return errors.New("duplicate username or email")
+		}
+	}
+
+	user.ID = r.s.newID("users")
+	user.CreatedAt = r.s.now()
+	user.UpdatedAt = user.CreatedAt
+	if user.Role == "" {
+		user.Role = models.RoleUser
+	}
+	r.s.users[user.ID] = *user
+	return nil
+}
+
+func (r *memoryUsers) FindByID(ctx context.Context, id uint) (*models.User, error) {
+	return r.find(func(u *models.User) bool { return u.ID == id })
+}
+
+func (r *memoryUsers) FindByUsername(ctx context.Context, username string) (*models.User, error) {
+	return r.find(func(u *models.User) bool { return u.Username == username })
+}
+
+func (r *memoryUsers) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	return r.find(func(u *models.User) bool { return u.Email == email })
+}
+
+func (r *memoryUsers) find(match func(*models.User) bool) (*models.User, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	for _, user := range r.s.users {
+		if !user.DeletedAt.Valid && match(&user) {
+			return &user, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryUsers) Follow(ctx context.Context, followerID, followeeID uint) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	key := [2]uint{followerID, followeeID}
+	if _, ok := r.s.follows[key]; !ok {
+		r.s.follows[key] = r.s.now()
+	}
+	return nil
+}
+
+func (r *memoryUsers) Unfollow(ctx context.Context, followerID, followeeID uint) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	delete(r.s.follows, [2]uint{followerID, followeeID})
+	return nil
+}
+
+func (r *memoryUsers) IsFollowing(ctx context.Context, followerID, followeeID uint) (bool, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	_, ok := r.s.follows[[2]uint{followerID, followeeID}]
+	return ok, nil
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"cmp"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Page 是一次 keyset 分页查询：按 Sort 对应的列和主键排序，
+// After 不为 nil 时从该位置之后开始，最多返回 Limit 行
+type Page struct {
+	Sort       string
+	Descending bool
+	After      *PagePosition
+	Limit      int
+}
+
+// PagePosition 是上一页最后一行的排序值和主键，排序值为 time.Time、string 或 int64
+type PagePosition struct {
+	Value any
+	ID    uint
+}
+
+// PostFilter 是文章列表的筛选条件，零值字段不参与筛选
+type PostFilter struct {
+	Status string
+	// OwnerID 不为 0 时只返回该用户的文章，查看草稿时为当前用户
+	OwnerID        uint
+	AuthorID       uint
+	AuthorUsername string
+	CreatedAfter   *time.Time
+	CreatedBefore  *time.Time
+	UpdatedAfter   *time.Time
+	UpdatedBefore  *time.Time
+	// Title 按字面不区分大小写匹配标题的一部分
+	Title       string
+	Tag         string
+	HasComments *bool
+}
+
+// applyPage 按 columns 中排序字段对应的列生成 keyset 条件、排序和行数限制
+func applyPage(db *gorm.DB, page Page, columns map[string]string, idColumn string) (*gorm.DB, error) {
+	column, ok := columns[page.Sort]
+	if !ok {
+		return nil, fmt.Errorf("unsupported sort field %q", page.Sort)
+	}
+
+	direction, op := "ASC", ">"
+	if page.Descending {
+		direction, op = "DESC", "<"
+	}
+
+	if page.After != nil {
+		db = db.Where(
+			fmt.Sprintf("(%s %s ?) OR (%s = ? AND %s %s ?)", column, op, column, idColumn, op),
+			page.After.Value, page.After.Value, page.After.ID,
+		)
+	}
+
+	return db.Order(column + " " + direction).
+		Order(idColumn + " " + direction).
+		Limit(page.Limit), nil
+}
+
+// pageRows 是 applyPage 的内存实现，key 返回行的排序值和主键
+func pageRows[T any](rows []T, page Page, key func(*T, string) (any, uint, bool)) ([]T, error) {
+	if len(rows) > 0 {
+		if _, _, ok := key(&rows[0], page.Sort); !ok {
+			return nil, fmt.Errorf("unsupported sort field %q", page.Sort)
+		}
+	}
+
+	// compare 按排序方向比较，返回负数表示 a 排在 b 前面
+	compare := func(aValue any, aID uint, bValue any, bID uint) int {
+		c := compareValues(aValue, bValue)
+		if c == 0 {
+			c = cmp.Compare(aID, bID)
+		}
+		if page.Descending {
+			c = -c
+		}
+		return c
+	}
+
+	var result []T
+	for i := range rows {
+		value, id, _ := key(&rows[i], page.Sort)
+		if page.After != nil && compare(value, id, page.After.Value, page.After.ID) <= 0 {
+			continue
+		}
+		result = append(result, rows[i])
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		aValue, aID, _ := key(&result[i], page.Sort)
+		bValue, bID, _ := key(&result[j], page.Sort)
+		return compare(aValue, aID, bValue, bID) < 0
+	})
+
+	if page.Limit > 0 && len(result) > page.Limit {
+		result = result[:page.Limit]
+	}
+	return result, nil
+}
+
+func compareValues(a, b any) int {
+	switch a := a.(type) {
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	case string:
+		return cmp.Compare(a, b.(string))
+	case int64:
+		return cmp.Compare(a, b.(int64))
+	}
+	return 0
+}
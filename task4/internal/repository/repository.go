@@ -0,0 +1,106 @@
+// Package repository 封装用户、文章和评论的存取，服务只依赖这里的接口。
+// NewGorm 返回基于 PostgreSQL 的实现，NewMemory 返回线程安全的内存实现，
+// 用于不依赖数据库的单元测试
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"blog-system/internal/models"
+	"blog-system/internal/moderation"
+)
+
+var (
+	// ErrNotFound 表示记录不存在或已被删除
+	ErrNotFound = errors.New("record not found")
+	// ErrInvalidAttachment 表示附件不存在、不属于文章作者或已关联到其他文章
+	ErrInvalidAttachment = errors.New("附件不存在或无权使用")
+)
+
+type UserRepository interface {
+	Create(ctx context.Context, user *models.User) error
+	FindByID(ctx context.Context, id uint) (*models.User, error)
+	FindByUsername(ctx context.Context, username string) (*models.User, error)
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+
+	// Follow 关注用户，已关注时不做任何事
+	Follow(ctx context.Context, followerID, followeeID uint) error
+	Unfollow(ctx context.Context, followerID, followeeID uint) error
+	IsFollowing(ctx context.Context, followerID, followeeID uint) (bool, error)
+}
+
+// PostChanges 是对文章的部分修改，nil 字段保持不变
+type PostChanges struct {
+	Title          *string
+	Content        *string
+	Status         *string
+	CommentsLocked *bool
+	CommentPolicy  *string
+
+	// Tags 替换文章的全部标签
+	Tags *[]string
+	// AttachmentIDs 替换文章的全部附件
+	AttachmentIDs *[]uint
+}
+
+type PostRepository interface {
+	// Create 保存文章并关联标签和附件，完成后 post 带有作者、标签和附件
+	Create(ctx context.Context, post *models.Post, tags []string, attachmentIDs []uint) error
+	FindByID(ctx context.Context, id uint) (*models.Post, error)
+	// FindDetail 加载文章及其作者、标签、附件和已通过审核的评论
+	FindDetail(ctx context.Context, id uint) (*models.Post, error)
+	// Update 应用修改，完成后 post 带有作者、标签和附件
+	Update(ctx context.Context, post *models.Post, changes PostChanges) error
+	// Delete 删除文章及其全部评论
+	Delete(ctx context.Context, post *models.Post) error
+
+	// List 按筛选条件分页查询文章，返回的文章带有作者和标签
+	List(ctx context.Context, filter PostFilter, page Page) ([]models.Post, error)
+	Count(ctx context.Context, filter PostFilter) (int64, error)
+}
+
+type CommentRepository interface {
+	// CountRecentComments 和 CountApprovedComments 供审核规则使用
+	moderation.History
+
+	// Create 保存评论并生成楼层路径，已通过审核的评论计入文章的评论数
+	Create(ctx context.Context, comment *models.Comment, parent *models.Comment) error
+	// FindByID 加载评论及其作者和文章，"[deleted]" 占位评论也会返回
+	FindByID(ctx context.Context, id uint) (*models.Comment, error)
+	// FindUnscoped 加载评论，包括已经删除的评论
+	FindUnscoped(ctx context.Context, id uint) (*models.Comment, error)
+	// Edit 把旧内容保存为修订记录并更新内容，status 不为空时同时修改状态
+	Edit(ctx context.Context, comment *models.Comment, editorID uint, content string, editedAt time.Time, status, reason string) error
+	// SetStatus 修改评论状态并同步文章的评论计数
+	SetStatus(ctx context.Context, comment *models.Comment, status, reason string) error
+	// Remove 有回复的评论保留为 "[deleted]" 占位，否则直接删除，
+	// 并向上清理已经没有回复的占位评论
+	Remove(ctx context.Context, comment *models.Comment) error
+	Revisions(ctx context.Context, commentID uint) ([]models.CommentRevision, error)
+
+	// ListThreads 分页查询文章下已通过审核的顶层评论，返回的评论带有作者
+	ListThreads(ctx context.Context, postID uint, page Page) ([]models.Comment, error)
+	CountThreads(ctx context.Context, postID uint) (int64, error)
+	// ListReplies 按楼层顺序返回 roots 下已通过审核的全部回复，返回的评论带有作者
+	ListReplies(ctx context.Context, postID uint, roots []models.Comment) ([]models.Comment, error)
+	// ListByStatus 分页查询指定状态的评论，ownerID 不为 0 时只查询该用户文章下的评论，
+	// 返回的评论带有作者和文章
+	ListByStatus(ctx context.Context, status string, ownerID uint, page Page) ([]models.Comment, error)
+
+	// IsBanned 判断用户是否被全站禁止评论或被文章作者禁止评论
+	IsBanned(ctx context.Context, userID, postOwnerID uint) (bool, error)
+	// Ban 保存禁止记录，reject 不为空时同时拒绝该评论，已经禁止时不重复保存
+	Ban(ctx context.Context, ban *models.CommentBan, reject *models.Comment) error
+
+	// RecountPostStats 根据评论重新计算所有文章的评论数和最后评论时间
+	RecountPostStats(ctx context.Context) (int64, error)
+}
+
+// Repositories 是服务用到的全部仓储
+type Repositories struct {
+	Users    UserRepository
+	Posts    PostRepository
+	Comments CommentRepository
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"blog-system/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// countedComments 是计入 posts.comment_count 的评论条件
+const countedComments = "comments.post_id = posts.id AND comments.deleted_at IS NULL AND comments.is_deleted = false " +
+	"AND comments.status = '" + models.CommentStatusApproved + "'"
+
+// incrementCommentStats 只在 commentedAt 更晚时更新最后评论时间，审核通过一条较早的评论不会让它倒退
+func incrementCommentStats(tx *gorm.DB, postID uint, commentedAt time.Time) error {
+	return tx.Model(&models.Post{}).
+		Where("id = ?", postID).
+		UpdateColumns(map[string]interface{}{
+			"comment_count": gorm.Expr("comment_count + 1"),
+			"last_commented_at": gorm.Expr("CASE WHEN last_commented_at IS NULL OR last_commented_at < ? THEN ? ELSE last_commented_at END",
+				commentedAt, commentedAt),
+		}).Error
+}
+
+func decrementCommentStats(tx *gorm.DB, postID uint) error {
+	return tx.Model(&models.Post{}).
+		Where("id = ?", postID).
+		UpdateColumns(map[string]interface{}{
+			"comment_count":     gorm.Expr("CASE WHEN comment_count > 0 THEN comment_count - 1 ELSE 0 END"),
+			"last_commented_at": gorm.Expr("(SELECT MAX(comments.created_at) FROM comments WHERE " + countedComments + ")"),
+		}).Error
+}
+
+// recountCommentStats 根据评论表重新计算所有文章的评论数和最后评论时间
+func recountCommentStats(db *gorm.DB) (int64, error) {
+	result := db.Exec("UPDATE posts SET " +
+		"comment_count = (SELECT COUNT(*) FROM comments WHERE " + countedComments + "), " +
+		"last_commented_at = (SELECT MAX(comments.created_at) FROM comments WHERE " + countedComments + ")")
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"blog-system/internal/models"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// resolveTags 按 slug 去重并查找或创建标签，保持传入的顺序
+func resolveTags(db *gorm.DB, names []string) ([]models.Tag, error) {
+	tags, err := normalizeTags(names)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range tags {
+		if err := db.Where(models.Tag{Slug: tags[i].Slug}).FirstOrCreate(&tags[i]).Error; err != nil {
+			return nil, err
+		}
+	}
+	return tags, nil
+}
+
+// normalizeTags 按 slug 去重并检查标签的长度和数量
+func normalizeTags(names []string) ([]models.Tag, error) {
+	tags := make([]models.Tag, 0, len(names))
+	seen := make(map[string]bool, len(names))
+
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		slug := models.TagSlug(name)
+		if slug == "" || seen[slug] {
+			continue
+		}
+		if len([]rune(name)) > models.MaxTagLength {
+			return nil, fmt.Errorf("标签 %s 超过%d个字符", name, models.MaxTagLength)
+		}
+		seen[slug] = true
+
+		tags = append(tags, models.Tag{Name: name, Slug: slug})
+	}
+
+	if len(tags) > models.MaxTagsPerPost {
+		return nil, errors.New("每篇文章最多10个标签")
+	}
+	return tags, nil
+}
@@ -1,62 +1,165 @@
 package routes
 
 import (
+	"blog-system/internal/app"
+	"blog-system/internal/feed"
 	"blog-system/internal/handlers"
 	"blog-system/internal/middleware"
-	"blog-system/pkg/logger"
+	"blog-system/pkg/database"
 
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRoutes(r *gin.Engine, jwtSecret string) {
-	userHandler := handlers.NewUserHandler()
-	postHandler := handlers.NewPostHandler()
-	commentHandler := handlers.NewCommentHandler()
+func SetupRoutes(r *gin.Engine, a *app.App) {
+	cfg := a.Config
+	svc := a.Services
 
+	userHandler := handlers.NewUserHandler(svc.Users, a.Metrics)
+	postHandler := handlers.NewPostHandler(svc.Posts, a.Views, a.Metrics)
+	commentHandler := handlers.NewCommentHandler(svc.Comments, a.Metrics)
+	moderationHandler := handlers.NewModerationHandler(svc.Moderation)
+	notificationHandler := handlers.NewNotificationHandler(svc.Notifications)
+	reactionHandler := handlers.NewReactionHandler(svc.Reactions)
+	bookmarkHandler := handlers.NewBookmarkHandler(svc.Bookmarks)
+	analyticsHandler := handlers.NewAnalyticsHandler(svc.Analytics)
+	feedHandler := handlers.NewFeedHandler(svc.Feeds)
+	sitemapHandler := handlers.NewSitemapHandler(svc.Sitemap, cfg.Robots)
+	uploadHandler := handlers.NewUploadHandler(svc.Uploads)
+
+	r.Use(middleware.RequestID())
+	r.Use(middleware.Tracing())
+	r.Use(middleware.Metrics(a.Metrics))
+	r.Use(middleware.AccessLog(a.Logger))
+	r.Use(middleware.Recovery(a.Logger))
 	r.Use(middleware.CORSMiddleware())
-	r.Use(gin.Logger())
-	r.Use(gin.Recovery())
+	r.Use(middleware.Timeout(cfg.Server.RequestTimeout,
+		"/api/v1/posts/:id/comments/stream",
+		"/api/v1/posts/:id/comments/ws",
+	))
+
+	// 读请求走只读副本，刚写入过的客户端固定读主库，没有配置 DB_REPLICAS 时不起作用，也不设置 cookie
+	pinWindow := cfg.Database.ReplicaPinWindow
+	if database.ReplicasOf(a.DB) == nil {
+		pinWindow = 0
+	}
+	readReplica := middleware.ReadReplica(pinWindow, cfg.JWT.Secret)
 
 	r.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "ok"})
+		body := gin.H{"status": "ok"}
+		if replicas := database.ReplicasOf(a.DB); replicas != nil {
+			body["replicas"] = replicas.Status()
+		}
+		c.JSON(200, body)
 	})
 
+	// 设置了 METRICS_PORT 时 /metrics 由 SetupMetrics 在内部端口上提供
+	if a.Metrics != nil && cfg.Metrics.Port == "" {
+		SetupMetrics(r, a)
+	}
+
+	site := r.Group("", readReplica)
+	for ext, format := range map[string]string{"rss": feed.FormatRSS, "atom": feed.FormatAtom, "json": feed.FormatJSON} {
+		site.GET("/feed."+ext, feedHandler.Serve(format))
+		site.GET("/authors/:username/feed."+ext, feedHandler.Serve(format))
+		site.GET("/tags/:slug/feed."+ext, feedHandler.Serve(format))
+	}
+
+	site.GET("/sitemap.xml", sitemapHandler.GetSitemap)
+	site.GET("/sitemaps/:name", sitemapHandler.GetSitemapFile)
+	site.GET("/robots.txt", sitemapHandler.GetRobots)
+
+	// 本地存储的文件由本服务提供，nosniff 防止浏览器把附件当作其他类型执行
+	if cfg.Upload.Driver == "local" {
+		uploads := r.Group("/uploads")
+		uploads.Use(func(c *gin.Context) {
+			c.Header("X-Content-Type-Options", "nosniff")
+		})
+		uploads.Static("", cfg.Upload.LocalDir)
+	}
+
 	v1 := r.Group("/api/v1")
 	{
-		auth := v1.Group("/auth")
+		auth := v1.Group("/auth", readReplica)
 		{
 			auth.POST("/register", userHandler.Register)
 			auth.POST("/login", userHandler.Login)
 		}
 
 		authenticated := v1.Group("")
-		authenticated.Use(middleware.AuthMiddleware(jwtSecret))
+		authenticated.Use(middleware.AuthMiddleware(a.Tokens), readReplica)
 		{
 			authenticated.GET("/profile", userHandler.GetProfile)
+			authenticated.GET("/profile/analytics", analyticsHandler.GetAnalytics)
+			authenticated.GET("/profile/bookmarks", bookmarkHandler.GetBookmarks)
+			authenticated.POST("/profile/bookmarks", bookmarkHandler.AddBookmark)
+			authenticated.DELETE("/profile/bookmarks/:id", bookmarkHandler.RemoveBookmark)
+			authenticated.GET("/profile/reading-lists", bookmarkHandler.GetMyReadingLists)
+			authenticated.POST("/profile/reading-lists", bookmarkHandler.CreateReadingList)
+			authenticated.PUT("/profile/reading-lists/:id", bookmarkHandler.UpdateReadingList)
+			authenticated.DELETE("/profile/reading-lists/:id", bookmarkHandler.DeleteReadingList)
+			authenticated.PUT("/profile/reading-lists/:id/order", bookmarkHandler.ReorderBookmarks)
+			authenticated.POST("/uploads", uploadHandler.Upload)
+			authenticated.DELETE("/uploads/:id", uploadHandler.DeleteAttachment)
+			authenticated.POST("/users/:id/follow", userHandler.Follow)
+			authenticated.DELETE("/users/:id/follow", userHandler.Unfollow)
 
 			posts := authenticated.Group("/posts")
 			{
 				posts.POST("", postHandler.CreatePost)
 				posts.PUT("/:id", postHandler.UpdatePost)
 				posts.DELETE("/:id", postHandler.DeletePost)
+				posts.POST("/:id/reactions", reactionHandler.TogglePostReaction)
 			}
 
 			comments := authenticated.Group("/comments")
 			{
 				comments.POST("", commentHandler.CreateComment)
+				comments.PUT("/:id", commentHandler.UpdateComment)
 				comments.DELETE("/:id", commentHandler.DeleteComment)
+				comments.GET("/:id/history", commentHandler.GetCommentHistory)
+				comments.POST("/:id/hide", commentHandler.HideComment)
+				comments.POST("/:id/unhide", commentHandler.UnhideComment)
+				comments.POST("/:id/reactions", reactionHandler.ToggleCommentReaction)
+			}
+
+			notifications := authenticated.Group("/notifications")
+			{
+				notifications.GET("", notificationHandler.GetNotifications)
+				notifications.GET("/unread-count", notificationHandler.GetUnreadCount)
+				notifications.POST("/:id/read", notificationHandler.MarkRead)
+				notifications.POST("/read-all", notificationHandler.MarkAllRead)
+				notifications.GET("/preferences", notificationHandler.GetPreferences)
+				notifications.PUT("/preferences", notificationHandler.UpdatePreferences)
+			}
+
+			moderation := authenticated.Group("/moderation")
+			{
+				moderation.GET("/comments", moderationHandler.GetQueue)
+				moderation.POST("/comments/:id/approve", moderationHandler.ApproveComment)
+				moderation.POST("/comments/:id/reject", moderationHandler.RejectComment)
+				moderation.POST("/comments/:id/ban", moderationHandler.BanCommenter)
 			}
 		}
 
 		public := v1.Group("")
-		public.Use(middleware.OptionalAuthMiddleware(jwtSecret))
+		public.Use(middleware.OptionalAuthMiddleware(a.Tokens), readReplica)
 		{
 			public.GET("/posts", postHandler.GetPosts)
+			public.GET("/posts/most-liked", reactionHandler.GetMostLiked)
 			public.GET("/posts/:id", postHandler.GetPost)
 
 			public.GET("/posts/:id/comments", commentHandler.GetCommentsByPostID)
+			public.GET("/users/:id/reading-lists", bookmarkHandler.GetUserReadingLists)
+			public.GET("/reading-lists/:id", bookmarkHandler.GetReadingList)
+			public.GET("/posts/:id/comments/stream", commentHandler.StreamComments)
+			public.GET("/posts/:id/comments/ws", commentHandler.CommentsWebSocket)
 		}
 	}
 
-	logger.Info("Routes setup completed")
+	a.Logger.Info("Routes setup completed")
+}
+
+// SetupMetrics 注册 /metrics，设置了 METRICS_TOKEN 时需要带上令牌才能访问
+func SetupMetrics(r *gin.Engine, a *app.App) {
+	r.GET("/metrics", middleware.MetricsToken(a.Config.Metrics.Token), gin.WrapH(a.Metrics.Handler()))
 }
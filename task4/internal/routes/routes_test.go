@@ -0,0 +1,156 @@
+package routes_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"blog-system/internal/app/apptest"
+)
+
+// decode 检查状态码并把响应中的 data 解析到 out
+func decode(t *testing.T, w *httptest.ResponseRecorder, status int, out any) {
+	t.Helper()
+	if w.Code != status {
+		t.Fatalf("status = %d, want %d: %s", w.Code, status, w.Body.String())
+	}
+	if out == nil {
+		return
+	}
+	var resp struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if err := json.Unmarshal(resp.Data, out); err != nil {
+		t.Fatalf("decode data: %v: %s", err, resp.Data)
+	}
+}
+
+func registerAndLogin(t *testing.T, env *apptest.Env, username string) string {
+	t.Helper()
+	decode(t, env.Do(http.MethodPost, "/api/v1/auth/register", map[string]string{
+		"username": username, "password": "secret123", "email": username + "@example.com",
+	}, ""), http.StatusOK, nil)
+
+	var login struct {
+		Token string `json:"token"`
+	}
+	decode(t, env.Do(http.MethodPost, "/api/v1/auth/login", map[string]string{
+		"username": username, "password": "secret123",
+	}, ""), http.StatusOK, &login)
+	if login.Token == "" {
+		t.Fatal("login returned no token")
+	}
+	return login.Token
+}
+
+type post struct {
+	ID           uint   `json:"id"`
+	Title        string `json:"title"`
+	CommentCount int64  `json:"comment_count"`
+}
+
+type comment struct {
+	ID      uint   `json:"id"`
+	Content string `json:"content"`
+	Status  string `json:"status"`
+}
+
+func createPost(t *testing.T, env *apptest.Env, token, title string) post {
+	t.Helper()
+	var p post
+	decode(t, env.Do(http.MethodPost, "/api/v1/posts", map[string]string{
+		"title": title, "content": "content of " + title,
+	}, token), http.StatusOK, &p)
+	return p
+}
+
+func TestPostCommentLifecycle(t *testing.T) {
+	env := apptest.New(t)
+	alice := registerAndLogin(t, env, "alice")
+	bob := registerAndLogin(t, env, "bob")
+
+	hello := createPost(t, env, alice, "Hello SQLite World")
+
+	var own comment
+	decode(t, env.Do(http.MethodPost, "/api/v1/comments", map[string]any{
+		"post_id": hello.ID, "content": "thanks for reading",
+	}, alice), http.StatusOK, &own)
+	var reply comment
+	decode(t, env.Do(http.MethodPost, "/api/v1/comments", map[string]any{
+		"post_id": hello.ID, "content": "nice post",
+	}, bob), http.StatusOK, &reply)
+
+	// 第一次评论的用户需要审核，只有通过审核的评论计入评论数
+	if own.Status != "approved" || reply.Status != "pending" {
+		t.Errorf("statuses = %s, %s; want approved, pending", own.Status, reply.Status)
+	}
+	var detail post
+	decode(t, env.Do(http.MethodGet, fmt.Sprintf("/api/v1/posts/%d", hello.ID), nil, ""), http.StatusOK, &detail)
+	if detail.CommentCount != 1 {
+		t.Errorf("comment_count = %d, want 1", detail.CommentCount)
+	}
+
+	// 不是作者不能删除文章和别人的评论
+	decode(t, env.Do(http.MethodDelete, fmt.Sprintf("/api/v1/posts/%d", hello.ID), nil, bob), http.StatusBadRequest, nil)
+	decode(t, env.Do(http.MethodDelete, fmt.Sprintf("/api/v1/comments/%d", own.ID), nil, bob), http.StatusBadRequest, nil)
+
+	decode(t, env.Do(http.MethodDelete, fmt.Sprintf("/api/v1/comments/%d", reply.ID), nil, bob), http.StatusOK, nil)
+	decode(t, env.Do(http.MethodDelete, fmt.Sprintf("/api/v1/posts/%d", hello.ID), nil, alice), http.StatusOK, nil)
+
+	decode(t, env.Do(http.MethodGet, fmt.Sprintf("/api/v1/posts/%d", hello.ID), nil, ""), http.StatusNotFound, nil)
+	decode(t, env.Do(http.MethodDelete, fmt.Sprintf("/api/v1/comments/%d", own.ID), nil, alice), http.StatusBadRequest, nil)
+}
+
+func searchTitles(t *testing.T, env *apptest.Env, title string) []string {
+	t.Helper()
+	var list struct {
+		Posts []post `json:"posts"`
+	}
+	decode(t, env.Do(http.MethodGet, "/api/v1/posts?title="+url.QueryEscape(title), nil, ""), http.StatusOK, &list)
+	titles := make([]string, 0, len(list.Posts))
+	for _, p := range list.Posts {
+		titles = append(titles, p.Title)
+	}
+	return titles
+}
+
+// 标题搜索在 PostgreSQL 上使用 ILIKE，其他数据库使用 LOWER 比较，默认的 SQLite 测试数据库覆盖后一种
+func TestPostTitleSearch(t *testing.T) {
+	env := apptest.New(t)
+	alice := registerAndLogin(t, env, "alice")
+
+	hello := createPost(t, env, alice, "Hello SQLite World")
+	createPost(t, env, alice, "Discount 100%_off")
+	createPost(t, env, alice, "Discount 100 percent off")
+	createPost(t, env, alice, "Bang! Bang!")
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"sqlite", "[Hello SQLite World]"},
+		{"HELLO sqlite", "[Hello SQLite World]"},
+		// % 和 _ 按字面匹配，不是通配符
+		{"100%_", "[Discount 100%_off]"},
+		{"100_", "[]"},
+		// ! 是 ESCAPE 使用的转义字符，本身也要转义
+		{"g! b", "[Bang! Bang!]"},
+		{"no such post", "[]"},
+	}
+	for _, tt := range tests {
+		if got := fmt.Sprint(searchTitles(t, env, tt.query)); got != tt.want {
+			t.Errorf("search %q = %s, want %s", tt.query, got, tt.want)
+		}
+	}
+
+	decode(t, env.Do(http.MethodDelete, fmt.Sprintf("/api/v1/posts/%d", hello.ID), nil, alice), http.StatusOK, nil)
+	if got := searchTitles(t, env, "sqlite"); len(got) != 0 {
+		t.Errorf("deleted post still listed: %v", got)
+	}
+}
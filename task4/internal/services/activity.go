@@ -0,0 +1,111 @@
+package services
+
+import (
+	"blog-system/internal/models"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// Activity 为文章和评论附加表情回应、收藏数并发送通知，这些数据不在
+// 文章和评论的仓储中。不依赖数据库的单元测试可以使用 NopActivity
+type Activity interface {
+	AttachPostReactions(ctx context.Context, posts []models.Post, viewerID uint) error
+	AttachPostDetailReactions(ctx context.Context, post *models.Post, viewerID uint) error
+	AttachBookmarkCounts(ctx context.Context, posts []models.Post, viewerID uint) error
+	AttachBookmarkCount(ctx context.Context, post *models.Post, viewerID uint) error
+	AttachCommentReactions(ctx context.Context, comments []models.Comment, viewerID uint) error
+
+	NotifyPostMentions(ctx context.Context, post *models.Post, previous string)
+	NotifyCommentPublished(ctx context.Context, comment *models.Comment)
+	NotifyCommentMentions(ctx context.Context, comment *models.Comment, previous string)
+}
+
+type dbActivity struct {
+	db *gorm.DB
+}
+
+func NewActivity(db *gorm.DB) Activity {
+	return dbActivity{db: db}
+}
+
+func (a dbActivity) AttachPostReactions(ctx context.Context, posts []models.Post, viewerID uint) error {
+	return attachPostReactions(a.db.WithContext(ctx), posts, viewerID)
+}
+
+func (a dbActivity) AttachPostDetailReactions(ctx context.Context, post *models.Post, viewerID uint) error {
+	return attachPostDetailReactions(a.db.WithContext(ctx), post, viewerID)
+}
+
+func (a dbActivity) AttachBookmarkCounts(ctx context.Context, posts []models.Post, viewerID uint) error {
+	return attachBookmarkCounts(a.db.WithContext(ctx), posts, viewerID)
+}
+
+func (a dbActivity) AttachBookmarkCount(ctx context.Context, post *models.Post, viewerID uint) error {
+	return attachBookmarkCount(a.db.WithContext(ctx), post, viewerID)
+}
+
+func (a dbActivity) AttachCommentReactions(ctx context.Context, comments []models.Comment, viewerID uint) error {
+	return attachCommentReactions(a.db.WithContext(ctx), comments, viewerID)
+}
+
+// 通知在文章或评论保存之后发送，客户端断开时也要完成，所以不随请求取消
+func (a dbActivity) NotifyPostMentions(ctx context.Context, post *models.Post, previous string) {
+	notifyPostMentions(a.db.WithContext(context.WithoutCancel(ctx)), post, previous)
+}
+
+func (a dbActivity) NotifyCommentPublished(ctx context.Context, comment *models.Comment) {
+	notifyCommentPublished(a.db.WithContext(context.WithoutCancel(ctx)), comment)
+}
+
+func (a dbActivity) NotifyCommentMentions(ctx context.Context, comment *models.Comment, previous string) {
+	notifyCommentMentions(a.db.WithContext(context.WithoutCancel(ctx)), comment, previous)
+}
+
+// NopActivity 不查询也不发送通知，表情统计为空，作者看到的收藏数为0
+type NopActivity struct{}
+
+func (NopActivity) AttachPostReactions(ctx context.Context, posts []models.Post, viewerID uint) error {
+	for i := range posts {
+		posts[i].Reactions = []models.ReactionSummary{}
+	}
+	return nil
+}
+
+func (NopActivity) AttachPostDetailReactions(ctx context.Context, post *models.Post, viewerID uint) error {
+	post.Reactions = []models.ReactionSummary{}
+	for i := range post.Comments {
+		post.Comments[i].Reactions = []models.ReactionSummary{}
+	}
+	return nil
+}
+
+func (NopActivity) AttachBookmarkCounts(ctx context.Context, posts []models.Post, viewerID uint) error {
+	for i := range posts {
+		if viewerID != 0 && posts[i].UserID == viewerID {
+			posts[i].BookmarkCount = new(int64)
+		}
+	}
+	return nil
+}
+
+func (NopActivity) AttachBookmarkCount(ctx context.Context, post *models.Post, viewerID uint) error {
+	if viewerID != 0 && post.UserID == viewerID {
+		post.BookmarkCount = new(int64)
+	}
+	return nil
+}
+
+func (NopActivity) AttachCommentReactions(ctx context.Context, comments []models.Comment, viewerID uint) error {
+	for i := range comments {
+		comments[i].Reactions = []models.ReactionSummary{}
+	}
+	return nil
+}
+
+func (NopActivity) NotifyPostMentions(ctx context.Context, post *models.Post, previous string) {}
+
+func (NopActivity) NotifyCommentPublished(ctx context.Context, comment *models.Comment) {}
+
+func (NopActivity) NotifyCommentMentions(ctx context.Context, comment *models.Comment, previous string) {
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"blog-system/config"
+	"blog-system/internal/analytics"
+	"blog-system/internal/models"
+	"blog-system/pkg/clock"
+	"blog-system/pkg/logger"
+	"blog-system/pkg/tracing"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	DefaultAnalyticsDays = 30
+	MaxAnalyticsDays     = 365
+
+	analyticsTopReferrers = 20
+	analyticsTopPosts     = 10
+)
+
+// viewStore 把一个批次的浏览量累加到统计表和 posts.view_count
+type viewStore struct {
+	db *gorm.DB
+}
+
+func (v viewStore) SaveViews(counts []analytics.ViewCount) error {
+	daily := make(map[models.PostDailyView]int64)
+	perPost := make(map[uint]int64)
+	referrers := make([]models.PostReferrerView, 0, len(counts))
+
+	for _, c := range counts {
+		daily[models.PostDailyView{PostID: c.PostID, Day: c.Day}] += c.Views
+		perPost[c.PostID] += c.Views
+		referrers = append(referrers, models.PostReferrerView{
+			PostID:   c.PostID,
+			Day:      c.Day,
+			Referrer: c.Referrer,
+			Views:    c.Views,
+		})
+	}
+
+	return v.db.Transaction(func(tx *gorm.DB) error {
+		for key, views := range daily {
+			row := key
+			row.Views = views
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "post_id"}, {Name: "day"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{"views": gorm.Expr("post_daily_views.views + ?", views)}),
+			}).Create(&row).Error; err != nil {
+				return err
+			}
+		}
+
+		for i := range referrers {
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "post_id"}, {Name: "day"}, {Name: "referrer"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{"views": gorm.Expr("post_referrer_views.views + ?", referrers[i].Views)}),
+			}).Create(&referrers[i]).Error; err != nil {
+				return err
+			}
+		}
+
+		for postID, views := range perPost {
+			if err := tx.Model(&models.Post{}).Where("id = ?", postID).
+				UpdateColumn("view_count", gorm.Expr("view_count + ?", views)).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// NewViewRecorder 创建写入数据库的浏览量记录器，调用方负责在退出前 Close
+func NewViewRecorder(db *gorm.DB, cfg config.AnalyticsConfig) *analytics.Recorder {
+	return analytics.NewRecorder(analytics.Config{
+		DedupeWindow:  cfg.DedupeWindow,
+		FlushInterval: cfg.FlushInterval,
+		MaxBuffer:     cfg.MaxBuffer,
+	}, viewStore{db: db})
+}
+
+type AnalyticsService struct {
+	db    *gorm.DB
+	clock clock.Clock
+}
+
+func NewAnalyticsService(db *gorm.DB, clk clock.Clock) *AnalyticsService {
+	return &AnalyticsService{db: db, clock: clk}
+}
+
+// GetAnalytics 统计作者最近 days 天的浏览量，postID 不为0时只统计该文章
+func (s *AnalyticsService) GetAnalytics(ctx context.Context, userID, postID uint, days int) (*models.AnalyticsResponse, error) {
+	ctx, span := tracing.Start(ctx, "AnalyticsService.GetAnalytics")
+	defer span.End()
+
+	db := s.db.WithContext(ctx)
+	if days <= 0 {
+		days = DefaultAnalyticsDays
+	}
+	if days > MaxAnalyticsDays {
+		days = MaxAnalyticsDays
+	}
+
+	now := s.clock.Now().UTC()
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	from := to.AddDate(0, 0, -(days - 1))
+
+	response := &models.AnalyticsResponse{
+		From:      from.Format(time.DateOnly),
+		To:        to.Format(time.DateOnly),
+		Daily:     make([]models.DailyViews, 0, days),
+		Referrers: []models.ReferrerViews{},
+		TopPosts:  []models.TopPost{},
+	}
+
+	postScope := db.Model(&models.Post{}).Select("id").Where("user_id = ?", userID)
+	if postID != 0 {
+		var post models.Post
+		if err := db.Where("user_id = ?", userID).First(&post, postID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, errors.New("文章不存在")
+			}
+			logger.ErrorContext(ctx, "Database error", "error", err)
+			return nil, errors.New("获取文章失败")
+		}
+		postScope = db.Model(&models.Post{}).Select("id").Where("id = ?", post.ID)
+		response.PostID = &post.ID
+	}
+
+	var daily []struct {
+		Day   time.Time
+		Views int64
+	}
+	if err := db.Model(&models.PostDailyView{}).
+		Select("day, SUM(views) AS views").
+		Where("post_id IN (?) AND day >= ?", postScope, from).
+		Group("day").
+		Scan(&daily).Error; err != nil {
+		logger.ErrorContext(ctx, "Failed to get daily views", "error", err)
+		return nil, errors.New("获取浏览统计失败")
+	}
+
+	byDay := make(map[string]int64, len(daily))
+	for _, d := range daily {
+		byDay[d.Day.UTC().Format(time.DateOnly)] += d.Views
+		response.TotalViews += d.Views
+	}
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		date := day.Format(time.DateOnly)
+		response.Daily = append(response.Daily, models.DailyViews{Date: date, Views: byDay[date]})
+	}
+
+	if err := db.Model(&models.PostReferrerView{}).
+		Select("referrer, SUM(views) AS views").
+		Where("post_id IN (?) AND day >= ?", postScope, from).
+		Group("referrer").
+		Order("views DESC").
+		Limit(analyticsTopReferrers).
+		Scan(&response.Referrers).Error; err != nil {
+		logger.ErrorContext(ctx, "Failed to get referrers", "error", err)
+		return nil, errors.New("获取来源统计失败")
+	}
+
+	if err := db.Model(&models.PostDailyView{}).
+		Select("post_daily_views.post_id, posts.title, SUM(post_daily_views.views) AS views").
+		Joins("JOIN posts ON posts.id = post_daily_views.post_id").
+		Where("post_daily_views.post_id IN (?) AND post_daily_views.day >= ?", postScope, from).
+		Group("post_daily_views.post_id, posts.title").
+		Order("views DESC").
+		Limit(analyticsTopPosts).
+		Scan(&response.TopPosts).Error; err != nil {
+		logger.ErrorContext(ctx, "Failed to get top posts", "error", err)
+		return nil, errors.New("获取热门文章失败")
+	}
+
+	return response, nil
+}
@@ -0,0 +1,504 @@
+package services
+
+import (
+	"blog-system/internal/models"
+	"blog-system/pkg/logger"
+	"blog-system/pkg/tracing"
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var errAlreadyBookmarked = errors.New("文章已在该书单中")
+
+type BookmarkService struct {
+	db *gorm.DB
+}
+
+func NewBookmarkService(db *gorm.DB) *BookmarkService {
+	return &BookmarkService{db: db}
+}
+
+// bookmarkListSpec 用于全部收藏，按收藏时间排序
+var bookmarkListSpec = &listSpec{
+	idColumn: "bookmarks.id",
+	fields: map[string]sortField{
+		"created_at": {expr: "bookmarks.created_at", kind: cursorTime},
+		"position":   {expr: "bookmarks.position", kind: cursorInt},
+	},
+	defaultSort:  "created_at",
+	defaultOrder: "desc",
+}
+
+// readingListSpec 用于单个书单，默认按用户调整后的顺序排列
+var readingListSpec = &listSpec{
+	idColumn:     bookmarkListSpec.idColumn,
+	fields:       bookmarkListSpec.fields,
+	defaultSort:  "position",
+	defaultOrder: "asc",
+}
+
+// loadBookmarkCounts 统计文章被多少位用户收藏
+func loadBookmarkCounts(db *gorm.DB, postIDs []uint) (map[uint]int64, error) {
+	counts := make(map[uint]int64, len(postIDs))
+	if len(postIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		PostID uint
+		Count  int64
+	}
+	if err := db.Model(&models.Bookmark{}).
+		Select("post_id, COUNT(DISTINCT user_id) AS count").
+		Where("post_id IN ?", postIDs).
+		Group("post_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.PostID] = row.Count
+	}
+	return counts, nil
+}
+
+// attachBookmarkCounts 收藏数只对文章作者可见
+func attachBookmarkCounts(db *gorm.DB, posts []models.Post, viewerID uint) error {
+	if viewerID == 0 {
+		return nil
+	}
+
+	var ids []uint
+	for i := range posts {
+		if posts[i].UserID == viewerID {
+			ids = append(ids, posts[i].ID)
+		}
+	}
+
+	counts, err := loadBookmarkCounts(db, ids)
+	if err != nil {
+		return err
+	}
+	for i := range posts {
+		if posts[i].UserID == viewerID {
+			count := counts[posts[i].ID]
+			posts[i].BookmarkCount = &count
+		}
+	}
+	return nil
+}
+
+func attachBookmarkCount(db *gorm.DB, post *models.Post, viewerID uint) error {
+	if viewerID == 0 || post.UserID != viewerID {
+		return nil
+	}
+
+	counts, err := loadBookmarkCounts(db, []uint{post.ID})
+	if err != nil {
+		return err
+	}
+	count := counts[post.ID]
+	post.BookmarkCount = &count
+	return nil
+}
+
+func loadOwnReadingList(db *gorm.DB, listID, userID uint) (*models.ReadingList, error) {
+	var list models.ReadingList
+	if err := db.Where("user_id = ?", userID).First(&list, listID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("书单不存在")
+		}
+		logger.ErrorContext(db.Statement.Context, "Database error", "error", err)
+		return nil, errors.New("获取书单失败")
+	}
+	return &list, nil
+}
+
+func readingListResponses(db *gorm.DB, lists []models.ReadingList) ([]models.ReadingListResponse, error) {
+	ids := make([]uint, len(lists))
+	for i := range lists {
+		ids[i] = lists[i].ID
+	}
+
+	var rows []struct {
+		ListID uint
+		Count  int64
+	}
+	if len(ids) > 0 {
+		if err := db.Model(&models.Bookmark{}).
+			Select("list_id, COUNT(*) AS count").
+			Where("list_id IN ?", ids).
+			Group("list_id").
+			Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	counts := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		counts[row.ListID] = row.Count
+	}
+
+	responses := make([]models.ReadingListResponse, len(lists))
+	for i := range lists {
+		responses[i] = lists[i].ToResponse(counts[lists[i].ID])
+	}
+	return responses, nil
+}
+
+// GetReadingLists 返回用户的书单，查看他人时只包含公开书单
+func (s *BookmarkService) GetReadingLists(ctx context.Context, ownerID, viewerID uint) ([]models.ReadingListResponse, error) {
+	ctx, span := tracing.Start(ctx, "BookmarkService.GetReadingLists")
+	defer span.End()
+
+	db := s.db.WithContext(ctx)
+	query := db.Where("user_id = ?", ownerID)
+	if ownerID != viewerID {
+		query = query.Where("is_public = ?", true)
+	}
+
+	var lists []models.ReadingList
+	if err := query.Order("created_at ASC").Find(&lists).Error; err != nil {
+		logger.ErrorContext(ctx, "Failed to get reading lists", "error", err)
+		return nil, errors.New("获取书单失败")
+	}
+
+	responses, err := readingListResponses(db, lists)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to count bookmarks", "error", err)
+		return nil, errors.New("获取书单失败")
+	}
+	return responses, nil
+}
+
+func (s *BookmarkService) CreateReadingList(ctx context.Context, userID uint, req *models.ReadingListRequest) (*models.ReadingListResponse, error) {
+	ctx, span := tracing.Start(ctx, "BookmarkService.CreateReadingList")
+	defer span.End()
+
+	list := &models.ReadingList{
+		UserID:   userID,
+		Name:     req.Name,
+		IsPublic: req.IsPublic,
+	}
+
+	result := s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(list)
+	if result.Error != nil {
+		logger.ErrorContext(ctx, "Failed to create reading list", "error", result.Error)
+		return nil, errors.New("书单创建失败")
+	}
+	if result.RowsAffected == 0 {
+		return nil, errors.New("书单名称已存在")
+	}
+
+	response := list.ToResponse(0)
+	return &response, nil
+}
+
+func (s *BookmarkService) UpdateReadingList(ctx context.Context, listID, userID uint, req *models.ReadingListRequest) (*models.ReadingListResponse, error) {
+	ctx, span := tracing.Start(ctx, "BookmarkService.UpdateReadingList")
+	defer span.End()
+
+	db := s.db.WithContext(ctx)
+	list, err := loadOwnReadingList(db, listID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != list.Name {
+		var count int64
+		if err := db.Model(&models.ReadingList{}).
+			Where("user_id = ? AND name = ? AND id <> ?", userID, req.Name, listID).
+			Count(&count).Error; err != nil {
+			logger.ErrorContext(ctx, "Database error", "error", err)
+			return nil, errors.New("书单更新失败")
+		}
+		if count > 0 {
+			return nil, errors.New("书单名称已存在")
+		}
+	}
+
+	if err := db.Model(list).Updates(map[string]interface{}{
+		"name":      req.Name,
+		"is_public": req.IsPublic,
+	}).Error; err != nil {
+		logger.ErrorContext(ctx, "Failed to update reading list", "error", err)
+		return nil, errors.New("书单更新失败")
+	}
+
+	responses, err := readingListResponses(db, []models.ReadingList{*list})
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to count bookmarks", "error", err)
+		return nil, errors.New("获取书单失败")
+	}
+	return &responses[0], nil
+}
+
+// DeleteReadingList 同时删除书单中的收藏
+func (s *BookmarkService) DeleteReadingList(ctx context.Context, listID, userID uint) error {
+	ctx, span := tracing.Start(ctx, "BookmarkService.DeleteReadingList")
+	defer span.End()
+
+	db := s.db.WithContext(ctx)
+	list, err := loadOwnReadingList(db, listID, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("list_id = ?", list.ID).Delete(&models.Bookmark{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(list).Error
+	}); err != nil {
+		logger.ErrorContext(ctx, "Failed to delete reading list", "error", err)
+		return errors.New("书单删除失败")
+	}
+
+	return nil
+}
+
+func (s *BookmarkService) AddBookmark(ctx context.Context, userID uint, req *models.BookmarkCreateRequest) (*models.BookmarkResponse, error) {
+	ctx, span := tracing.Start(ctx, "BookmarkService.AddBookmark")
+	defer span.End()
+
+	db := s.db.WithContext(ctx)
+	var post models.Post
+	if err := db.Preload("User").Preload("Tags").First(&post, req.PostID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("文章不存在")
+		}
+		logger.ErrorContext(ctx, "Database error", "error", err)
+		return nil, errors.New("获取文章失败")
+	}
+	if post.Status != models.PostStatusPublished && post.UserID != userID {
+		return nil, errors.New("文章不存在")
+	}
+
+	var list *models.ReadingList
+	if req.ListID != nil {
+		var err error
+		if list, err = loadOwnReadingList(db, *req.ListID, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	bookmark := &models.Bookmark{
+		UserID: userID,
+		PostID: post.ID,
+	}
+
+	// 默认书单和收藏都在事务中用 ON CONFLICT 插入，并发收藏时由唯一索引判断是否重复
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if list == nil {
+			var err error
+			if list, err = defaultReadingList(tx, userID); err != nil {
+				return err
+			}
+		}
+		bookmark.ListID = list.ID
+
+		var last struct{ Position *int64 }
+		if err := tx.Model(&models.Bookmark{}).Select("MAX(position) AS position").
+			Where("list_id = ?", list.ID).Scan(&last).Error; err != nil {
+			return err
+		}
+		if last.Position != nil {
+			bookmark.Position = *last.Position + 1
+		}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(bookmark)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errAlreadyBookmarked
+		}
+		return nil
+	}); err != nil {
+		if errors.Is(err, errAlreadyBookmarked) {
+			return nil, err
+		}
+		logger.ErrorContext(ctx, "Failed to create bookmark", "error", err)
+		return nil, errors.New("收藏失败")
+	}
+
+	bookmark.Post = post
+	response := bookmark.ToResponse(userID)
+	return &response, nil
+}
+
+// defaultReadingList 返回用户的默认书单，不存在时创建，并发创建时读取已经插入的那一行
+func defaultReadingList(tx *gorm.DB, userID uint) (*models.ReadingList, error) {
+	list := &models.ReadingList{UserID: userID, Name: models.DefaultReadingListName}
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(list)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return list, nil
+	}
+
+	list = &models.ReadingList{}
+	if err := tx.Where("user_id = ? AND name = ?", userID, models.DefaultReadingListName).First(list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (s *BookmarkService) RemoveBookmark(ctx context.Context, bookmarkID, userID uint) error {
+	ctx, span := tracing.Start(ctx, "BookmarkService.RemoveBookmark")
+	defer span.End()
+
+	result := s.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.Bookmark{}, bookmarkID)
+	if result.Error != nil {
+		logger.ErrorContext(ctx, "Failed to delete bookmark", "error", result.Error)
+		return errors.New("取消收藏失败")
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("收藏不存在")
+	}
+	return nil
+}
+
+// ReorderBookmarks 按传入的顺序重新排列书单，必须包含书单中的全部收藏
+func (s *BookmarkService) ReorderBookmarks(ctx context.Context, listID, userID uint, bookmarkIDs []uint) error {
+	ctx, span := tracing.Start(ctx, "BookmarkService.ReorderBookmarks")
+	defer span.End()
+
+	db := s.db.WithContext(ctx)
+	if _, err := loadOwnReadingList(db, listID, userID); err != nil {
+		return err
+	}
+
+	var current []uint
+	if err := db.Model(&models.Bookmark{}).Where("list_id = ?", listID).
+		Pluck("id", &current).Error; err != nil {
+		logger.ErrorContext(ctx, "Database error", "error", err)
+		return errors.New("获取收藏失败")
+	}
+
+	inList := make(map[uint]bool, len(current))
+	for _, id := range current {
+		inList[id] = true
+	}
+	if len(bookmarkIDs) != len(current) {
+		return fmt.Errorf("需要提供书单中全部 %d 条收藏的顺序", len(current))
+	}
+	for _, id := range bookmarkIDs {
+		if !inList[id] {
+			return fmt.Errorf("收藏 %d 不在该书单中或重复出现", id)
+		}
+		delete(inList, id)
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		for position, id := range bookmarkIDs {
+			if err := tx.Model(&models.Bookmark{}).Where("id = ?", id).
+				UpdateColumn("position", position).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		logger.ErrorContext(ctx, "Failed to reorder bookmarks", "error", err)
+		return errors.New("调整顺序失败")
+	}
+
+	return nil
+}
+
+func (s *BookmarkService) listBookmarks(spec *listSpec, query *gorm.DB, viewerID uint, q *PageQuery) ([]models.BookmarkResponse, *PageInfo, error) {
+	k, err := spec.resolve(q)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var bookmarks []models.Bookmark
+	if err := k.apply(query.Session(&gorm.Session{})).
+		Preload("Post", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("Post.User").
+		Preload("Post.Tags").
+		Find(&bookmarks).Error; err != nil {
+		logger.ErrorContext(query.Statement.Context, "Failed to get bookmarks", "error", err)
+		return nil, nil, errors.New("获取收藏失败")
+	}
+
+	bookmarks, info := paginate(k, bookmarks, func(b *models.Bookmark) (any, uint) {
+		if k.sort == "position" {
+			return b.Position, b.ID
+		}
+		return b.CreatedAt, b.ID
+	})
+
+	if q.WithTotal {
+		var total int64
+		if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			logger.ErrorContext(query.Statement.Context, "Failed to count bookmarks", "error", err)
+			return nil, nil, errors.New("获取收藏总数失败")
+		}
+		info.Total = &total
+	}
+
+	responses := make([]models.BookmarkResponse, len(bookmarks))
+	for i := range bookmarks {
+		responses[i] = bookmarks[i].ToResponse(viewerID)
+	}
+	return responses, info, nil
+}
+
+// GetBookmarks 返回当前用户的收藏，listID 为0时包含全部书单
+func (s *BookmarkService) GetBookmarks(ctx context.Context, userID, listID uint, q *PageQuery) ([]models.BookmarkResponse, *PageInfo, error) {
+	ctx, span := tracing.Start(ctx, "BookmarkService.GetBookmarks")
+	defer span.End()
+
+	db := s.db.WithContext(ctx)
+	query := db.Model(&models.Bookmark{}).Where("bookmarks.user_id = ?", userID)
+	spec := bookmarkListSpec
+
+	if listID != 0 {
+		if _, err := loadOwnReadingList(db, listID, userID); err != nil {
+			return nil, nil, err
+		}
+		query = query.Where("bookmarks.list_id = ?", listID)
+		spec = readingListSpec
+	}
+
+	return s.listBookmarks(spec, query, userID, q)
+}
+
+// GetReadingListBookmarks 查看书单内容，私有书单只有创建者可以查看
+func (s *BookmarkService) GetReadingListBookmarks(ctx context.Context, listID, viewerID uint, q *PageQuery) (*models.ReadingListResponse, []models.BookmarkResponse, *PageInfo, error) {
+	ctx, span := tracing.Start(ctx, "BookmarkService.GetReadingListBookmarks")
+	defer span.End()
+
+	db := s.db.WithContext(ctx)
+	var list models.ReadingList
+	if err := db.First(&list, listID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, nil, errors.New("书单不存在")
+		}
+		logger.ErrorContext(ctx, "Database error", "error", err)
+		return nil, nil, nil, errors.New("获取书单失败")
+	}
+	if !list.IsPublic && list.UserID != viewerID {
+		return nil, nil, nil, errors.New("书单不存在")
+	}
+
+	lists, err := readingListResponses(db, []models.ReadingList{list})
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to count bookmarks", "error", err)
+		return nil, nil, nil, errors.New("获取书单失败")
+	}
+
+	query := db.Model(&models.Bookmark{}).Where("bookmarks.list_id = ?", list.ID)
+	bookmarks, info, err := s.listBookmarks(readingListSpec, query, viewerID, q)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return &lists[0], bookmarks, info, nil
+}
@@ -0,0 +1,62 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+
+	"blog-system/internal/app/apptest"
+	"blog-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// 模拟另一个请求在检查之后、插入之前先收藏了同一篇文章：
+// 第一次插入默认书单或收藏之前，用同一个连接插入冲突的行
+func TestAddBookmarkRacingWithAnotherRequest(t *testing.T) {
+	env := apptest.New(t)
+	ctx := context.Background()
+	svc := env.App.Services
+
+	user, err := svc.Users.Register(ctx, &models.UserCreateRequest{
+		Username: "alice", Password: "secret123", Email: "alice@example.com",
+	})
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	post, err := svc.Posts.CreatePost(ctx, user.ID, &models.PostCreateRequest{Title: "标题", Content: "内容"})
+	if err != nil {
+		t.Fatalf("create post: %v", err)
+	}
+
+	raced := map[string]bool{}
+	if err := env.App.DB.Callback().Create().Before("gorm:create").Register("test:race", func(db *gorm.DB) {
+		table := db.Statement.Table
+		if raced[table] {
+			return
+		}
+		raced[table] = true
+
+		conn := db.Session(&gorm.Session{NewDB: true})
+		switch table {
+		case "reading_lists":
+			db.AddError(conn.Exec(
+				"INSERT INTO reading_lists (user_id, name, is_public, created_at, updated_at) VALUES (?, ?, ?, ?, ?)",
+				user.ID, models.DefaultReadingListName, false, env.Clock.Now(), env.Clock.Now()).Error)
+		case "bookmarks":
+			db.AddError(conn.Exec(
+				"INSERT INTO bookmarks (user_id, list_id, post_id, position, created_at) "+
+					"SELECT ?, id, ?, 0, ? FROM reading_lists WHERE user_id = ? AND name = ?",
+				user.ID, post.ID, env.Clock.Now(), user.ID, models.DefaultReadingListName).Error)
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = svc.Bookmarks.AddBookmark(ctx, user.ID, &models.BookmarkCreateRequest{PostID: post.ID})
+	if err == nil || err.Error() != "文章已在该书单中" {
+		t.Errorf("AddBookmark error = %v, want 文章已在该书单中", err)
+	}
+	if !raced["reading_lists"] || !raced["bookmarks"] {
+		t.Errorf("conflicting rows inserted for %v, want reading_lists and bookmarks", raced)
+	}
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"blog-system/internal/events"
+	"blog-system/internal/models"
+	"blog-system/pkg/logger"
+	"context"
+)
+
+// publishCommentEvent 推送读者可见的评论变更，推送失败不影响评论操作本身
+func publishCommentEvent(broker events.Broker, eventType string, comment *models.Comment) {
+	event := events.Event{
+		Type:      eventType,
+		PostID:    comment.PostID,
+		CommentID: comment.ID,
+	}
+
+	// 保留为占位的评论需要客户端显示为 "[deleted]"，真正删除的评论只需要ID
+	if eventType != events.CommentDeleted || comment.IsDeleted {
+		response := comment.ToResponse()
+		event.Comment = &response
+	}
+
+	if err := broker.Publish(event); err != nil {
+		logger.Error("Failed to publish comment event", "error", err)
+	}
+}
+
+// SubscribeComments 订阅已发布文章的评论变更
+func (s *CommentService) SubscribeComments(ctx context.Context, postID uint) (<-chan events.Event, func(), error) {
+	if _, err := s.publishedPost(ctx, postID); err != nil {
+		return nil, nil, err
+	}
+
+	ch, cancel := s.broker.Subscribe(postID)
+	return ch, cancel, nil
+}
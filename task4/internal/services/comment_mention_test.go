@@ -0,0 +1,67 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+
+	"blog-system/internal/app/apptest"
+	"blog-system/internal/models"
+)
+
+func TestEditingCommentNotifiesNewMentions(t *testing.T) {
+	env := apptest.New(t)
+	ctx := context.Background()
+	svc := env.App.Services
+
+	register := func(username string) uint {
+		t.Helper()
+		user, err := svc.Users.Register(ctx, &models.UserCreateRequest{
+			Username: username, Password: "secret123", Email: username + "@example.com",
+		})
+		if err != nil {
+			t.Fatalf("register %s: %v", username, err)
+		}
+		return user.ID
+	}
+	alice, bob, carol, dave := register("alice"), register("bob"), register("carol"), register("dave")
+	if _, err := svc.Notifications.UpdatePreferences(ctx, dave, map[string]bool{models.NotificationTypeMention: false}); err != nil {
+		t.Fatalf("disable mentions: %v", err)
+	}
+
+	post, err := svc.Posts.CreatePost(ctx, alice, &models.PostCreateRequest{Title: "标题", Content: "内容"})
+	if err != nil {
+		t.Fatalf("create post: %v", err)
+	}
+	comment, err := svc.Comments.CreateComment(ctx, alice, &models.CommentCreateRequest{PostID: post.ID, Content: "hi @bob"})
+	if err != nil {
+		t.Fatalf("create comment: %v", err)
+	}
+
+	mentions := func(userID uint) int64 {
+		t.Helper()
+		var count int64
+		if err := env.App.DB.Model(&models.Notification{}).
+			Where("user_id = ? AND type = ? AND comment_id = ?", userID, models.NotificationTypeMention, comment.ID).
+			Count(&count).Error; err != nil {
+			t.Fatalf("count notifications: %v", err)
+		}
+		return count
+	}
+
+	if _, err := svc.Comments.UpdateComment(ctx, comment.ID, alice, &models.CommentUpdateRequest{
+		Content: "hi @bob, @carol and @dave",
+	}); err != nil {
+		t.Fatalf("update comment: %v", err)
+	}
+
+	// bob 在编辑之前已经被提及过，不重复通知；dave 关闭了提及通知
+	if got := mentions(bob); got != 1 {
+		t.Errorf("bob got %d mention notifications, want 1", got)
+	}
+	if got := mentions(carol); got != 1 {
+		t.Errorf("carol got %d mention notifications, want 1", got)
+	}
+	if got := mentions(dave); got != 0 {
+		t.Errorf("dave got %d mention notifications with mentions disabled", got)
+	}
+}
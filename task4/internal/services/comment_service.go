@@ -1,95 +1,549 @@
 package services
 
 import (
+	"blog-system/config"
+	"blog-system/internal/events"
 	"blog-system/internal/models"
-	"blog-system/pkg/database"
+	"blog-system/internal/moderation"
+	"blog-system/internal/repository"
+	"blog-system/pkg/clock"
 	"blog-system/pkg/logger"
+	"blog-system/pkg/tracing"
+	"context"
 	"errors"
+	"fmt"
+	"time"
+)
 
-	"gorm.io/gorm"
+const (
+	CommentViewFlat = "flat"
+	CommentViewTree = "tree"
 )
 
-type CommentService struct{}
+type CommentService struct {
+	users    repository.UserRepository
+	posts    repository.PostRepository
+	comments repository.CommentRepository
+	activity Activity
+	clock    clock.Clock
+
+	maxDepth   int
+	editWindow time.Duration
+
+	createPipeline *moderation.Pipeline
+	editPipeline   *moderation.Pipeline
 
-func NewCommentService() *CommentService {
-	return &CommentService{}
+	broker events.Broker
 }
 
-func (s *CommentService) CreateComment(userID uint, req *models.CommentCreateRequest) (*models.CommentResponse, error) {
-	var post models.Post
-	if err := database.DB.First(&post, req.PostID).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("文章不存在")
-		}
-		logger.Error("Database error:", err)
-		return nil, errors.New("获取文章失败")
+func NewCommentService(repos repository.Repositories, activity Activity, clk clock.Clock, cfg config.CommentConfig, broker events.Broker) *CommentService {
+	maxDepth := cfg.MaxDepth
+	if maxDepth < 0 {
+		maxDepth = 0
+	}
+	if maxDepth > models.CommentMaxDepthLimit {
+		maxDepth = models.CommentMaxDepthLimit
 	}
 
-	comment := &models.Comment{
-		Content: req.Content,
+	createPipeline, editPipeline := newModerationPipelines(repos.Comments, clk, cfg.Moderation)
+
+	return &CommentService{
+		users:          repos.Users,
+		posts:          repos.Posts,
+		comments:       repos.Comments,
+		activity:       activity,
+		clock:          clk,
+		maxDepth:       maxDepth,
+		editWindow:     cfg.EditWindow,
+		createPipeline: createPipeline,
+		editPipeline:   editPipeline,
+		broker:         broker,
+	}
+}
+
+// moderate 版主和文章作者的评论无需审核
+func (s *CommentService) moderate(ctx context.Context, pipeline *moderation.Pipeline, userID uint, post *models.Post, content string) (moderation.Result, error) {
+	if userID == post.UserID {
+		return moderation.Result{Verdict: moderation.Approve}, nil
+	}
+
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return moderation.Result{}, err
+	}
+	if user.IsModerator() {
+		return moderation.Result{Verdict: moderation.Approve}, nil
+	}
+
+	return pipeline.Run(ctx, &moderation.Input{
 		UserID:  userID,
-		PostID:  req.PostID,
+		PostID:  post.ID,
+		Content: content,
+	})
+}
+
+func (s *CommentService) CreateComment(ctx context.Context, userID uint, req *models.CommentCreateRequest) (*models.CommentResponse, error) {
+	ctx, span := tracing.Start(ctx, "CommentService.CreateComment")
+	defer span.End()
+
+	post, err := s.publishedPost(ctx, req.PostID)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := database.DB.Create(comment).Error; err != nil {
-		logger.Error("Failed to create comment:", err)
+	banned, err := s.comments.IsBanned(ctx, userID, post.UserID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to check comment ban", "error", err)
 		return nil, errors.New("评论创建失败")
 	}
+	if banned {
+		return nil, errors.New("你已被禁止在此发表评论")
+	}
+
+	if err := s.checkCommentPolicy(ctx, userID, post); err != nil {
+		return nil, err
+	}
+
+	comment := &models.Comment{
+		Content:  req.Content,
+		UserID:   userID,
+		PostID:   req.PostID,
+		ParentID: req.ParentID,
+	}
+
+	var parent *models.Comment
+	if req.ParentID != nil {
+		parent, err = s.comments.FindByID(ctx, *req.ParentID)
+		if err == nil && parent.PostID != req.PostID {
+			err = repository.ErrNotFound
+		}
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return nil, errors.New("回复的评论不存在")
+			}
+			logger.ErrorContext(ctx, "Database error", "error", err)
+			return nil, errors.New("获取评论失败")
+		}
+		if parent.IsDeleted || parent.Status != models.CommentStatusApproved {
+			return nil, errors.New("不能回复已删除或未通过审核的评论")
+		}
+		if parent.Depth+1 > s.maxDepth {
+			return nil, fmt.Errorf("回复层级不能超过%d层", s.maxDepth)
+		}
+		comment.Depth = parent.Depth + 1
+	}
+
+	result, err := s.moderate(ctx, s.createPipeline, userID, post, req.Content)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to moderate comment", "error", err)
+		return nil, errors.New("评论创建失败")
+	}
+	comment.Status = commentStatusFor(result.Verdict)
+	comment.ModerationReason = result.Reason()
+
+	if err := s.comments.Create(ctx, comment, parent); err != nil {
+		logger.ErrorContext(ctx, "Failed to create comment", "error", err)
+		return nil, errors.New("评论创建失败")
+	}
+
+	if comment.Status == models.CommentStatusRejected {
+		return nil, fmt.Errorf("评论未通过审核：%s", comment.ModerationReason)
+	}
+
+	if comment.Status == models.CommentStatusApproved {
+		s.activity.NotifyCommentPublished(ctx, comment)
+	}
+
+	comment.Reactions = []models.ReactionSummary{}
 
-	if err := database.DB.Preload("User").First(comment, comment.ID).Error; err != nil {
-		logger.Error("Failed to load comment with user:", err)
-		return nil, errors.New("获取评论信息失败")
+	if comment.Status == models.CommentStatusApproved {
+		publishCommentEvent(s.broker, events.CommentCreated, comment)
 	}
 
 	response := comment.ToResponse()
 	return &response, nil
 }
 
-func (s *CommentService) GetCommentsByPostID(postID uint) ([]models.CommentResponse, error) {
-	var post models.Post
-	if err := database.DB.First(&post, postID).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
+// publishedPost 加载已发布的文章，草稿和不存在的文章都视为不存在
+func (s *CommentService) publishedPost(ctx context.Context, postID uint) (*models.Post, error) {
+	post, err := s.posts.FindByID(ctx, postID)
+	if err == nil && post.Status != models.PostStatusPublished {
+		err = repository.ErrNotFound
+	}
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
 			return nil, errors.New("文章不存在")
 		}
-		logger.Error("Database error:", err)
+		logger.ErrorContext(ctx, "Database error", "error", err)
 		return nil, errors.New("获取文章失败")
 	}
+	return post, nil
+}
+
+// checkCommentPolicy 检查文章的评论设置，文章作者和版主不受限制
+func (s *CommentService) checkCommentPolicy(ctx context.Context, userID uint, post *models.Post) error {
+	if userID == post.UserID || (!post.CommentsLocked && post.CommentPolicy != models.CommentPolicyFollowers) {
+		return nil
+	}
+
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to load user", "error", err)
+		return errors.New("获取用户信息失败")
+	}
+	if user.IsModerator() {
+		return nil
+	}
+
+	if post.CommentsLocked {
+		return errors.New("该文章的评论已关闭")
+	}
+
+	following, err := s.users.IsFollowing(ctx, userID, post.UserID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to check follow", "error", err)
+		return errors.New("评论创建失败")
+	}
+	if !following {
+		return errors.New("该文章仅允许作者的关注者评论")
+	}
+
+	return nil
+}
+
+var commentListSpec = &listSpec{
+	fields: map[string]sortField{
+		"created_at": {kind: cursorTime},
+		"updated_at": {kind: cursorTime},
+	},
+	defaultSort:  "created_at",
+	defaultOrder: "asc",
+}
+
+// GetCommentsByPostID 按顶层评论分页，每个顶层评论连同其全部回复一起返回
+func (s *CommentService) GetCommentsByPostID(ctx context.Context, postID, viewerID uint, view string, q *PageQuery) ([]models.CommentResponse, *PageInfo, error) {
+	ctx, span := tracing.Start(ctx, "CommentService.GetCommentsByPostID")
+	defer span.End()
+
+	if view == "" {
+		view = CommentViewFlat
+	}
+	if view != CommentViewFlat && view != CommentViewTree {
+		return nil, nil, fmt.Errorf("%w: view 只能是 flat 或 tree", ErrInvalidPageQuery)
+	}
+
+	k, err := commentListSpec.resolve(q)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if _, err := s.publishedPost(ctx, postID); err != nil {
+		return nil, nil, err
+	}
+
+	roots, err := s.comments.ListThreads(ctx, postID, k.page())
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to get comments", "error", err)
+		return nil, nil, errors.New("获取评论列表失败")
+	}
+
+	roots, info := paginate(k, roots, func(c *models.Comment) (any, uint) {
+		if k.sort == "updated_at" {
+			return c.UpdatedAt, c.ID
+		}
+		return c.CreatedAt, c.ID
+	})
+
+	replies, err := s.comments.ListReplies(ctx, postID, roots)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to get replies", "error", err)
+		return nil, nil, errors.New("获取评论列表失败")
+	}
+
+	if q.WithTotal {
+		total, err := s.comments.CountThreads(ctx, postID)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to count comments", "error", err)
+			return nil, nil, errors.New("获取评论总数失败")
+		}
+		info.Total = &total
+	}
+
+	if err := s.activity.AttachCommentReactions(ctx, roots, viewerID); err != nil {
+		logger.ErrorContext(ctx, "Failed to load reactions", "error", err)
+		return nil, nil, errors.New("获取表情统计失败")
+	}
+	if err := s.activity.AttachCommentReactions(ctx, replies, viewerID); err != nil {
+		logger.ErrorContext(ctx, "Failed to load reactions", "error", err)
+		return nil, nil, errors.New("获取表情统计失败")
+	}
+
+	if view == CommentViewTree {
+		return buildCommentTree(roots, replies), info, nil
+	}
+	return flattenCommentThreads(roots, replies), info, nil
+}
+
+// flattenCommentThreads 按楼层顺序展开，回复已按 path 排好序，父评论总在子评论之前。
+// 和 buildCommentTree 一样，父评论没有通过审核或被隐藏时，它下面的回复都不返回
+func flattenCommentThreads(roots, replies []models.Comment) []models.CommentResponse {
+	visible := make(map[uint]bool, len(roots)+len(replies))
+	for _, root := range roots {
+		visible[root.ID] = true
+	}
+
+	byRoot := make(map[string][]models.Comment)
+	for _, reply := range replies {
+		if !visible[*reply.ParentID] {
+			continue
+		}
+		visible[reply.ID] = true
+		prefix := reply.Path[:models.CommentPathWidth]
+		byRoot[prefix] = append(byRoot[prefix], reply)
+	}
 
-	var comments []models.Comment
-	if err := database.DB.Preload("User").
-		Where("post_id = ?", postID).
-		Order("created_at ASC").
-		Find(&comments).Error; err != nil {
-		logger.Error("Failed to get comments:", err)
-		return nil, errors.New("获取评论列表失败")
+	responses := make([]models.CommentResponse, 0, len(roots)+len(replies))
+	for _, root := range roots {
+		responses = append(responses, root.ToResponse())
+		for _, reply := range byRoot[root.Path] {
+			responses = append(responses, reply.ToResponse())
+		}
 	}
+	return responses
+}
 
-	responses := make([]models.CommentResponse, len(comments))
-	for i, comment := range comments {
-		responses[i] = comment.ToResponse()
+func buildCommentTree(roots, replies []models.Comment) []models.CommentResponse {
+	children := make(map[uint][]models.Comment)
+	for _, reply := range replies {
+		children[*reply.ParentID] = append(children[*reply.ParentID], reply)
 	}
 
-	return responses, nil
+	var build func(c *models.Comment) models.CommentResponse
+	build = func(c *models.Comment) models.CommentResponse {
+		response := c.ToResponse()
+		for i := range children[c.ID] {
+			response.Replies = append(response.Replies, build(&children[c.ID][i]))
+		}
+		return response
+	}
+
+	responses := make([]models.CommentResponse, len(roots))
+	for i := range roots {
+		responses[i] = build(&roots[i])
+	}
+	return responses
 }
 
-func (s *CommentService) DeleteComment(commentID, userID uint) error {
-	var comment models.Comment
-	if err := database.DB.First(&comment, commentID).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("评论不存在")
+func (s *CommentService) UpdateComment(ctx context.Context, commentID, userID uint, req *models.CommentUpdateRequest) (*models.CommentResponse, error) {
+	ctx, span := tracing.Start(ctx, "CommentService.UpdateComment")
+	defer span.End()
+
+	comment, err := s.liveComment(ctx, commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if comment.UserID != userID {
+		return nil, errors.New("无权限修改此评论")
+	}
+
+	if s.editWindow > 0 && s.clock.Now().Sub(comment.CreatedAt) > s.editWindow {
+		return nil, fmt.Errorf("评论发布超过%s后不能再编辑", s.editWindow)
+	}
+
+	wasApproved := comment.Status == models.CommentStatusApproved
+	edited := req.Content != comment.Content
+	previousContent := comment.Content
+
+	if edited {
+		post, err := s.posts.FindByID(ctx, comment.PostID)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to load post", "error", err)
+			return nil, errors.New("获取文章失败")
+		}
+
+		result, err := s.moderate(ctx, s.editPipeline, userID, post, req.Content)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to moderate comment", "error", err)
+			return nil, errors.New("评论更新失败")
+		}
+		if result.Verdict == moderation.Reject {
+			return nil, fmt.Errorf("修改后的评论未通过审核：%s", result.Reason())
+		}
+
+		// 编辑后需要复审的评论先撤下，等待重新审核
+		status, reason := "", ""
+		if result.Verdict == moderation.Review && comment.Status == models.CommentStatusApproved {
+			status, reason = models.CommentStatusPending, result.Reason()
 		}
-		logger.Error("Database error:", err)
-		return errors.New("获取评论失败")
+
+		if err := s.comments.Edit(ctx, comment, userID, req.Content, s.clock.Now(), status, reason); err != nil {
+			logger.ErrorContext(ctx, "Failed to update comment", "error", err)
+			return nil, errors.New("评论更新失败")
+		}
+	}
+
+	loaded := []models.Comment{*comment}
+	if err := s.activity.AttachCommentReactions(ctx, loaded, userID); err != nil {
+		logger.ErrorContext(ctx, "Failed to load reactions", "error", err)
+		return nil, errors.New("获取表情统计失败")
+	}
+	comment = &loaded[0]
+
+	// 待审核的评论在通过审核时通知其中提及的所有用户，已公开的评论只通知编辑新增的提及
+	if edited && comment.Status == models.CommentStatusApproved {
+		publishCommentEvent(s.broker, events.CommentEdited, comment)
+		s.activity.NotifyCommentMentions(ctx, comment, previousContent)
+	} else if wasApproved && comment.Status != models.CommentStatusApproved {
+		publishCommentEvent(s.broker, events.CommentDeleted, comment)
+	}
+
+	response := comment.ToResponse()
+	return &response, nil
+}
+
+func (s *CommentService) GetCommentHistory(ctx context.Context, commentID, userID uint) ([]models.CommentRevision, error) {
+	ctx, span := tracing.Start(ctx, "CommentService.GetCommentHistory")
+	defer span.End()
+
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to load user", "error", err)
+		return nil, errors.New("获取用户信息失败")
+	}
+
+	if !user.IsModerator() {
+		return nil, errors.New("无权限查看评论编辑历史")
+	}
+
+	if _, err := s.comments.FindUnscoped(ctx, commentID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, errors.New("评论不存在")
+		}
+		logger.ErrorContext(ctx, "Database error", "error", err)
+		return nil, errors.New("获取评论失败")
+	}
+
+	revisions, err := s.comments.Revisions(ctx, commentID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to get comment revisions", "error", err)
+		return nil, errors.New("获取评论编辑历史失败")
+	}
+
+	return revisions, nil
+}
+
+func (s *CommentService) DeleteComment(ctx context.Context, commentID, userID uint) error {
+	ctx, span := tracing.Start(ctx, "CommentService.DeleteComment")
+	defer span.End()
+
+	comment, err := s.liveComment(ctx, commentID)
+	if err != nil {
+		return err
 	}
 
 	if comment.UserID != userID {
-		return errors.New("无权限删除此评论")
+		post, err := s.posts.FindByID(ctx, comment.PostID)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to load post", "error", err)
+			return errors.New("获取文章失败")
+		}
+
+		_, ok, err := canManageComments(ctx, s.users, userID, post)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to load user", "error", err)
+			return errors.New("获取用户信息失败")
+		}
+		if !ok {
+			return errors.New("无权限删除此评论")
+		}
 	}
 
-	if err := database.DB.Delete(&comment).Error; err != nil {
-		logger.Error("Failed to delete comment:", err)
+	if err := s.comments.Remove(ctx, comment); err != nil {
+		logger.ErrorContext(ctx, "Failed to delete comment", "error", err)
 		return errors.New("评论删除失败")
 	}
 
+	if comment.Status == models.CommentStatusApproved {
+		publishCommentEvent(s.broker, events.CommentDeleted, comment)
+	}
+
 	return nil
 }
+
+// liveComment 加载未删除的评论，"[deleted]" 占位评论视为不存在
+func (s *CommentService) liveComment(ctx context.Context, commentID uint) (*models.Comment, error) {
+	comment, err := s.comments.FindByID(ctx, commentID)
+	if err == nil && comment.IsDeleted {
+		err = repository.ErrNotFound
+	}
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, errors.New("评论不存在")
+		}
+		logger.ErrorContext(ctx, "Database error", "error", err)
+		return nil, errors.New("获取评论失败")
+	}
+	return comment, nil
+}
+
+// HideComment 文章作者或版主隐藏评论，隐藏的评论不再出现在评论列表中
+func (s *CommentService) HideComment(ctx context.Context, commentID, userID uint) (*models.CommentResponse, error) {
+	ctx, span := tracing.Start(ctx, "CommentService.HideComment")
+	defer span.End()
+
+	comment, _, err := loadManagedComment(ctx, s.users, s.comments, commentID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if comment.Status != models.CommentStatusApproved {
+		return nil, errors.New("只能隐藏已发布的评论")
+	}
+
+	if err := s.comments.SetStatus(ctx, comment, models.CommentStatusHidden, ""); err != nil {
+		logger.ErrorContext(ctx, "Failed to hide comment", "error", err)
+		return nil, errors.New("隐藏评论失败")
+	}
+
+	publishCommentEvent(s.broker, events.CommentDeleted, comment)
+
+	response := comment.ToResponse()
+	return &response, nil
+}
+
+func (s *CommentService) UnhideComment(ctx context.Context, commentID, userID uint) (*models.CommentResponse, error) {
+	ctx, span := tracing.Start(ctx, "CommentService.UnhideComment")
+	defer span.End()
+
+	comment, _, err := loadManagedComment(ctx, s.users, s.comments, commentID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if comment.Status != models.CommentStatusHidden {
+		return nil, errors.New("评论未被隐藏")
+	}
+
+	if err := s.comments.SetStatus(ctx, comment, models.CommentStatusApproved, ""); err != nil {
+		logger.ErrorContext(ctx, "Failed to unhide comment", "error", err)
+		return nil, errors.New("取消隐藏评论失败")
+	}
+
+	publishCommentEvent(s.broker, events.CommentCreated, comment)
+
+	response := comment.ToResponse()
+	return &response, nil
+}
+
+func (s *CommentService) RepairPostStats(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Start(ctx, "CommentService.RepairPostStats")
+	defer span.End()
+
+	rows, err := s.comments.RecountPostStats(ctx)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to recount comment stats", "error", err)
+		return 0, errors.New("重新统计评论数失败")
+	}
+	return rows, nil
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"blog-system/config"
+	"blog-system/internal/feed"
+	"blog-system/internal/models"
+	"blog-system/pkg/logger"
+	"blog-system/pkg/tracing"
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+var ErrFeedNotFound = errors.New("订阅源不存在")
+
+type FeedService struct {
+	db          *gorm.DB
+	site        config.SiteConfig
+	cfg         config.FeedConfig
+	postService *PostService
+}
+
+func NewFeedService(db *gorm.DB, posts *PostService, site config.SiteConfig, cfg config.FeedConfig) *FeedService {
+	if cfg.Items <= 0 || cfg.Items > MaxPageSize {
+		cfg.Items = DefaultPageSize
+	}
+	if cfg.ExcerptLength <= 0 {
+		cfg.ExcerptLength = 300
+	}
+
+	return &FeedService{
+		db:          db,
+		site:        site,
+		cfg:         cfg,
+		postService: posts,
+	}
+}
+
+// FullContent 返回默认是否输出全文
+func (s *FeedService) FullContent() bool {
+	return s.cfg.FullContent
+}
+
+// BuildFeed 使用与文章列表相同的查询生成订阅源，feedPath 是订阅源自身的路径
+func (s *FeedService) BuildFeed(ctx context.Context, filter *PostQuery, fullContent bool, feedPath string) (*feed.Feed, error) {
+	ctx, span := tracing.Start(ctx, "FeedService.BuildFeed")
+	defer span.End()
+
+	db := s.db.WithContext(ctx)
+	f := &feed.Feed{
+		Title:       s.site.Title,
+		Description: s.site.Description,
+		Link:        s.site.URL,
+		FeedURL:     s.site.URL + feedPath,
+	}
+
+	if filter.AuthorUsername != "" {
+		var user models.User
+		if err := db.Where("username = ?", filter.AuthorUsername).First(&user).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrFeedNotFound
+			}
+			logger.ErrorContext(ctx, "Database error", "error", err)
+			return nil, errors.New("获取作者失败")
+		}
+		f.Title = fmt.Sprintf("%s - %s", s.site.Title, user.Username)
+		f.Link = authorURL(s.site, user.Username)
+	}
+
+	if filter.Tag != "" {
+		var tag models.Tag
+		if err := db.Where("slug = ?", models.TagSlug(filter.Tag)).First(&tag).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrFeedNotFound
+			}
+			logger.ErrorContext(ctx, "Database error", "error", err)
+			return nil, errors.New("获取标签失败")
+		}
+		f.Title = fmt.Sprintf("%s - #%s", s.site.Title, tag.Name)
+	}
+
+	posts, _, err := s.postService.GetPosts(ctx, filter, &PageQuery{
+		Limit: s.cfg.Items,
+		Sort:  "created_at",
+		Order: "desc",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	f.Items = make([]feed.Item, len(posts))
+	for i, post := range posts {
+		item := feed.Item{
+			ID:         feed.TagURI(s.site.URL, post.CreatedAt, fmt.Sprintf("posts/%d", post.ID)),
+			Title:      post.Title,
+			Link:       postURL(s.site, post.ID),
+			Author:     post.User.Username,
+			Summary:    feed.Excerpt(post.Content, s.cfg.ExcerptLength),
+			Categories: post.Tags,
+			Published:  post.CreatedAt,
+			Updated:    post.UpdatedAt,
+		}
+		if fullContent {
+			item.Content = post.Content
+		}
+		if post.UpdatedAt.After(f.Updated) {
+			f.Updated = post.UpdatedAt
+		}
+		f.Items[i] = item
+	}
+
+	return f, nil
+}
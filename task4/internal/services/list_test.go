@@ -0,0 +1,171 @@
+package services_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"blog-system/internal/events"
+	"blog-system/internal/models"
+	"blog-system/internal/services"
+)
+
+func postTitles(posts []models.PostListResponse) string {
+	titles := make([]string, len(posts))
+	for i, post := range posts {
+		titles[i] = post.Title
+	}
+	return fmt.Sprint(titles)
+}
+
+func TestGetPostsPagesThroughMemoryRepository(t *testing.T) {
+	s := newMemoryServices(t)
+	ctx := context.Background()
+	alice := s.register(t, "alice")
+	bob := s.register(t, "bob")
+
+	for _, title := range []string{"one", "two", "three"} {
+		if _, err := s.posts.CreatePost(ctx, alice, &models.PostCreateRequest{Title: title, Content: "内容"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.posts.CreatePost(ctx, bob, &models.PostCreateRequest{Title: "Bob's 100%", Content: "内容"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.posts.CreatePost(ctx, alice, &models.PostCreateRequest{Title: "draft", Content: "内容", Status: models.PostStatusDraft}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 创建时间相同，按 ID 排序
+	page := &services.PageQuery{Limit: 2, Sort: "created_at", Order: "asc"}
+	first, info, err := s.posts.GetPosts(ctx, &services.PostQuery{}, page)
+	if err != nil {
+		t.Fatalf("first page: %v", err)
+	}
+	if got := postTitles(first); got != "[one two]" || info.NextCursor == "" || info.PrevCursor != "" {
+		t.Fatalf("first page = %s, %+v", got, info)
+	}
+
+	page.Cursor = info.NextCursor
+	second, info, err := s.posts.GetPosts(ctx, &services.PostQuery{}, page)
+	if err != nil {
+		t.Fatalf("second page: %v", err)
+	}
+	if got := postTitles(second); got != "[three Bob's 100%]" || info.NextCursor != "" || info.PrevCursor == "" {
+		t.Fatalf("second page = %s, %+v", got, info)
+	}
+
+	page.Cursor = info.PrevCursor
+	back, _, err := s.posts.GetPosts(ctx, &services.PostQuery{}, page)
+	if err != nil {
+		t.Fatalf("previous page: %v", err)
+	}
+	if got := postTitles(back); got != "[one two]" {
+		t.Errorf("previous page = %s, want [one two]", got)
+	}
+
+	tests := []struct {
+		name   string
+		filter services.PostQuery
+		want   string
+	}{
+		{"author", services.PostQuery{AuthorUsername: "bob"}, "[Bob's 100%]"},
+		{"title", services.PostQuery{Title: "100%"}, "[Bob's 100%]"},
+		{"drafts", services.PostQuery{Status: models.PostStatusDraft, ViewerID: alice}, "[draft]"},
+		{"other user's drafts", services.PostQuery{Status: models.PostStatusDraft, ViewerID: bob}, "[]"},
+	}
+	for _, tt := range tests {
+		posts, info, err := s.posts.GetPosts(ctx, &tt.filter, &services.PageQuery{Limit: 10, WithTotal: true})
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := postTitles(posts); got != tt.want || *info.Total != int64(len(posts)) {
+			t.Errorf("%s: posts = %s, total = %d; want %s", tt.name, got, *info.Total, tt.want)
+		}
+	}
+}
+
+func TestCommentListsUseMemoryRepository(t *testing.T) {
+	s := newMemoryServices(t)
+	ctx := context.Background()
+	alice := s.register(t, "alice")
+	bob := s.register(t, "bob")
+	postID := s.post(t, alice)
+
+	first := s.comment(t, alice, postID, nil)
+	reply := s.comment(t, alice, postID, &first)
+	second := s.comment(t, alice, postID, nil)
+	// bob 第一次评论需要审核
+	pending := s.comment(t, bob, postID, nil)
+
+	comments, info, err := s.comments.GetCommentsByPostID(ctx, postID, 0, services.CommentViewFlat,
+		&services.PageQuery{Limit: 1, WithTotal: true})
+	if err != nil {
+		t.Fatalf("list comments: %v", err)
+	}
+	if len(comments) != 2 || comments[0].ID != first || comments[1].ID != reply || *info.Total != 2 {
+		t.Fatalf("first thread = %+v, total %d", comments, *info.Total)
+	}
+	comments, _, err = s.comments.GetCommentsByPostID(ctx, postID, 0, services.CommentViewFlat,
+		&services.PageQuery{Limit: 1, Cursor: info.NextCursor})
+	if err != nil {
+		t.Fatalf("list next thread: %v", err)
+	}
+	if len(comments) != 1 || comments[0].ID != second {
+		t.Errorf("second thread = %+v, want comment %d", comments, second)
+	}
+
+	moderation := services.NewModerationService(s.repos, services.NopActivity{}, events.NewMemoryBroker())
+	queue, _, err := moderation.GetQueue(ctx, alice, "", &services.PageQuery{Limit: 10})
+	if err != nil {
+		t.Fatalf("moderation queue: %v", err)
+	}
+	if len(queue) != 1 || queue[0].ID != pending || queue[0].PostTitle != "标题" {
+		t.Errorf("post author's queue = %+v, want comment %d", queue, pending)
+	}
+	queue, _, err = moderation.GetQueue(ctx, bob, "", &services.PageQuery{Limit: 10})
+	if err != nil {
+		t.Fatalf("moderation queue: %v", err)
+	}
+	if len(queue) != 0 {
+		t.Errorf("queue of a user without posts = %+v, want empty", queue)
+	}
+}
+
+// 被隐藏的回复下面已经通过审核的回复，在两种视图中都不返回
+func TestCommentViewsHideRepliesOfHiddenComments(t *testing.T) {
+	s := newMemoryServices(t)
+	ctx := context.Background()
+	alice := s.register(t, "alice")
+	postID := s.post(t, alice)
+
+	root := s.comment(t, alice, postID, nil)
+	hidden := s.comment(t, alice, postID, &root)
+	s.comment(t, alice, postID, &hidden)
+	visible := s.comment(t, alice, postID, &root)
+
+	comment, err := s.repos.Comments.FindByID(ctx, hidden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.repos.Comments.SetStatus(ctx, comment, models.CommentStatusHidden, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	flat, _, err := s.comments.GetCommentsByPostID(ctx, postID, 0, services.CommentViewFlat, &services.PageQuery{Limit: 10})
+	if err != nil {
+		t.Fatalf("flat view: %v", err)
+	}
+	if len(flat) != 2 || flat[0].ID != root || flat[1].ID != visible {
+		t.Errorf("flat view = %+v, want comments %d and %d", flat, root, visible)
+	}
+
+	tree, _, err := s.comments.GetCommentsByPostID(ctx, postID, 0, services.CommentViewTree, &services.PageQuery{Limit: 10})
+	if err != nil {
+		t.Fatalf("tree view: %v", err)
+	}
+	if len(tree) != 1 || len(tree[0].Replies) != 1 || tree[0].Replies[0].ID != visible {
+		t.Errorf("tree view = %+v, want comment %d with reply %d", tree, root, visible)
+	}
+}
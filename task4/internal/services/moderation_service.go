@@ -0,0 +1,232 @@
+package services
+
+import (
+	"blog-system/config"
+	"blog-system/internal/events"
+	"blog-system/internal/models"
+	"blog-system/internal/moderation"
+	"blog-system/internal/repository"
+	"blog-system/pkg/clock"
+	"blog-system/pkg/logger"
+	"blog-system/pkg/tracing"
+	"context"
+	"errors"
+	"fmt"
+)
+
+// newModerationPipelines 返回发表评论和编辑评论使用的审核流程，编辑时只检查内容
+func newModerationPipelines(history moderation.History, clk clock.Clock, cfg config.ModerationConfig) (create, edit *moderation.Pipeline) {
+	contentChecks := []moderation.Check{
+		&moderation.LinkLimit{Max: cfg.MaxLinks},
+		moderation.NewBannedWords(cfg.BannedWords),
+		&moderation.Spam{ReviewScore: cfg.SpamReviewScore, RejectScore: cfg.SpamRejectScore},
+	}
+
+	createChecks := append([]moderation.Check{
+		&moderation.RateLimit{Max: cfg.RateLimit, Window: cfg.RateWindow, History: history, Now: clk.Now},
+	}, contentChecks...)
+	if cfg.ReviewFirstComment {
+		createChecks = append(createChecks, &moderation.FirstTimeCommenter{History: history})
+	}
+
+	return moderation.NewPipeline(createChecks...), moderation.NewPipeline(contentChecks...)
+}
+
+func commentStatusFor(verdict moderation.Verdict) string {
+	switch verdict {
+	case moderation.Reject:
+		return models.CommentStatusRejected
+	case moderation.Review:
+		return models.CommentStatusPending
+	default:
+		return models.CommentStatusApproved
+	}
+}
+
+type ModerationService struct {
+	users    repository.UserRepository
+	comments repository.CommentRepository
+	activity Activity
+	broker   events.Broker
+}
+
+func NewModerationService(repos repository.Repositories, activity Activity, broker events.Broker) *ModerationService {
+	return &ModerationService{
+		users:    repos.Users,
+		comments: repos.Comments,
+		activity: activity,
+		broker:   broker,
+	}
+}
+
+var moderationListSpec = &listSpec{
+	fields: map[string]sortField{
+		"created_at": {kind: cursorTime},
+	},
+	defaultSort:  "created_at",
+	defaultOrder: "asc",
+}
+
+// GetQueue 版主可以看到全部待审核评论，文章作者只能看到自己文章下的，
+// 也可以查看被拒绝或被隐藏的评论
+func (s *ModerationService) GetQueue(ctx context.Context, userID uint, status string, q *PageQuery) ([]models.ModerationCommentResponse, *PageInfo, error) {
+	ctx, span := tracing.Start(ctx, "ModerationService.GetQueue")
+	defer span.End()
+
+	if status == "" {
+		status = models.CommentStatusPending
+	}
+	if status != models.CommentStatusPending && status != models.CommentStatusRejected && status != models.CommentStatusHidden {
+		return nil, nil, fmt.Errorf("%w: status 只能是 pending、rejected 或 hidden", ErrInvalidPageQuery)
+	}
+
+	k, err := moderationListSpec.resolve(q)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to load user", "error", err)
+		return nil, nil, errors.New("获取用户信息失败")
+	}
+
+	var ownerID uint
+	if !user.IsModerator() {
+		ownerID = userID
+	}
+
+	comments, err := s.comments.ListByStatus(ctx, status, ownerID, k.page())
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to get moderation queue", "error", err)
+		return nil, nil, errors.New("获取审核队列失败")
+	}
+
+	comments, info := paginate(k, comments, func(c *models.Comment) (any, uint) {
+		return c.CreatedAt, c.ID
+	})
+
+	responses := make([]models.ModerationCommentResponse, len(comments))
+	for i := range comments {
+		responses[i] = comments[i].ToModerationResponse()
+	}
+
+	return responses, info, nil
+}
+
+// canManageComments 版主和文章作者可以管理文章下的评论
+func canManageComments(ctx context.Context, users repository.UserRepository, userID uint, post *models.Post) (*models.User, bool, error) {
+	user, err := users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, false, err
+	}
+	return user, user.IsModerator() || post.UserID == userID, nil
+}
+
+// loadManagedComment 加载评论并确认操作者可以管理该评论
+func loadManagedComment(ctx context.Context, users repository.UserRepository, comments repository.CommentRepository, commentID, userID uint) (*models.Comment, *models.User, error) {
+	comment, err := comments.FindByID(ctx, commentID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil, errors.New("评论不存在")
+		}
+		logger.ErrorContext(ctx, "Database error", "error", err)
+		return nil, nil, errors.New("获取评论失败")
+	}
+
+	user, ok, err := canManageComments(ctx, users, userID, &comment.Post)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to load user", "error", err)
+		return nil, nil, errors.New("获取用户信息失败")
+	}
+	if !ok {
+		return nil, nil, errors.New("无权限管理此评论")
+	}
+
+	return comment, user, nil
+}
+
+func (s *ModerationService) ApproveComment(ctx context.Context, commentID, userID uint) (*models.CommentResponse, error) {
+	ctx, span := tracing.Start(ctx, "ModerationService.ApproveComment")
+	defer span.End()
+
+	comment, _, err := loadManagedComment(ctx, s.users, s.comments, commentID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 被隐藏的评论通过 UnhideComment 恢复，被拒绝的评论不能再通过
+	if comment.Status != models.CommentStatusPending {
+		return nil, errors.New("只能通过待审核的评论")
+	}
+
+	if err := s.comments.SetStatus(ctx, comment, models.CommentStatusApproved, ""); err != nil {
+		logger.ErrorContext(ctx, "Failed to approve comment", "error", err)
+		return nil, errors.New("评论审核失败")
+	}
+
+	s.activity.NotifyCommentPublished(ctx, comment)
+	publishCommentEvent(s.broker, events.CommentCreated, comment)
+
+	response := comment.ToResponse()
+	return &response, nil
+}
+
+func (s *ModerationService) RejectComment(ctx context.Context, commentID, userID uint, reason string) (*models.CommentResponse, error) {
+	ctx, span := tracing.Start(ctx, "ModerationService.RejectComment")
+	defer span.End()
+
+	comment, _, err := loadManagedComment(ctx, s.users, s.comments, commentID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if comment.Status != models.CommentStatusPending {
+		return nil, errors.New("只能拒绝待审核的评论")
+	}
+
+	if err := s.comments.SetStatus(ctx, comment, models.CommentStatusRejected, reason); err != nil {
+		logger.ErrorContext(ctx, "Failed to reject comment", "error", err)
+		return nil, errors.New("评论审核失败")
+	}
+
+	response := comment.ToResponse()
+	return &response, nil
+}
+
+// BanCommenter 拒绝待审核的评论并禁止其作者评论：版主全站禁止，文章作者只禁止评论自己的文章
+func (s *ModerationService) BanCommenter(ctx context.Context, commentID, userID uint, reason string) (*models.CommentBan, error) {
+	ctx, span := tracing.Start(ctx, "ModerationService.BanCommenter")
+	defer span.End()
+
+	comment, user, err := loadManagedComment(ctx, s.users, s.comments, commentID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if comment.UserID == userID {
+		return nil, errors.New("不能禁止自己评论")
+	}
+
+	ban := &models.CommentBan{
+		UserID:   comment.UserID,
+		OwnerID:  comment.Post.UserID,
+		BannedBy: userID,
+		Reason:   reason,
+	}
+	if user.IsModerator() {
+		ban.OwnerID = 0
+	}
+
+	var reject *models.Comment
+	if comment.Status == models.CommentStatusPending {
+		reject = comment
+	}
+
+	if err := s.comments.Ban(ctx, ban, reject); err != nil {
+		logger.ErrorContext(ctx, "Failed to ban commenter", "error", err)
+		return nil, errors.New("禁止评论失败")
+	}
+
+	return ban, nil
+}
@@ -0,0 +1,44 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+
+	"blog-system/internal/events"
+	"blog-system/internal/models"
+	"blog-system/internal/services"
+)
+
+func TestApproveOnlyPendingComments(t *testing.T) {
+	s := newMemoryServices(t)
+	ctx := context.Background()
+	moderation := services.NewModerationService(s.repos, services.NopActivity{}, events.NewMemoryBroker())
+	alice := s.register(t, "alice")
+	bob := s.register(t, "bob")
+	postID := s.post(t, alice)
+
+	// bob 第一次评论需要审核
+	pending := s.comment(t, bob, postID, nil)
+	if _, err := moderation.ApproveComment(ctx, pending, alice); err != nil {
+		t.Fatalf("approve pending comment: %v", err)
+	}
+	_, err := moderation.ApproveComment(ctx, pending, alice)
+	expectError(t, "approve approved comment", err, "只能通过待审核的评论")
+
+	for _, status := range []string{models.CommentStatusHidden, models.CommentStatusRejected} {
+		id := s.comment(t, bob, postID, nil)
+		comment, err := s.repos.Comments.FindByID(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.repos.Comments.SetStatus(ctx, comment, status, ""); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = moderation.ApproveComment(ctx, id, alice)
+		expectError(t, "approve "+status+" comment", err, "只能通过待审核的评论")
+		if comment, _ := s.repos.Comments.FindByID(ctx, id); comment.Status != status {
+			t.Errorf("%s comment status changed to %s", status, comment.Status)
+		}
+	}
+}
@@ -0,0 +1,364 @@
+package services
+
+import (
+	"blog-system/internal/models"
+	"blog-system/pkg/clock"
+	"blog-system/pkg/logger"
+	"blog-system/pkg/tracing"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// mentionPattern 匹配 @用户名，前面不能紧跟字母数字以避免匹配邮箱
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_@])@([\p{L}\p{N}_.\-]{3,50})`)
+
+func parseMentions(content string) []string {
+	seen := make(map[string]bool)
+	var usernames []string
+	for _, match := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		username := strings.TrimRight(match[1], ".-")
+		if username != "" && !seen[username] {
+			seen[username] = true
+			usernames = append(usernames, username)
+		}
+	}
+	return usernames
+}
+
+func resolveMentions(db *gorm.DB, content string) ([]models.User, error) {
+	usernames := parseMentions(content)
+	if len(usernames) == 0 {
+		return nil, nil
+	}
+
+	var users []models.User
+	err := db.Where("username IN ?", usernames).Find(&users).Error
+	return users, err
+}
+
+// notifier 收集一次操作要发送的通知，同一用户只收到一条
+type notifier struct {
+	db        *gorm.DB
+	actorID   uint
+	postID    uint
+	commentID *uint
+	notified  map[uint]bool
+	batch     []models.Notification
+}
+
+func newNotifier(db *gorm.DB, actorID, postID uint, commentID *uint) *notifier {
+	return &notifier{
+		db:        db,
+		actorID:   actorID,
+		postID:    postID,
+		commentID: commentID,
+		notified:  map[uint]bool{actorID: true},
+	}
+}
+
+func (n *notifier) add(userID uint, notificationType string) {
+	if userID == 0 || n.notified[userID] {
+		return
+	}
+	n.notified[userID] = true
+	n.batch = append(n.batch, models.Notification{
+		UserID:    userID,
+		ActorID:   n.actorID,
+		Type:      notificationType,
+		PostID:    n.postID,
+		CommentID: n.commentID,
+	})
+}
+
+// send 过滤掉接收者关闭的通知类型后批量写入
+func (n *notifier) send() error {
+	if len(n.batch) == 0 {
+		return nil
+	}
+
+	recipients := make([]uint, len(n.batch))
+	for i := range n.batch {
+		recipients[i] = n.batch[i].UserID
+	}
+
+	var disabled []models.NotificationPreference
+	if err := n.db.Where("user_id IN ? AND enabled = ?", recipients, false).Find(&disabled).Error; err != nil {
+		return err
+	}
+	off := make(map[string]bool, len(disabled))
+	for _, p := range disabled {
+		off[fmt.Sprintf("%d:%s", p.UserID, p.Type)] = true
+	}
+
+	notifications := make([]models.Notification, 0, len(n.batch))
+	for _, notification := range n.batch {
+		if !off[fmt.Sprintf("%d:%s", notification.UserID, notification.Type)] {
+			notifications = append(notifications, notification)
+		}
+	}
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	return n.db.Create(&notifications).Error
+}
+
+// notifyCommentPublished 在评论公开后通知文章作者、被回复的评论作者和被提及的用户
+func notifyCommentPublished(db *gorm.DB, comment *models.Comment) {
+	var post models.Post
+	if err := db.First(&post, comment.PostID).Error; err != nil {
+		logger.ErrorContext(db.Statement.Context, "Failed to load post for notifications", "error", err)
+		return
+	}
+
+	commentID := comment.ID
+	n := newNotifier(db, comment.UserID, comment.PostID, &commentID)
+
+	if comment.ParentID != nil {
+		var parent models.Comment
+		if err := db.First(&parent, *comment.ParentID).Error; err == nil && !parent.IsDeleted {
+			n.add(parent.UserID, models.NotificationTypeReply)
+		}
+	}
+	n.add(post.UserID, models.NotificationTypeComment)
+
+	mentioned, err := resolveMentions(db, comment.Content)
+	if err != nil {
+		logger.ErrorContext(db.Statement.Context, "Failed to resolve mentions", "error", err)
+	}
+	for _, user := range mentioned {
+		n.add(user.ID, models.NotificationTypeMention)
+	}
+
+	if err := n.send(); err != nil {
+		logger.ErrorContext(db.Statement.Context, "Failed to create notifications", "error", err)
+	}
+}
+
+// notifyPostMentions 通知已发布文章中新提及的用户，previous 为修改前的内容
+func notifyPostMentions(db *gorm.DB, post *models.Post, previous string) {
+	if post.Status != models.PostStatusPublished {
+		return
+	}
+
+	mentioned, err := resolveMentions(db, post.Content)
+	if err != nil {
+		logger.ErrorContext(db.Statement.Context, "Failed to resolve mentions", "error", err)
+		return
+	}
+
+	already := make(map[string]bool)
+	for _, username := range parseMentions(previous) {
+		already[username] = true
+	}
+
+	n := newNotifier(db, post.UserID, post.ID, nil)
+	for _, user := range mentioned {
+		if !already[user.Username] {
+			n.add(user.ID, models.NotificationTypeMention)
+		}
+	}
+
+	if err := n.send(); err != nil {
+		logger.ErrorContext(db.Statement.Context, "Failed to create notifications", "error", err)
+	}
+}
+
+// notifyCommentMentions 通知已公开评论中新提及的用户，previous 为修改前的内容
+func notifyCommentMentions(db *gorm.DB, comment *models.Comment, previous string) {
+	if comment.Status != models.CommentStatusApproved {
+		return
+	}
+
+	mentioned, err := resolveMentions(db, comment.Content)
+	if err != nil {
+		logger.ErrorContext(db.Statement.Context, "Failed to resolve mentions", "error", err)
+		return
+	}
+
+	already := make(map[string]bool)
+	for _, username := range parseMentions(previous) {
+		already[username] = true
+	}
+
+	commentID := comment.ID
+	n := newNotifier(db, comment.UserID, comment.PostID, &commentID)
+	for _, user := range mentioned {
+		if !already[user.Username] {
+			n.add(user.ID, models.NotificationTypeMention)
+		}
+	}
+
+	if err := n.send(); err != nil {
+		logger.ErrorContext(db.Statement.Context, "Failed to create notifications", "error", err)
+	}
+}
+
+type NotificationService struct {
+	db    *gorm.DB
+	clock clock.Clock
+}
+
+func NewNotificationService(db *gorm.DB, clk clock.Clock) *NotificationService {
+	return &NotificationService{db: db, clock: clk}
+}
+
+var notificationListSpec = &listSpec{
+	idColumn: "notifications.id",
+	fields: map[string]sortField{
+		"created_at": {expr: "notifications.created_at", kind: cursorTime},
+	},
+	defaultSort:  "created_at",
+	defaultOrder: "desc",
+}
+
+func (s *NotificationService) GetNotifications(ctx context.Context, userID uint, unreadOnly bool, q *PageQuery) ([]models.NotificationResponse, int64, *PageInfo, error) {
+	ctx, span := tracing.Start(ctx, "NotificationService.GetNotifications")
+	defer span.End()
+
+	k, err := notificationListSpec.resolve(q)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	query := s.db.WithContext(ctx).Preload("Actor").Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var notifications []models.Notification
+	if err := k.apply(query).Find(&notifications).Error; err != nil {
+		logger.ErrorContext(ctx, "Failed to get notifications", "error", err)
+		return nil, 0, nil, errors.New("获取通知列表失败")
+	}
+
+	notifications, info := paginate(k, notifications, func(n *models.Notification) (any, uint) {
+		return n.CreatedAt, n.ID
+	})
+
+	unread, err := s.CountUnread(ctx, userID)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	responses := make([]models.NotificationResponse, len(notifications))
+	for i := range notifications {
+		responses[i] = notifications[i].ToResponse()
+	}
+
+	return responses, unread, info, nil
+}
+
+func (s *NotificationService) CountUnread(ctx context.Context, userID uint) (int64, error) {
+	ctx, span := tracing.Start(ctx, "NotificationService.CountUnread")
+	defer span.End()
+
+	var unread int64
+	if err := s.db.WithContext(ctx).Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&unread).Error; err != nil {
+		logger.ErrorContext(ctx, "Failed to count unread notifications", "error", err)
+		return 0, errors.New("获取未读通知数失败")
+	}
+	return unread, nil
+}
+
+func (s *NotificationService) MarkRead(ctx context.Context, notificationID, userID uint) error {
+	ctx, span := tracing.Start(ctx, "NotificationService.MarkRead")
+	defer span.End()
+
+	db := s.db.WithContext(ctx)
+	var notification models.Notification
+	if err := db.Where("user_id = ?", userID).First(&notification, notificationID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("通知不存在")
+		}
+		logger.ErrorContext(ctx, "Database error", "error", err)
+		return errors.New("获取通知失败")
+	}
+
+	if notification.ReadAt != nil {
+		return nil
+	}
+
+	if err := db.Model(&notification).Update("read_at", s.clock.Now()).Error; err != nil {
+		logger.ErrorContext(ctx, "Failed to mark notification read", "error", err)
+		return errors.New("标记通知已读失败")
+	}
+
+	return nil
+}
+
+func (s *NotificationService) MarkAllRead(ctx context.Context, userID uint) (int64, error) {
+	ctx, span := tracing.Start(ctx, "NotificationService.MarkAllRead")
+	defer span.End()
+
+	result := s.db.WithContext(ctx).Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", s.clock.Now())
+	if result.Error != nil {
+		logger.ErrorContext(ctx, "Failed to mark notifications read", "error", result.Error)
+		return 0, errors.New("标记全部已读失败")
+	}
+	return result.RowsAffected, nil
+}
+
+func (s *NotificationService) GetPreferences(ctx context.Context, userID uint) (map[string]bool, error) {
+	ctx, span := tracing.Start(ctx, "NotificationService.GetPreferences")
+	defer span.End()
+
+	var preferences []models.NotificationPreference
+	if err := s.db.WithContext(ctx).Where("user_id = ?", userID).Find(&preferences).Error; err != nil {
+		logger.ErrorContext(ctx, "Failed to get notification preferences", "error", err)
+		return nil, errors.New("获取通知设置失败")
+	}
+
+	result := make(map[string]bool, len(models.NotificationTypes))
+	for _, t := range models.NotificationTypes {
+		result[t] = true
+	}
+	for _, p := range preferences {
+		result[p.Type] = p.Enabled
+	}
+	return result, nil
+}
+
+func (s *NotificationService) UpdatePreferences(ctx context.Context, userID uint, updates map[string]bool) (map[string]bool, error) {
+	ctx, span := tracing.Start(ctx, "NotificationService.UpdatePreferences")
+	defer span.End()
+
+	valid := make(map[string]bool, len(models.NotificationTypes))
+	for _, t := range models.NotificationTypes {
+		valid[t] = true
+	}
+
+	preferences := make([]models.NotificationPreference, 0, len(updates))
+	for t, enabled := range updates {
+		if !valid[t] {
+			return nil, fmt.Errorf("未知的通知类型 %s", t)
+		}
+		preferences = append(preferences, models.NotificationPreference{
+			UserID:  userID,
+			Type:    t,
+			Enabled: enabled,
+		})
+	}
+
+	if len(preferences) > 0 {
+		if err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "type"}},
+			DoUpdates: clause.AssignmentColumns([]string{"enabled", "updated_at"}),
+		}).Create(&preferences).Error; err != nil {
+			logger.ErrorContext(ctx, "Failed to update notification preferences", "error", err)
+			return nil, errors.New("更新通知设置失败")
+		}
+	}
+
+	return s.GetPreferences(ctx, userID)
+}
@@ -0,0 +1,248 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"blog-system/config"
+	"blog-system/internal/events"
+	"blog-system/internal/models"
+	"blog-system/internal/repository"
+	"blog-system/internal/services"
+	"blog-system/pkg/auth"
+	"blog-system/pkg/clock"
+)
+
+// memoryServices 是基于内存存储的用户、文章和评论服务，不需要数据库
+type memoryServices struct {
+	repos    repository.Repositories
+	users    *services.UserService
+	posts    *services.PostService
+	comments *services.CommentService
+}
+
+func newMemoryServices(t *testing.T) *memoryServices {
+	t.Helper()
+
+	cfg, _, err := config.Load(nil)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	clk := clock.NewFixed(time.Now().Truncate(time.Second))
+	repos := repository.NewMemory(clk)
+	return &memoryServices{
+		repos:    repos,
+		users:    services.NewUserService(repos.Users, auth.NewTokenIssuer("secret", time.Hour, clk)),
+		posts:    services.NewPostService(repos.Posts, services.NopActivity{}),
+		comments: services.NewCommentService(repos, services.NopActivity{}, clk, cfg.Comment, events.NewMemoryBroker()),
+	}
+}
+
+func (s *memoryServices) register(t *testing.T, username string) uint {
+	t.Helper()
+	user, err := s.users.Register(context.Background(), &models.UserCreateRequest{
+		Username: username, Password: "secret123", Email: username + "@example.com",
+	})
+	if err != nil {
+		t.Fatalf("register %s: %v", username, err)
+	}
+	return user.ID
+}
+
+func (s *memoryServices) post(t *testing.T, userID uint) uint {
+	t.Helper()
+	post, err := s.posts.CreatePost(context.Background(), userID, &models.PostCreateRequest{Title: "标题", Content: "内容"})
+	if err != nil {
+		t.Fatalf("create post: %v", err)
+	}
+	return post.ID
+}
+
+func (s *memoryServices) comment(t *testing.T, userID, postID uint, parentID *uint) uint {
+	t.Helper()
+	comment, err := s.comments.CreateComment(context.Background(), userID, &models.CommentCreateRequest{
+		PostID: postID, Content: "评论", ParentID: parentID,
+	})
+	if err != nil {
+		t.Fatalf("create comment: %v", err)
+	}
+	return comment.ID
+}
+
+func expectError(t *testing.T, what string, err error, want string) {
+	t.Helper()
+	if err == nil || err.Error() != want {
+		t.Errorf("%s: err = %v, want %q", what, err, want)
+	}
+}
+
+func TestOnlyOwnerCanModifyPostsAndComments(t *testing.T) {
+	s := newMemoryServices(t)
+	ctx := context.Background()
+	alice := s.register(t, "alice")
+	bob := s.register(t, "bob")
+
+	postID := s.post(t, alice)
+	commentID := s.comment(t, alice, postID, nil)
+
+	_, err := s.posts.UpdatePost(ctx, postID, bob, &models.PostUpdateRequest{Title: "改过的标题"})
+	expectError(t, "update another user's post", err, "无权限修改此文章")
+	expectError(t, "delete another user's post", s.posts.DeletePost(ctx, postID, bob), "无权限删除此文章")
+	_, err = s.comments.UpdateComment(ctx, commentID, bob, &models.CommentUpdateRequest{Content: "改过的评论"})
+	expectError(t, "update another user's comment", err, "无权限修改此评论")
+	expectError(t, "delete another user's comment", s.comments.DeleteComment(ctx, commentID, bob), "无权限删除此评论")
+
+	post, err := s.repos.Posts.FindByID(ctx, postID)
+	if err != nil {
+		t.Fatalf("post was deleted by a non-owner: %v", err)
+	}
+	if post.Title != "标题" {
+		t.Errorf("post title = %q, changed by a non-owner", post.Title)
+	}
+	comment, err := s.repos.Comments.FindByID(ctx, commentID)
+	if err != nil {
+		t.Fatalf("comment was deleted by a non-owner: %v", err)
+	}
+	if comment.Content != "评论" {
+		t.Errorf("comment content = %q, changed by a non-owner", comment.Content)
+	}
+
+	// 文章作者可以删除别人在自己文章下的评论
+	bobComment := s.comment(t, bob, postID, nil)
+	if err := s.comments.DeleteComment(ctx, bobComment, alice); err != nil {
+		t.Errorf("post author deleting a comment: %v", err)
+	}
+	if _, err := s.posts.UpdatePost(ctx, postID, alice, &models.PostUpdateRequest{Title: "新标题"}); err != nil {
+		t.Errorf("owner updating post: %v", err)
+	}
+	if err := s.posts.DeletePost(ctx, postID, alice); err != nil {
+		t.Errorf("owner deleting post: %v", err)
+	}
+}
+
+func TestMissingRecordsAreNotFound(t *testing.T) {
+	s := newMemoryServices(t)
+	ctx := context.Background()
+	alice := s.register(t, "alice")
+	postID := s.post(t, alice)
+	const missing = 9999
+
+	_, err := s.posts.GetPostByID(ctx, missing, alice)
+	expectError(t, "get missing post", err, "文章不存在")
+	_, err = s.posts.UpdatePost(ctx, missing, alice, &models.PostUpdateRequest{Title: "标题"})
+	expectError(t, "update missing post", err, "文章不存在")
+	expectError(t, "delete missing post", s.posts.DeletePost(ctx, missing, alice), "文章不存在")
+
+	_, err = s.comments.CreateComment(ctx, alice, &models.CommentCreateRequest{PostID: missing, Content: "评论"})
+	expectError(t, "comment on missing post", err, "文章不存在")
+	parentID := uint(missing)
+	_, err = s.comments.CreateComment(ctx, alice, &models.CommentCreateRequest{PostID: postID, Content: "评论", ParentID: &parentID})
+	expectError(t, "reply to missing comment", err, "回复的评论不存在")
+	_, err = s.comments.UpdateComment(ctx, missing, alice, &models.CommentUpdateRequest{Content: "评论"})
+	expectError(t, "update missing comment", err, "评论不存在")
+	expectError(t, "delete missing comment", s.comments.DeleteComment(ctx, missing, alice), "评论不存在")
+
+	_, err = s.users.GetUserByID(ctx, missing)
+	expectError(t, "get missing user", err, "用户不存在")
+	expectError(t, "follow missing user", s.users.Follow(ctx, alice, missing), "用户不存在")
+	_, _, err = s.users.Login(ctx, &models.UserLoginRequest{Username: "nobody", Password: "secret123"})
+	expectError(t, "login as missing user", err, "用户名或密码错误")
+}
+
+func TestDeletePostDeletesComments(t *testing.T) {
+	s := newMemoryServices(t)
+	ctx := context.Background()
+	alice := s.register(t, "alice")
+	postID := s.post(t, alice)
+	root := s.comment(t, alice, postID, nil)
+	reply := s.comment(t, alice, postID, &root)
+
+	if err := s.posts.DeletePost(ctx, postID, alice); err != nil {
+		t.Fatalf("delete post: %v", err)
+	}
+
+	if _, err := s.repos.Posts.FindByID(ctx, postID); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("find deleted post: err = %v, want ErrNotFound", err)
+	}
+	for _, id := range []uint{root, reply} {
+		if _, err := s.repos.Comments.FindByID(ctx, id); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("find comment %d of deleted post: err = %v, want ErrNotFound", id, err)
+		}
+		expectError(t, "delete comment of deleted post", s.comments.DeleteComment(ctx, id, alice), "评论不存在")
+	}
+	_, err := s.comments.CreateComment(ctx, alice, &models.CommentCreateRequest{PostID: postID, Content: "评论"})
+	expectError(t, "comment on deleted post", err, "文章不存在")
+}
+
+// 有回复的评论删除后保留为 "[deleted]" 占位，最后一条回复删除后占位评论一并删除
+func TestDeleteCommentPrunesPlaceholders(t *testing.T) {
+	s := newMemoryServices(t)
+	ctx := context.Background()
+	alice := s.register(t, "alice")
+	postID := s.post(t, alice)
+
+	root := s.comment(t, alice, postID, nil)
+	middle := s.comment(t, alice, postID, &root)
+	first := s.comment(t, alice, postID, &middle)
+	second := s.comment(t, alice, postID, &middle)
+
+	isPlaceholder := func(id uint) bool {
+		t.Helper()
+		comment, err := s.repos.Comments.FindByID(ctx, id)
+		if err != nil {
+			return false
+		}
+		if comment.IsDeleted && comment.ToResponse().Content != models.DeletedCommentContent {
+			t.Errorf("placeholder %d content = %q", id, comment.ToResponse().Content)
+		}
+		return comment.IsDeleted
+	}
+	isGone := func(id uint) bool {
+		t.Helper()
+		_, err := s.repos.Comments.FindByID(ctx, id)
+		return errors.Is(err, repository.ErrNotFound)
+	}
+	commentCount := func() int64 {
+		t.Helper()
+		post, err := s.repos.Posts.FindByID(ctx, postID)
+		if err != nil {
+			t.Fatalf("find post: %v", err)
+		}
+		return post.CommentCount
+	}
+
+	for _, id := range []uint{root, middle} {
+		if err := s.comments.DeleteComment(ctx, id, alice); err != nil {
+			t.Fatalf("delete comment %d: %v", id, err)
+		}
+		if !isPlaceholder(id) {
+			t.Errorf("comment %d with replies is not a placeholder", id)
+		}
+		// 占位评论视为已删除
+		expectError(t, "delete placeholder", s.comments.DeleteComment(ctx, id, alice), "评论不存在")
+	}
+	if got := commentCount(); got != 2 {
+		t.Errorf("comment_count = %d, want 2", got)
+	}
+
+	if err := s.comments.DeleteComment(ctx, first, alice); err != nil {
+		t.Fatalf("delete first reply: %v", err)
+	}
+	if !isGone(first) || !isPlaceholder(middle) || !isPlaceholder(root) {
+		t.Error("placeholders were pruned while a reply is left")
+	}
+
+	if err := s.comments.DeleteComment(ctx, second, alice); err != nil {
+		t.Fatalf("delete last reply: %v", err)
+	}
+	for _, id := range []uint{second, middle, root} {
+		if !isGone(id) {
+			t.Errorf("comment %d was not removed", id)
+		}
+	}
+	if got := commentCount(); got != 0 {
+		t.Errorf("comment_count = %d, want 0", got)
+	}
+}
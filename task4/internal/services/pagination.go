@@ -0,0 +1,291 @@
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"blog-system/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultPageSize = 10
+	MaxPageSize     = 100
+)
+
+var ErrInvalidPageQuery = errors.New("无效的分页参数")
+
+// PageQuery 列表接口通用的游标分页参数
+type PageQuery struct {
+	Cursor    string
+	Limit     int
+	Sort      string
+	Order     string
+	WithTotal bool
+}
+
+// PageInfo 列表接口通用的分页结果
+type PageInfo struct {
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+	PageSize   int    `json:"page_size"`
+	Sort       string `json:"sort"`
+	Order      string `json:"order"`
+	Total      *int64 `json:"total,omitempty"`
+}
+
+var pageQueryParams = map[string]bool{
+	"cursor":     true,
+	"page_size":  true,
+	"sort":       true,
+	"order":      true,
+	"with_total": true,
+}
+
+func ParsePageQuery(values url.Values) *PageQuery {
+	pageSize, err := strconv.Atoi(values.Get("page_size"))
+	if err != nil || pageSize < 1 || pageSize > MaxPageSize {
+		pageSize = DefaultPageSize
+	}
+
+	withTotal, _ := strconv.ParseBool(values.Get("with_total"))
+
+	return &PageQuery{
+		Cursor:    values.Get("cursor"),
+		Limit:     pageSize,
+		Sort:      values.Get("sort"),
+		Order:     values.Get("order"),
+		WithTotal: withTotal,
+	}
+}
+
+type cursorKind int
+
+const (
+	cursorTime cursorKind = iota
+	cursorString
+	cursorInt
+)
+
+type sortField struct {
+	// expr 是排序使用的列，只有由 apply 直接生成 SQL 的列表需要，
+	// 通过仓储查询的列表由仓储决定排序的列
+	expr string
+	kind cursorKind
+}
+
+// listSpec 描述一个列表接口允许的排序字段
+type listSpec struct {
+	idColumn     string
+	fields       map[string]sortField
+	defaultSort  string
+	defaultOrder string
+}
+
+type cursor struct {
+	Sort  string `json:"s"`
+	Order string `json:"o"`
+	Value any    `json:"v"`
+	ID    uint   `json:"id"`
+	Back  bool   `json:"b,omitempty"`
+}
+
+func encodeCursor(c *cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (*cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var c cursor
+	if err := decoder.Decode(&c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+type keyset struct {
+	spec  *listSpec
+	field sortField
+	sort  string
+	order string
+	limit int
+	from  *cursor
+	value any
+}
+
+func (s *listSpec) resolve(q *PageQuery) (*keyset, error) {
+	k := &keyset{
+		spec:  s,
+		sort:  q.Sort,
+		order: q.Order,
+		limit: q.Limit,
+	}
+
+	if k.sort == "" {
+		k.sort = s.defaultSort
+	}
+	if k.order == "" {
+		k.order = s.defaultOrder
+	}
+	if k.order != "asc" && k.order != "desc" {
+		return nil, fmt.Errorf("%w: order 只能是 asc 或 desc", ErrInvalidPageQuery)
+	}
+
+	field, ok := s.fields[k.sort]
+	if !ok {
+		return nil, fmt.Errorf("%w: 不支持按 %s 排序", ErrInvalidPageQuery, k.sort)
+	}
+	k.field = field
+
+	if k.limit <= 0 {
+		k.limit = DefaultPageSize
+	}
+	if k.limit > MaxPageSize {
+		k.limit = MaxPageSize
+	}
+
+	if q.Cursor == "" {
+		return k, nil
+	}
+
+	c, err := decodeCursor(q.Cursor)
+	if err != nil || c.Sort != k.sort || c.Order != k.order {
+		return nil, fmt.Errorf("%w: 游标无效或与排序参数不匹配", ErrInvalidPageQuery)
+	}
+
+	value, err := field.parse(c.Value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: 游标无效", ErrInvalidPageQuery)
+	}
+
+	k.from = c
+	k.value = value
+	return k, nil
+}
+
+func (f sortField) parse(v any) (any, error) {
+	switch f.kind {
+	case cursorTime:
+		s, ok := v.(string)
+		if !ok {
+			return nil, errors.New("cursor value is not a time")
+		}
+		return time.Parse(time.RFC3339Nano, s)
+	case cursorInt:
+		n, ok := v.(json.Number)
+		if !ok {
+			return nil, errors.New("cursor value is not a number")
+		}
+		return n.Int64()
+	default:
+		s, ok := v.(string)
+		if !ok {
+			return nil, errors.New("cursor value is not a string")
+		}
+		return s, nil
+	}
+}
+
+func (k *keyset) backward() bool {
+	return k.from != nil && k.from.Back
+}
+
+// descending 返回本次查询实际的扫描方向，向前翻页时与请求的排序相反
+func (k *keyset) descending() bool {
+	return (k.order == "desc") != k.backward()
+}
+
+func (k *keyset) apply(db *gorm.DB) *gorm.DB {
+	direction, op := "ASC", ">"
+	if k.descending() {
+		direction, op = "DESC", "<"
+	}
+
+	if k.from != nil {
+		db = db.Where(
+			fmt.Sprintf("(%s %s ?) OR (%s = ? AND %s %s ?)", k.field.expr, op, k.field.expr, k.spec.idColumn, op),
+			k.value, k.value, k.from.ID,
+		)
+	}
+
+	return db.Order(k.field.expr + " " + direction).
+		Order(k.spec.idColumn + " " + direction).
+		Limit(k.limit + 1)
+}
+
+// page 返回交给仓储执行的同一个 keyset 查询，和 apply 一样多取一行用来判断是否还有下一页
+func (k *keyset) page() repository.Page {
+	page := repository.Page{Sort: k.sort, Descending: k.descending(), Limit: k.limit + 1}
+	if k.from != nil {
+		page.After = &repository.PagePosition{Value: k.value, ID: k.from.ID}
+	}
+	return page
+}
+
+func (k *keyset) cursorFor(value any, id uint, back bool) string {
+	return encodeCursor(&cursor{
+		Sort:  k.sort,
+		Order: k.order,
+		Value: value,
+		ID:    id,
+		Back:  back,
+	})
+}
+
+// paginate 截断多取的一行并生成前后页游标，key 返回行的排序值和主键
+func paginate[T any](k *keyset, rows []T, key func(*T) (any, uint)) ([]T, *PageInfo) {
+	hasMore := len(rows) > k.limit
+	if hasMore {
+		rows = rows[:k.limit]
+	}
+
+	if k.backward() {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+
+	info := &PageInfo{
+		PageSize: k.limit,
+		Sort:     k.sort,
+		Order:    k.order,
+	}
+
+	if len(rows) == 0 {
+		return rows, info
+	}
+
+	firstValue, firstID := key(&rows[0])
+	lastValue, lastID := key(&rows[len(rows)-1])
+
+	if k.backward() {
+		if hasMore {
+			info.PrevCursor = k.cursorFor(firstValue, firstID, true)
+		}
+		info.NextCursor = k.cursorFor(lastValue, lastID, false)
+	} else {
+		if hasMore {
+			info.NextCursor = k.cursorFor(lastValue, lastID, false)
+		}
+		if k.from != nil {
+			info.PrevCursor = k.cursorFor(firstValue, firstID, true)
+		}
+	}
+
+	return rows, info
+}
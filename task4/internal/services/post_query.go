@@ -0,0 +1,143 @@
+package services
+
+import (
+	"blog-system/internal/models"
+	"blog-system/internal/repository"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidPostQuery = errors.New("无效的筛选参数")
+
+// PostQuery 文章列表的筛选条件，处理器和后台工具共用
+type PostQuery struct {
+	AuthorID       uint
+	AuthorUsername string
+	CreatedAfter   *time.Time
+	CreatedBefore  *time.Time
+	UpdatedAfter   *time.Time
+	UpdatedBefore  *time.Time
+	Title          string
+	HasComments    *bool
+	Status         string
+	Tag            string
+
+	// ViewerID 为当前登录用户，草稿只对作者本人可见
+	ViewerID uint
+}
+
+var postQueryParams = map[string]bool{
+	"author_id":      true,
+	"author":         true,
+	"created_after":  true,
+	"created_before": true,
+	"updated_after":  true,
+	"updated_before": true,
+	"title":          true,
+	"has_comments":   true,
+	"status":         true,
+	"tag":            true,
+}
+
+// ParsePostQuery 解析查询字符串中的筛选条件，分页参数之外的未知参数会被拒绝
+func ParsePostQuery(values url.Values) (*PostQuery, error) {
+	for key := range values {
+		if !postQueryParams[key] && !pageQueryParams[key] {
+			return nil, fmt.Errorf("%w: 未知参数 %s", ErrInvalidPostQuery, key)
+		}
+	}
+
+	q := &PostQuery{
+		AuthorUsername: values.Get("author"),
+		Title:          strings.TrimSpace(values.Get("title")),
+		Status:         values.Get("status"),
+		Tag:            values.Get("tag"),
+	}
+
+	if v := values.Get("author_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%w: author_id 必须是正整数", ErrInvalidPostQuery)
+		}
+		q.AuthorID = uint(id)
+	}
+
+	var err error
+	if q.CreatedAfter, err = parseQueryTime(values, "created_after", false); err != nil {
+		return nil, err
+	}
+	if q.CreatedBefore, err = parseQueryTime(values, "created_before", true); err != nil {
+		return nil, err
+	}
+	if q.UpdatedAfter, err = parseQueryTime(values, "updated_after", false); err != nil {
+		return nil, err
+	}
+	if q.UpdatedBefore, err = parseQueryTime(values, "updated_before", true); err != nil {
+		return nil, err
+	}
+
+	if v := values.Get("has_comments"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("%w: has_comments 必须是 true 或 false", ErrInvalidPostQuery)
+		}
+		q.HasComments = &b
+	}
+
+	if q.Status != "" && q.Status != models.PostStatusPublished && q.Status != models.PostStatusDraft {
+		return nil, fmt.Errorf("%w: status 只能是 published 或 draft", ErrInvalidPostQuery)
+	}
+
+	return q, nil
+}
+
+// parseQueryTime 支持 RFC3339 和 YYYY-MM-DD，日期作为结束时间时包含当天
+func parseQueryTime(values url.Values, key string, endOfDay bool) (*time.Time, error) {
+	v := values.Get(key)
+	if v == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return &t, nil
+	}
+
+	t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s 必须是 RFC3339 时间或 YYYY-MM-DD 日期", ErrInvalidPostQuery, key)
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
+
+// filter 返回交给仓储的筛选条件，默认只查询已发布的文章
+func (q *PostQuery) filter() (repository.PostFilter, error) {
+	f := repository.PostFilter{
+		Status:         q.Status,
+		AuthorID:       q.AuthorID,
+		AuthorUsername: q.AuthorUsername,
+		CreatedAfter:   q.CreatedAfter,
+		CreatedBefore:  q.CreatedBefore,
+		UpdatedAfter:   q.UpdatedAfter,
+		UpdatedBefore:  q.UpdatedBefore,
+		Title:          q.Title,
+		Tag:            q.Tag,
+		HasComments:    q.HasComments,
+	}
+	if f.Status == "" {
+		f.Status = models.PostStatusPublished
+	}
+	if f.Status == models.PostStatusDraft {
+		if q.ViewerID == 0 {
+			return f, fmt.Errorf("%w: 查看草稿需要登录", ErrInvalidPostQuery)
+		}
+		f.OwnerID = q.ViewerID
+	}
+	return f, nil
+}
@@ -2,72 +2,157 @@ package services
 
 import (
 	"blog-system/internal/models"
-	"blog-system/pkg/database"
+	"blog-system/internal/repository"
 	"blog-system/pkg/logger"
+	"blog-system/pkg/tracing"
+	"context"
 	"errors"
-
-	"gorm.io/gorm"
 )
 
-type PostService struct{}
+type PostService struct {
+	posts    repository.PostRepository
+	activity Activity
+}
 
-func NewPostService() *PostService {
-	return &PostService{}
+func NewPostService(posts repository.PostRepository, activity Activity) *PostService {
+	return &PostService{posts: posts, activity: activity}
 }
 
-func (s *PostService) CreatePost(userID uint, req *models.PostCreateRequest) (*models.PostResponse, error) {
+func (s *PostService) CreatePost(ctx context.Context, userID uint, req *models.PostCreateRequest) (*models.PostResponse, error) {
+	ctx, span := tracing.Start(ctx, "PostService.CreatePost")
+	defer span.End()
+
 	post := &models.Post{
 		Title:   req.Title,
 		Content: req.Content,
 		UserID:  userID,
+		Status:  req.Status,
+
+		CommentsLocked: req.CommentsLocked,
+		CommentPolicy:  req.CommentPolicy,
+	}
+	if post.Status == "" {
+		post.Status = models.PostStatusPublished
+	}
+	if post.CommentPolicy == "" {
+		post.CommentPolicy = models.CommentPolicyEveryone
 	}
 
-	if err := database.DB.Create(post).Error; err != nil {
-		logger.Error("Failed to create post:", err)
+	if err := s.posts.Create(ctx, post, req.Tags, req.AttachmentIDs); err != nil {
+		if errors.Is(err, ErrInvalidAttachment) {
+			return nil, err
+		}
+		logger.ErrorContext(ctx, "Failed to create post", "error", err)
 		return nil, errors.New("文章创建失败")
 	}
 
-	if err := database.DB.Preload("User").First(post, post.ID).Error; err != nil {
-		logger.Error("Failed to load post with user:", err)
-		return nil, errors.New("获取文章信息失败")
-	}
+	s.activity.NotifyPostMentions(ctx, post, "")
+	post.Reactions = []models.ReactionSummary{}
+	post.BookmarkCount = new(int64)
 
 	response := post.ToResponse()
 	return &response, nil
 }
 
-func (s *PostService) GetPostByID(id uint) (*models.PostResponse, error) {
-	var post models.Post
-	if err := database.DB.Preload("User").Preload("Comments.User").First(&post, id).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
+func (s *PostService) GetPostByID(ctx context.Context, id, viewerID uint) (*models.PostResponse, error) {
+	ctx, span := tracing.Start(ctx, "PostService.GetPostByID")
+	defer span.End()
+
+	post, err := s.posts.FindDetail(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
 			return nil, errors.New("文章不存在")
 		}
-		logger.Error("Database error:", err)
+		logger.ErrorContext(ctx, "Database error", "error", err)
 		return nil, errors.New("获取文章失败")
 	}
 
+	if post.Status != models.PostStatusPublished && post.UserID != viewerID {
+		return nil, errors.New("文章不存在")
+	}
+
+	if err := s.activity.AttachPostDetailReactions(ctx, post, viewerID); err != nil {
+		logger.ErrorContext(ctx, "Failed to load reactions", "error", err)
+		return nil, errors.New("获取表情统计失败")
+	}
+
+	if err := s.activity.AttachBookmarkCount(ctx, post, viewerID); err != nil {
+		logger.ErrorContext(ctx, "Failed to count bookmarks", "error", err)
+		return nil, errors.New("获取收藏数失败")
+	}
+
 	response := post.ToResponse()
 	return &response, nil
 }
 
-func (s *PostService) GetPosts(page, pageSize int) ([]models.PostListResponse, int64, error) {
-	var posts []models.Post
-	var total int64
+var postListSpec = &listSpec{
+	fields: map[string]sortField{
+		"created_at":    {kind: cursorTime},
+		"updated_at":    {kind: cursorTime},
+		"title":         {kind: cursorString},
+		"comment_count": {kind: cursorInt},
+		"view_count":    {kind: cursorInt},
+	},
+	defaultSort:  "created_at",
+	defaultOrder: "desc",
+}
+
+func postSortKey(p *models.Post, sort string) any {
+	switch sort {
+	case "updated_at":
+		return p.UpdatedAt
+	case "title":
+		return p.Title
+	case "comment_count":
+		return p.CommentCount
+	case "view_count":
+		return p.ViewCount
+	default:
+		return p.CreatedAt
+	}
+}
+
+func (s *PostService) GetPosts(ctx context.Context, filter *PostQuery, q *PageQuery) ([]models.PostListResponse, *PageInfo, error) {
+	ctx, span := tracing.Start(ctx, "PostService.GetPosts")
+	defer span.End()
+
+	k, err := postListSpec.resolve(q)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	postFilter, err := filter.filter()
+	if err != nil {
+		return nil, nil, err
+	}
 
-	offset := (page - 1) * pageSize
+	posts, err := s.posts.List(ctx, postFilter, k.page())
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to get posts", "error", err)
+		return nil, nil, errors.New("获取文章列表失败")
+	}
+
+	posts, info := paginate(k, posts, func(p *models.Post) (any, uint) {
+		return postSortKey(p, k.sort), p.ID
+	})
 
-	if err := database.DB.Model(&models.Post{}).Count(&total).Error; err != nil {
-		logger.Error("Failed to count posts:", err)
-		return nil, 0, errors.New("获取文章总数失败")
+	if q.WithTotal {
+		total, err := s.posts.Count(ctx, postFilter)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to count posts", "error", err)
+			return nil, nil, errors.New("获取文章总数失败")
+		}
+		info.Total = &total
 	}
 
-	if err := database.DB.Preload("User").
-		Order("created_at DESC").
-		Offset(offset).
-		Limit(pageSize).
-		Find(&posts).Error; err != nil {
-		logger.Error("Failed to get posts:", err)
-		return nil, 0, errors.New("获取文章列表失败")
+	if err := s.activity.AttachPostReactions(ctx, posts, filter.ViewerID); err != nil {
+		logger.ErrorContext(ctx, "Failed to load reactions", "error", err)
+		return nil, nil, errors.New("获取表情统计失败")
+	}
+
+	if err := s.activity.AttachBookmarkCounts(ctx, posts, filter.ViewerID); err != nil {
+		logger.ErrorContext(ctx, "Failed to count bookmarks", "error", err)
+		return nil, nil, errors.New("获取收藏数失败")
 	}
 
 	responses := make([]models.PostListResponse, len(posts))
@@ -75,51 +160,84 @@ func (s *PostService) GetPosts(page, pageSize int) ([]models.PostListResponse, i
 		responses[i] = post.ToListResponse()
 	}
 
-	return responses, total, nil
+	return responses, info, nil
 }
 
-func (s *PostService) UpdatePost(postID, userID uint, req *models.PostUpdateRequest) (*models.PostResponse, error) {
-	var post models.Post
-	if err := database.DB.First(&post, postID).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
+func (s *PostService) UpdatePost(ctx context.Context, postID, userID uint, req *models.PostUpdateRequest) (*models.PostResponse, error) {
+	ctx, span := tracing.Start(ctx, "PostService.UpdatePost")
+	defer span.End()
+
+	post, err := s.posts.FindByID(ctx, postID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
 			return nil, errors.New("文章不存在")
 		}
-		logger.Error("Database error:", err)
+		logger.ErrorContext(ctx, "Database error", "error", err)
 		return nil, errors.New("获取文章失败")
 	}
 
 	if post.UserID != userID {
 		return nil, errors.New("无权限修改此文章")
 	}
-	updates := make(map[string]interface{})
+
+	// 草稿发布时通知其中提及的所有用户，已发布的文章只通知新增的提及
+	previousContent := post.Content
+	if post.Status != models.PostStatusPublished {
+		previousContent = ""
+	}
+
+	changes := repository.PostChanges{
+		CommentsLocked: req.CommentsLocked,
+		Tags:           req.Tags,
+		AttachmentIDs:  req.AttachmentIDs,
+	}
 	if req.Title != "" {
-		updates["title"] = req.Title
+		changes.Title = &req.Title
 	}
 	if req.Content != "" {
-		updates["content"] = req.Content
+		changes.Content = &req.Content
+	}
+	if req.Status != "" {
+		changes.Status = &req.Status
+	}
+	if req.CommentPolicy != "" {
+		changes.CommentPolicy = &req.CommentPolicy
 	}
 
-	if err := database.DB.Model(&post).Updates(updates).Error; err != nil {
-		logger.Error("Failed to update post:", err)
+	if err := s.posts.Update(ctx, post, changes); err != nil {
+		if errors.Is(err, ErrInvalidAttachment) {
+			return nil, err
+		}
+		logger.ErrorContext(ctx, "Failed to update post", "error", err)
 		return nil, errors.New("文章更新失败")
 	}
 
-	if err := database.DB.Preload("User").First(&post, postID).Error; err != nil {
-		logger.Error("Failed to reload post:", err)
-		return nil, errors.New("获取更新后的文章信息失败")
+	s.activity.NotifyPostMentions(ctx, post, previousContent)
+
+	if err := s.activity.AttachPostDetailReactions(ctx, post, userID); err != nil {
+		logger.ErrorContext(ctx, "Failed to load reactions", "error", err)
+		return nil, errors.New("获取表情统计失败")
+	}
+
+	if err := s.activity.AttachBookmarkCount(ctx, post, userID); err != nil {
+		logger.ErrorContext(ctx, "Failed to count bookmarks", "error", err)
+		return nil, errors.New("获取收藏数失败")
 	}
 
 	response := post.ToResponse()
 	return &response, nil
 }
 
-func (s *PostService) DeletePost(postID, userID uint) error {
-	var post models.Post
-	if err := database.DB.First(&post, postID).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
+func (s *PostService) DeletePost(ctx context.Context, postID, userID uint) error {
+	ctx, span := tracing.Start(ctx, "PostService.DeletePost")
+	defer span.End()
+
+	post, err := s.posts.FindByID(ctx, postID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
 			return errors.New("文章不存在")
 		}
-		logger.Error("Database error:", err)
+		logger.ErrorContext(ctx, "Database error", "error", err)
 		return errors.New("获取文章失败")
 	}
 
@@ -127,13 +245,8 @@ func (s *PostService) DeletePost(postID, userID uint) error {
 		return errors.New("无权限删除此文章")
 	}
 
-	if err := database.DB.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Where("post_id = ?", postID).Delete(&models.Comment{}).Error; err != nil {
-			return err
-		}
-		return tx.Delete(&post).Error
-	}); err != nil {
-		logger.Error("Failed to delete post:", err)
+	if err := s.posts.Delete(ctx, post); err != nil {
+		logger.ErrorContext(ctx, "Failed to delete post", "error", err)
 		return errors.New("文章删除失败")
 	}
 
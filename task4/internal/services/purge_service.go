@@ -0,0 +1,131 @@
+package services
+
+import (
+	"blog-system/internal/models"
+	"blog-system/internal/storage"
+	"blog-system/pkg/logger"
+	"blog-system/pkg/tracing"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const purgeBatchSize = 100
+
+// PurgeService 彻底删除已删除一段时间的文章和没有关联文章的附件
+type PurgeService struct {
+	db    *gorm.DB
+	store storage.Storage
+}
+
+func NewPurgeService(db *gorm.DB, store storage.Storage) *PurgeService {
+	return &PurgeService{db: db, store: store}
+}
+
+// PurgeDeletedPosts 彻底删除在 before 之前被删除的文章，连同评论、表情回应、收藏、
+// 通知、浏览统计和附件，附件文件在数据库提交后删除
+func (s *PurgeService) PurgeDeletedPosts(ctx context.Context, before time.Time) (int, error) {
+	ctx, span := tracing.Start(ctx, "PurgeService.PurgeDeletedPosts")
+	defer span.End()
+
+	db := s.db.WithContext(ctx)
+	total := 0
+	for {
+		var ids []uint
+		if err := db.Unscoped().Model(&models.Post{}).
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+			Order("id").Limit(purgeBatchSize).
+			Pluck("id", &ids).Error; err != nil {
+			logger.ErrorContext(ctx, "Failed to find deleted posts", "error", err)
+			return total, errors.New("查询已删除文章失败")
+		}
+		if len(ids) == 0 {
+			return total, nil
+		}
+
+		var attachments []models.Attachment
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("post_id IN ?", ids).Find(&attachments).Error; err != nil {
+				return err
+			}
+			return purgePosts(tx, ids)
+		}); err != nil {
+			logger.ErrorContext(ctx, "Failed to purge posts", "error", err)
+			return total, errors.New("彻底删除文章失败")
+		}
+
+		deleteAttachmentFiles(s.store, attachments...)
+		total += len(ids)
+	}
+}
+
+func purgePosts(tx *gorm.DB, ids []uint) error {
+	commentIDs := tx.Unscoped().Model(&models.Comment{}).Select("id").Where("post_id IN ?", ids)
+
+	steps := []func() *gorm.DB{
+		func() *gorm.DB { return tx.Where("comment_id IN (?)", commentIDs).Delete(&models.CommentRevision{}) },
+		func() *gorm.DB {
+			return tx.Where("target_type = ? AND target_id IN (?)", models.ReactionTargetComment, commentIDs).Delete(&models.Reaction{})
+		},
+		func() *gorm.DB {
+			return tx.Where("target_type = ? AND target_id IN ?", models.ReactionTargetPost, ids).Delete(&models.Reaction{})
+		},
+		func() *gorm.DB { return tx.Where("post_id IN ?", ids).Delete(&models.Notification{}) },
+		func() *gorm.DB { return tx.Where("post_id IN ?", ids).Delete(&models.Bookmark{}) },
+		func() *gorm.DB { return tx.Where("post_id IN ?", ids).Delete(&models.PostDailyView{}) },
+		func() *gorm.DB { return tx.Where("post_id IN ?", ids).Delete(&models.PostReferrerView{}) },
+		func() *gorm.DB { return tx.Where("post_id IN ?", ids).Delete(&models.Attachment{}) },
+		func() *gorm.DB { return tx.Exec("DELETE FROM post_tags WHERE post_id IN ?", ids) },
+		func() *gorm.DB { return tx.Unscoped().Where("post_id IN ?", ids).Delete(&models.Comment{}) },
+		func() *gorm.DB { return tx.Unscoped().Where("id IN ?", ids).Delete(&models.Post{}) },
+	}
+	for _, step := range steps {
+		if err := step().Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// orphanAttachment 是可以清理的附件：没有关联文章，且在 before 之前上传或移出文章
+const orphanAttachment = "post_id IS NULL AND COALESCE(detached_at, created_at) < ?"
+
+// PurgeOrphanUploads 删除在 before 之前上传且一直没有关联文章的附件，以及在 before 之前被移出文章的附件
+func (s *PurgeService) PurgeOrphanUploads(ctx context.Context, before time.Time) (int, error) {
+	ctx, span := tracing.Start(ctx, "PurgeService.PurgeOrphanUploads")
+	defer span.End()
+
+	db := s.db.WithContext(ctx)
+	total := 0
+	for {
+		var candidates []models.Attachment
+		if err := db.Where(orphanAttachment, before).
+			Order("id").Limit(purgeBatchSize).
+			Find(&candidates).Error; err != nil {
+			logger.ErrorContext(ctx, "Failed to find orphan uploads", "error", err)
+			return total, errors.New("查询未使用的附件失败")
+		}
+		if len(candidates) == 0 {
+			return total, nil
+		}
+
+		// 查询之后附件可能刚被关联到文章，删除时重新检查条件，只删除确实删掉了记录的附件文件
+		var deleted []models.Attachment
+		for _, attachment := range candidates {
+			result := db.Where("id = ? AND "+orphanAttachment, attachment.ID, before).Delete(&models.Attachment{})
+			if result.Error != nil {
+				logger.ErrorContext(ctx, "Failed to delete orphan upload", "error", result.Error, "attachment_id", attachment.ID)
+				deleteAttachmentFiles(s.store, deleted...)
+				return total + len(deleted), errors.New("删除未使用的附件失败")
+			}
+			if result.RowsAffected > 0 {
+				deleted = append(deleted, attachment)
+			}
+		}
+
+		deleteAttachmentFiles(s.store, deleted...)
+		total += len(deleted)
+	}
+}
@@ -0,0 +1,157 @@
+package services_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"blog-system/internal/app/apptest"
+	"blog-system/internal/models"
+	"blog-system/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// createAttachment 保存附件文件和记录，createdAt 为上传时间
+func createAttachment(t *testing.T, env *apptest.Env, userID uint, key string, createdAt time.Time) *models.Attachment {
+	t.Helper()
+
+	if err := env.App.Storage.Put(context.Background(), key, []byte("data"), "text/plain"); err != nil {
+		t.Fatalf("store %s: %v", key, err)
+	}
+	attachment := &models.Attachment{
+		UserID:      userID,
+		Filename:    key,
+		ContentType: "text/plain",
+		Size:        4,
+		Key:         key,
+		URL:         env.App.Storage.URL(key),
+		CreatedAt:   createdAt,
+	}
+	if err := env.App.DB.Create(attachment).Error; err != nil {
+		t.Fatalf("create attachment %s: %v", key, err)
+	}
+	return attachment
+}
+
+func fileExists(t *testing.T, env *apptest.Env, key string) bool {
+	t.Helper()
+	_, err := os.Stat(filepath.Join(env.App.Config.Upload.LocalDir, key))
+	return err == nil
+}
+
+func attachmentExists(t *testing.T, env *apptest.Env, id uint) bool {
+	t.Helper()
+	var count int64
+	if err := env.App.DB.Model(&models.Attachment{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		t.Fatalf("count attachments: %v", err)
+	}
+	return count > 0
+}
+
+func TestPurgeOrphanUploadsCountsFromDetach(t *testing.T) {
+	env := apptest.New(t)
+	ctx := context.Background()
+	now := env.Clock.Now()
+
+	user := &models.User{Username: "alice", Email: "alice@example.com", Password: "x", Role: models.RoleUser}
+	if err := env.App.Repos.Users.Create(ctx, user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	detached := createAttachment(t, env, user.ID, "detached.txt", now.Add(-48*time.Hour))
+	orphan := createAttachment(t, env, user.ID, "orphan.txt", now.Add(-48*time.Hour))
+	recent := createAttachment(t, env, user.ID, "recent.txt", now)
+
+	// 两天前上传的附件刚刚才从文章中移出
+	post := &models.Post{Title: "title", Content: "content", UserID: user.ID, Status: models.PostStatusPublished}
+	if err := env.App.Repos.Posts.Create(ctx, post, nil, []uint{detached.ID}); err != nil {
+		t.Fatalf("create post: %v", err)
+	}
+	if err := env.App.Repos.Posts.Update(ctx, post, repository.PostChanges{AttachmentIDs: &[]uint{}}); err != nil {
+		t.Fatalf("detach attachment: %v", err)
+	}
+
+	purged, err := env.App.Services.Purge.PurgeOrphanUploads(ctx, now.Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("purged %d attachments, want 1", purged)
+	}
+	if attachmentExists(t, env, orphan.ID) || fileExists(t, env, orphan.Key) {
+		t.Errorf("orphan uploaded two days ago was not purged")
+	}
+	if !attachmentExists(t, env, detached.ID) || !fileExists(t, env, detached.Key) {
+		t.Errorf("attachment detached just now was purged")
+	}
+	if !attachmentExists(t, env, recent.ID) {
+		t.Errorf("recent upload was purged")
+	}
+
+	env.Clock.Advance(25 * time.Hour)
+	purged, err = env.App.Services.Purge.PurgeOrphanUploads(ctx, env.Clock.Now().Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+	if purged != 2 {
+		t.Errorf("purged %d attachments after the retention period, want 2", purged)
+	}
+	if attachmentExists(t, env, detached.ID) || fileExists(t, env, detached.Key) {
+		t.Errorf("detached attachment was not purged after the retention period")
+	}
+}
+
+// 查询出待清理的附件之后，附件被并发的请求关联到文章，不能再删除记录和文件
+func TestPurgeOrphanUploadsSkipsAttachmentLinkedConcurrently(t *testing.T) {
+	env := apptest.New(t)
+	ctx := context.Background()
+	now := env.Clock.Now()
+
+	user := &models.User{Username: "alice", Email: "alice@example.com", Password: "x", Role: models.RoleUser}
+	if err := env.App.Repos.Users.Create(ctx, user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	post := &models.Post{Title: "title", Content: "content", UserID: user.ID, Status: models.PostStatusPublished}
+	if err := env.App.Repos.Posts.Create(ctx, post, nil, nil); err != nil {
+		t.Fatalf("create post: %v", err)
+	}
+	linked := createAttachment(t, env, user.ID, "linked.txt", now.Add(-48*time.Hour))
+	orphan := createAttachment(t, env, user.ID, "orphan.txt", now.Add(-48*time.Hour))
+
+	// 在第一次删除附件之前把 linked 关联到文章，模拟查询和删除之间的并发更新
+	raced := false
+	err := env.App.DB.Callback().Delete().Before("gorm:delete").Register("test:link_attachment", func(db *gorm.DB) {
+		if raced || db.Statement.Table != "attachments" {
+			return
+		}
+		raced = true
+		if err := db.Session(&gorm.Session{NewDB: true}).Model(&models.Attachment{}).
+			Where("id = ?", linked.ID).Update("post_id", post.ID).Error; err != nil {
+			t.Errorf("link attachment: %v", err)
+		}
+	})
+	if err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+	t.Cleanup(func() { env.App.DB.Callback().Delete().Remove("test:link_attachment") })
+
+	purged, err := env.App.Services.Purge.PurgeOrphanUploads(ctx, now.Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+	if !raced {
+		t.Fatal("callback did not run")
+	}
+	if purged != 1 {
+		t.Errorf("purged %d attachments, want 1", purged)
+	}
+	if !attachmentExists(t, env, linked.ID) || !fileExists(t, env, linked.Key) {
+		t.Errorf("attachment linked during the purge was deleted")
+	}
+	if attachmentExists(t, env, orphan.ID) || fileExists(t, env, orphan.Key) {
+		t.Errorf("orphan was not purged")
+	}
+}
@@ -0,0 +1,283 @@
+package services
+
+import (
+	"blog-system/internal/models"
+	"blog-system/pkg/clock"
+	"blog-system/pkg/logger"
+	"blog-system/pkg/tracing"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// popularPeriod 是点赞排行的统计周期
+	popularPeriod       = 7 * 24 * time.Hour
+	DefaultPopularLimit = 10
+	MaxPopularLimit     = 50
+)
+
+type ReactionService struct {
+	db    *gorm.DB
+	clock clock.Clock
+}
+
+func NewReactionService(db *gorm.DB, clk clock.Clock) *ReactionService {
+	return &ReactionService{db: db, clock: clk}
+}
+
+// loadReactionSummaries 批量统计对象的表情数量，viewerID 为0时 Reacted 均为 false
+func loadReactionSummaries(db *gorm.DB, targetType string, ids []uint, viewerID uint) (map[uint][]models.ReactionSummary, error) {
+	summaries := make(map[uint][]models.ReactionSummary, len(ids))
+	if len(ids) == 0 {
+		return summaries, nil
+	}
+
+	var counts []struct {
+		TargetID uint
+		Type     string
+		Count    int64
+	}
+	if err := db.Model(&models.Reaction{}).
+		Select("target_id, type, COUNT(*) AS count").
+		Where("target_type = ? AND target_id IN ?", targetType, ids).
+		Group("target_id, type").
+		Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+
+	reacted := make(map[uint]map[string]bool)
+	if viewerID != 0 {
+		var own []models.Reaction
+		if err := db.Select("target_id, type").
+			Where("target_type = ? AND target_id IN ? AND user_id = ?", targetType, ids, viewerID).
+			Find(&own).Error; err != nil {
+			return nil, err
+		}
+		for _, r := range own {
+			if reacted[r.TargetID] == nil {
+				reacted[r.TargetID] = make(map[string]bool)
+			}
+			reacted[r.TargetID][r.Type] = true
+		}
+	}
+
+	byTarget := make(map[uint]map[string]int64)
+	for _, c := range counts {
+		if byTarget[c.TargetID] == nil {
+			byTarget[c.TargetID] = make(map[string]int64)
+		}
+		byTarget[c.TargetID][c.Type] = c.Count
+	}
+
+	for _, id := range ids {
+		summary := []models.ReactionSummary{}
+		for _, t := range models.ReactionTypes {
+			if count := byTarget[id][t]; count > 0 {
+				summary = append(summary, models.ReactionSummary{
+					Type:    t,
+					Count:   count,
+					Reacted: reacted[id][t],
+				})
+			}
+		}
+		summaries[id] = summary
+	}
+
+	return summaries, nil
+}
+
+func attachPostReactions(db *gorm.DB, posts []models.Post, viewerID uint) error {
+	ids := make([]uint, len(posts))
+	for i := range posts {
+		ids[i] = posts[i].ID
+	}
+
+	summaries, err := loadReactionSummaries(db, models.ReactionTargetPost, ids, viewerID)
+	if err != nil {
+		return err
+	}
+	for i := range posts {
+		posts[i].Reactions = summaries[posts[i].ID]
+	}
+	return nil
+}
+
+// attachPostDetailReactions 统计单篇文章及其已加载评论的表情
+func attachPostDetailReactions(db *gorm.DB, post *models.Post, viewerID uint) error {
+	summaries, err := loadReactionSummaries(db, models.ReactionTargetPost, []uint{post.ID}, viewerID)
+	if err != nil {
+		return err
+	}
+	post.Reactions = summaries[post.ID]
+
+	return attachCommentReactions(db, post.Comments, viewerID)
+}
+
+func attachCommentReactions(db *gorm.DB, comments []models.Comment, viewerID uint) error {
+	ids := make([]uint, len(comments))
+	for i := range comments {
+		ids[i] = comments[i].ID
+	}
+
+	summaries, err := loadReactionSummaries(db, models.ReactionTargetComment, ids, viewerID)
+	if err != nil {
+		return err
+	}
+	for i := range comments {
+		comments[i].Reactions = summaries[comments[i].ID]
+	}
+	return nil
+}
+
+// checkReactionTarget 只能对可见的文章和已发布的评论添加表情
+func checkReactionTarget(db *gorm.DB, targetType string, targetID, userID uint) error {
+	switch targetType {
+	case models.ReactionTargetPost:
+		var post models.Post
+		if err := db.First(&post, targetID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("文章不存在")
+			}
+			logger.ErrorContext(db.Statement.Context, "Database error", "error", err)
+			return errors.New("获取文章失败")
+		}
+		if post.Status != models.PostStatusPublished && post.UserID != userID {
+			return errors.New("文章不存在")
+		}
+	case models.ReactionTargetComment:
+		var comment models.Comment
+		if err := db.Where("is_deleted = ? AND status = ?", false, models.CommentStatusApproved).
+			First(&comment, targetID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("评论不存在")
+			}
+			logger.ErrorContext(db.Statement.Context, "Database error", "error", err)
+			return errors.New("获取评论失败")
+		}
+	}
+	return nil
+}
+
+// ToggleReaction 已经使用过该表情时取消，否则添加
+func (s *ReactionService) ToggleReaction(ctx context.Context, userID uint, targetType string, targetID uint, reactionType string) (*models.ReactionToggleResponse, error) {
+	ctx, span := tracing.Start(ctx, "ReactionService.ToggleReaction")
+	defer span.End()
+
+	db := s.db.WithContext(ctx)
+	if !models.IsValidReactionType(reactionType) {
+		return nil, errors.New("不支持的表情类型")
+	}
+
+	if err := checkReactionTarget(db, targetType, targetID, userID); err != nil {
+		return nil, err
+	}
+
+	reaction := &models.Reaction{
+		UserID:     userID,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Type:       reactionType,
+	}
+
+	var reacted bool
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where(reaction).Delete(&models.Reaction{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			return nil
+		}
+
+		reacted = true
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(reaction).Error
+	}); err != nil {
+		logger.ErrorContext(ctx, "Failed to toggle reaction", "error", err)
+		return nil, errors.New("操作失败")
+	}
+
+	summaries, err := loadReactionSummaries(db, targetType, []uint{targetID}, userID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to load reactions", "error", err)
+		return nil, errors.New("获取表情统计失败")
+	}
+
+	return &models.ReactionToggleResponse{
+		Type:      reactionType,
+		Reacted:   reacted,
+		Reactions: summaries[targetID],
+	}, nil
+}
+
+// GetMostLiked 返回近7天新增点赞最多的已发布文章
+func (s *ReactionService) GetMostLiked(ctx context.Context, viewerID uint, limit int) ([]models.PopularPostResponse, error) {
+	ctx, span := tracing.Start(ctx, "ReactionService.GetMostLiked")
+	defer span.End()
+
+	db := s.db.WithContext(ctx)
+	if limit <= 0 {
+		limit = DefaultPopularLimit
+	}
+	if limit > MaxPopularLimit {
+		limit = MaxPopularLimit
+	}
+
+	var ranking []struct {
+		TargetID uint
+		Likes    int64
+	}
+	if err := db.Model(&models.Reaction{}).
+		Select("reactions.target_id, COUNT(*) AS likes").
+		Joins("JOIN posts ON posts.id = reactions.target_id AND posts.deleted_at IS NULL AND posts.status = ?", models.PostStatusPublished).
+		Where("reactions.target_type = ? AND reactions.type = ? AND reactions.created_at >= ?",
+			models.ReactionTargetPost, models.ReactionLike, s.clock.Now().Add(-popularPeriod)).
+		Group("reactions.target_id").
+		Order("likes DESC").
+		Order("reactions.target_id DESC").
+		Limit(limit).
+		Scan(&ranking).Error; err != nil {
+		logger.ErrorContext(ctx, "Failed to rank posts", "error", err)
+		return nil, errors.New("获取点赞排行失败")
+	}
+
+	ids := make([]uint, len(ranking))
+	for i, r := range ranking {
+		ids[i] = r.TargetID
+	}
+
+	var posts []models.Post
+	if len(ids) > 0 {
+		if err := db.Preload("User").Preload("Tags").Where("id IN ?", ids).Find(&posts).Error; err != nil {
+			logger.ErrorContext(ctx, "Failed to get posts", "error", err)
+			return nil, errors.New("获取点赞排行失败")
+		}
+	}
+
+	if err := attachPostReactions(db, posts, viewerID); err != nil {
+		logger.ErrorContext(ctx, "Failed to load reactions", "error", err)
+		return nil, errors.New("获取表情统计失败")
+	}
+
+	byID := make(map[uint]*models.Post, len(posts))
+	for i := range posts {
+		byID[posts[i].ID] = &posts[i]
+	}
+
+	responses := make([]models.PopularPostResponse, 0, len(ranking))
+	for _, r := range ranking {
+		post, ok := byID[r.TargetID]
+		if !ok {
+			continue
+		}
+		responses = append(responses, models.PopularPostResponse{
+			PostListResponse: post.ToListResponse(),
+			WeeklyLikes:      r.Likes,
+		})
+	}
+
+	return responses, nil
+}
@@ -0,0 +1,17 @@
+package services
+
+import (
+	"blog-system/config"
+	"fmt"
+	"net/url"
+)
+
+// 前台页面地址的约定，订阅源和站点地图共用
+
+func postURL(site config.SiteConfig, postID uint) string {
+	return fmt.Sprintf("%s/posts/%d", site.URL, postID)
+}
+
+func authorURL(site config.SiteConfig, username string) string {
+	return fmt.Sprintf("%s/authors/%s", site.URL, url.PathEscape(username))
+}
@@ -0,0 +1,246 @@
+package services
+
+import (
+	"blog-system/config"
+	"blog-system/internal/models"
+	"blog-system/internal/sitemap"
+	"blog-system/pkg/logger"
+	"blog-system/pkg/tracing"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// sitemapOverlap 增量刷新时向前多查一段时间，避免漏掉提交较晚的事务
+const sitemapOverlap = time.Minute
+
+var ErrSitemapNotFound = errors.New("站点地图不存在")
+
+type sitemapPost struct {
+	authorID uint
+	username string
+	updated  time.Time
+}
+
+// SitemapFile 是渲染好的站点地图文件
+type SitemapFile struct {
+	Body         []byte
+	LastModified time.Time
+}
+
+// SitemapService 在内存中维护已发布文章的列表，首次请求时全量加载，
+// 之后每隔 RefreshInterval 只查询有变化的文章，内容变化时才重新渲染
+type SitemapService struct {
+	db   *gorm.DB
+	site config.SiteConfig
+	cfg  config.SitemapConfig
+
+	mu          sync.Mutex
+	posts       map[uint]sitemapPost
+	lastRefresh time.Time
+	index       *SitemapFile
+	files       []*SitemapFile
+}
+
+func NewSitemapService(db *gorm.DB, site config.SiteConfig, cfg config.SitemapConfig) *SitemapService {
+	if cfg.MaxURLs <= 0 || cfg.MaxURLs > sitemap.MaxURLs {
+		cfg.MaxURLs = sitemap.MaxURLs
+	}
+	return &SitemapService{db: db, site: site, cfg: cfg}
+}
+
+// Index 返回 /sitemap.xml 的内容：URL 不超过上限时是站点地图本身，否则是索引
+func (s *SitemapService) Index(ctx context.Context) (*SitemapFile, error) {
+	ctx, span := tracing.Start(ctx, "SitemapService.Index")
+	defer span.End()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.refresh(ctx); err != nil {
+		return nil, err
+	}
+	if s.index != nil {
+		return s.index, nil
+	}
+	return s.files[0], nil
+}
+
+// File 返回索引中的第 n 个站点地图，从1开始
+func (s *SitemapService) File(ctx context.Context, n int) (*SitemapFile, error) {
+	ctx, span := tracing.Start(ctx, "SitemapService.File")
+	defer span.End()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.refresh(ctx); err != nil {
+		return nil, err
+	}
+	if s.index == nil || n < 1 || n > len(s.files) {
+		return nil, ErrSitemapNotFound
+	}
+	return s.files[n-1], nil
+}
+
+func (s *SitemapService) FileURL(n int) string {
+	return fmt.Sprintf("%s/sitemaps/sitemap-%d.xml", s.site.URL, n)
+}
+
+func (s *SitemapService) refresh(ctx context.Context) error {
+	if s.posts != nil && time.Since(s.lastRefresh) < s.cfg.RefreshInterval {
+		return nil
+	}
+
+	start := time.Now()
+	query := s.db.WithContext(ctx).Unscoped().Model(&models.Post{}).
+		Select("posts.id, posts.user_id, posts.status, posts.updated_at, posts.deleted_at, users.username").
+		Joins("JOIN users ON users.id = posts.user_id")
+
+	full := s.posts == nil
+	if full {
+		query = query.Where("posts.status = ? AND posts.deleted_at IS NULL", models.PostStatusPublished)
+	} else {
+		since := s.lastRefresh.Add(-sitemapOverlap)
+		query = query.Where("posts.updated_at >= ? OR posts.deleted_at >= ?", since, since)
+	}
+
+	var rows []struct {
+		ID        uint
+		UserID    uint
+		Status    string
+		UpdatedAt time.Time
+		DeletedAt *time.Time
+		Username  string
+	}
+	if err := query.Scan(&rows).Error; err != nil {
+		logger.ErrorContext(ctx, "Failed to load posts for sitemap", "error", err)
+		return errors.New("生成站点地图失败")
+	}
+
+	posts := s.posts
+	if full {
+		posts = make(map[uint]sitemapPost, len(rows))
+	}
+
+	changed := full
+	for _, row := range rows {
+		old, exists := posts[row.ID]
+		if row.Status != models.PostStatusPublished || row.DeletedAt != nil {
+			if exists {
+				delete(posts, row.ID)
+				changed = true
+			}
+			continue
+		}
+
+		post := sitemapPost{authorID: row.UserID, username: row.Username, updated: row.UpdatedAt}
+		if !exists || !old.updated.Equal(post.updated) {
+			posts[row.ID] = post
+			changed = true
+		}
+	}
+
+	if changed {
+		if err := s.render(posts); err != nil {
+			logger.ErrorContext(ctx, "Failed to render sitemap", "error", err)
+			return errors.New("生成站点地图失败")
+		}
+	}
+
+	s.posts = posts
+	s.lastRefresh = start
+	return nil
+}
+
+func (s *SitemapService) render(posts map[uint]sitemapPost) error {
+	ids := make([]uint, 0, len(posts))
+	authors := make(map[string]time.Time)
+	var latest time.Time
+	for id, post := range posts {
+		ids = append(ids, id)
+		if post.updated.After(authors[post.username]) {
+			authors[post.username] = post.updated
+		}
+		if post.updated.After(latest) {
+			latest = post.updated
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	usernames := make([]string, 0, len(authors))
+	for username := range authors {
+		usernames = append(usernames, username)
+	}
+	sort.Strings(usernames)
+
+	urls := make([]sitemap.URL, 0, 1+len(ids)+len(usernames))
+	urls = append(urls, sitemap.URL{Loc: s.site.URL + "/", LastMod: latest})
+	for _, id := range ids {
+		urls = append(urls, sitemap.URL{Loc: postURL(s.site, id), LastMod: posts[id].updated})
+	}
+	for _, username := range usernames {
+		urls = append(urls, sitemap.URL{Loc: authorURL(s.site, username), LastMod: authors[username]})
+	}
+
+	var files []*SitemapFile
+	for start := 0; start < len(urls); start += s.cfg.MaxURLs {
+		end := min(start+s.cfg.MaxURLs, len(urls))
+		body, err := sitemap.RenderURLSet(urls[start:end])
+		if err != nil {
+			return err
+		}
+
+		var lastModified time.Time
+		for _, u := range urls[start:end] {
+			if u.LastMod.After(lastModified) {
+				lastModified = u.LastMod
+			}
+		}
+		files = append(files, &SitemapFile{Body: body, LastModified: lastModified})
+	}
+
+	s.files = files
+	s.index = nil
+	if len(files) == 1 {
+		return nil
+	}
+
+	entries := make([]sitemap.URL, len(files))
+	for i, file := range files {
+		entries[i] = sitemap.URL{Loc: s.FileURL(i + 1), LastMod: file.LastModified}
+	}
+	body, err := sitemap.RenderIndex(entries)
+	if err != nil {
+		return err
+	}
+	s.index = &SitemapFile{Body: body, LastModified: latest}
+	return nil
+}
+
+// RobotsTxt 根据配置生成 robots.txt，并指向站点地图
+func (s *SitemapService) RobotsTxt(robots config.RobotsConfig) []byte {
+	var b strings.Builder
+	b.WriteString("User-agent: *\n")
+	if robots.DisallowAll {
+		b.WriteString("Disallow: /\n")
+	} else {
+		for _, path := range robots.Allow {
+			fmt.Fprintf(&b, "Allow: %s\n", path)
+		}
+		for _, path := range robots.Disallow {
+			fmt.Fprintf(&b, "Disallow: %s\n", path)
+		}
+		if len(robots.Disallow) == 0 {
+			b.WriteString("Disallow:\n")
+		}
+	}
+	fmt.Fprintf(&b, "\nSitemap: %s/sitemap.xml\n", s.site.URL)
+	return []byte(b.String())
+}
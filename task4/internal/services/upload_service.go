@@ -0,0 +1,241 @@
+package services
+
+import (
+	"blog-system/config"
+	"blog-system/internal/media"
+	"blog-system/internal/models"
+	"blog-system/internal/repository"
+	"blog-system/internal/storage"
+	"blog-system/pkg/logger"
+	"blog-system/pkg/tracing"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUploadTooLarge      = errors.New("文件大小超过限制")
+	ErrUnsupportedFileType = errors.New("不支持的文件类型")
+	ErrInvalidAttachment   = repository.ErrInvalidAttachment
+)
+
+// uploadExtensions 为允许上传的类型及保存时使用的扩展名
+var uploadExtensions = map[string]string{
+	media.TypeJPEG:    ".jpg",
+	media.TypePNG:     ".png",
+	media.TypeGIF:     ".gif",
+	media.TypeWebP:    ".webp",
+	"application/pdf": ".pdf",
+	"application/zip": ".zip",
+	"text/plain":      ".txt",
+}
+
+// NewStorage 按配置创建本地存储或 S3 存储
+func NewStorage(cfg config.UploadConfig) (storage.Storage, error) {
+	switch cfg.Driver {
+	case "local":
+		return storage.NewLocal(cfg.LocalDir, cfg.PublicURL)
+	case "s3":
+		return storage.NewS3(storage.S3Config{
+			Endpoint:  cfg.S3.Endpoint,
+			Region:    cfg.S3.Region,
+			Bucket:    cfg.S3.Bucket,
+			AccessKey: cfg.S3.AccessKey,
+			SecretKey: cfg.S3.SecretKey,
+			PathStyle: cfg.S3.PathStyle,
+			PublicURL: cfg.S3.PublicURL,
+		})
+	default:
+		return nil, fmt.Errorf("unknown upload driver %q", cfg.Driver)
+	}
+}
+
+type UploadService struct {
+	db    *gorm.DB
+	cfg   config.UploadConfig
+	store storage.Storage
+}
+
+func NewUploadService(db *gorm.DB, cfg config.UploadConfig, store storage.Storage) *UploadService {
+	return &UploadService{db: db, cfg: cfg, store: store}
+}
+
+// MaxUploadSize 返回用户角色对应的单个文件大小上限
+func (s *UploadService) MaxUploadSize(ctx context.Context, userID uint) (int64, error) {
+	ctx, span := tracing.Start(ctx, "UploadService.MaxUploadSize")
+	defer span.End()
+
+	var user models.User
+	if err := s.db.WithContext(ctx).First(&user, userID).Error; err != nil {
+		logger.ErrorContext(ctx, "Failed to load user", "error", err)
+		return 0, errors.New("获取用户信息失败")
+	}
+
+	if size, ok := s.cfg.MaxSize[user.Role]; ok {
+		return size, nil
+	}
+	return s.cfg.MaxSize[models.RoleUser], nil
+}
+
+// Upload 按内容识别文件类型，图片会重新编码去掉元数据并生成缩略图。
+// 指定 postID 时直接关联到该文章，文章必须属于当前用户。
+// limit 是 MaxUploadSize 返回的大小上限，调用方在读取请求体之前已经取得
+func (s *UploadService) Upload(ctx context.Context, userID uint, limit int64, postID *uint, filename string, file io.Reader) (*models.Attachment, error) {
+	ctx, span := tracing.Start(ctx, "UploadService.Upload")
+	defer span.End()
+
+	db := s.db.WithContext(ctx)
+	data, err := io.ReadAll(io.LimitReader(file, limit+1))
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to read upload", "error", err)
+		return nil, errors.New("读取上传文件失败")
+	}
+	if int64(len(data)) > limit {
+		return nil, ErrUploadTooLarge
+	}
+
+	if postID != nil {
+		var post models.Post
+		if err := db.Select("id", "user_id").First(&post, *postID).Error; err != nil || post.UserID != userID {
+			return nil, errors.New("文章不存在或无权限")
+		}
+	}
+
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+	if _, ok := uploadExtensions[contentType]; !ok || !slices.Contains(s.cfg.AllowedTypes, contentType) {
+		return nil, ErrUnsupportedFileType
+	}
+
+	attachment := &models.Attachment{
+		UserID:      userID,
+		PostID:      postID,
+		Filename:    cleanFilename(filename),
+		ContentType: contentType,
+	}
+
+	var thumbnail []byte
+	var thumbnailType string
+	if media.IsImage(contentType) {
+		result, err := media.Process(data, contentType, media.Options{
+			MaxPixels:     s.cfg.MaxPixels,
+			ThumbnailSize: s.cfg.ThumbnailSize,
+			JPEGQuality:   s.cfg.JPEGQuality,
+		})
+		if err != nil {
+			if errors.Is(err, media.ErrTooLarge) || errors.Is(err, media.ErrInvalidImage) || errors.Is(err, media.ErrUnsupported) {
+				return nil, err
+			}
+			logger.ErrorContext(ctx, "Failed to process image", "error", err)
+			return nil, errors.New("图片处理失败")
+		}
+
+		data = result.Data
+		attachment.ContentType = result.ContentType
+		attachment.Width = result.Width
+		attachment.Height = result.Height
+		thumbnail = result.Thumbnail
+		thumbnailType = result.ThumbnailType
+
+		name := newUploadKey()
+		attachment.ThumbnailKey = name + "_thumb" + uploadExtensions[result.ThumbnailType]
+		attachment.Key = name + uploadExtensions[result.ContentType]
+	} else {
+		attachment.Key = newUploadKey() + uploadExtensions[contentType]
+	}
+	attachment.Size = int64(len(data))
+
+	if err := s.store.Put(ctx, attachment.Key, data, attachment.ContentType); err != nil {
+		logger.ErrorContext(ctx, "Failed to store upload", "error", err)
+		return nil, errors.New("保存文件失败")
+	}
+	attachment.URL = s.store.URL(attachment.Key)
+
+	if thumbnail != nil {
+		if err := s.store.Put(ctx, attachment.ThumbnailKey, thumbnail, thumbnailType); err != nil {
+			logger.ErrorContext(ctx, "Failed to store thumbnail", "error", err)
+			deleteAttachmentFiles(s.store, *attachment)
+			return nil, errors.New("保存文件失败")
+		}
+		attachment.ThumbnailURL = s.store.URL(attachment.ThumbnailKey)
+	}
+
+	if err := db.Create(attachment).Error; err != nil {
+		logger.ErrorContext(ctx, "Failed to create attachment", "error", err)
+		deleteAttachmentFiles(s.store, *attachment)
+		return nil, errors.New("保存附件信息失败")
+	}
+
+	return attachment, nil
+}
+
+func (s *UploadService) DeleteAttachment(ctx context.Context, attachmentID, userID uint) error {
+	ctx, span := tracing.Start(ctx, "UploadService.DeleteAttachment")
+	defer span.End()
+
+	db := s.db.WithContext(ctx)
+	var attachment models.Attachment
+	if err := db.First(&attachment, attachmentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("附件不存在")
+		}
+		logger.ErrorContext(ctx, "Database error", "error", err)
+		return errors.New("获取附件失败")
+	}
+
+	if attachment.UserID != userID {
+		return errors.New("无权限删除此附件")
+	}
+
+	if err := db.Delete(&attachment).Error; err != nil {
+		logger.ErrorContext(ctx, "Failed to delete attachment", "error", err)
+		return errors.New("删除附件失败")
+	}
+
+	deleteAttachmentFiles(s.store, attachment)
+	return nil
+}
+
+// deleteAttachmentFiles 删除附件在存储中的文件，失败时只记录日志
+func deleteAttachmentFiles(store storage.Storage, attachments ...models.Attachment) {
+	for _, attachment := range attachments {
+		for _, key := range []string{attachment.Key, attachment.ThumbnailKey} {
+			if key == "" {
+				continue
+			}
+			if err := store.Delete(context.Background(), key); err != nil {
+				logger.Error("Failed to delete stored file", "key", key, "error", err)
+			}
+		}
+	}
+}
+
+func newUploadKey() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return time.Now().UTC().Format("2006/01") + "/" + hex.EncodeToString(b)
+}
+
+// cleanFilename 只保留原文件名的最后一段，用于展示
+func cleanFilename(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == "/" {
+		name = "file"
+	}
+	for len(name) > 255 {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
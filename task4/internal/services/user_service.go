@@ -2,33 +2,38 @@ package services
 
 import (
 	"blog-system/internal/models"
+	"blog-system/internal/repository"
 	"blog-system/pkg/auth"
-	"blog-system/pkg/database"
 	"blog-system/pkg/logger"
+	"blog-system/pkg/tracing"
+	"context"
 	"errors"
-
-	"gorm.io/gorm"
 )
 
-type UserService struct{}
+type UserService struct {
+	users  repository.UserRepository
+	tokens *auth.TokenIssuer
+}
 
-func NewUserService() *UserService {
-	return &UserService{}
+func NewUserService(users repository.UserRepository, tokens *auth.TokenIssuer) *UserService {
+	return &UserService{users: users, tokens: tokens}
 }
 
-func (s *UserService) Register(req *models.UserCreateRequest) (*models.UserResponse, error) {
-	var existingUser models.User
-	if err := database.DB.Where("username = ?", req.Username).First(&existingUser).Error; err == nil {
+func (s *UserService) Register(ctx context.Context, req *models.UserCreateRequest) (*models.UserResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserService.Register")
+	defer span.End()
+
+	if _, err := s.users.FindByUsername(ctx, req.Username); err == nil {
 		return nil, errors.New("用户名已存在")
 	}
 
-	if err := database.DB.Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
+	if _, err := s.users.FindByEmail(ctx, req.Email); err == nil {
 		return nil, errors.New("邮箱已存在")
 	}
 
 	hashedPassword, err := auth.HashPassword(req.Password)
 	if err != nil {
-		logger.Error("Failed to hash password:", err)
+		logger.ErrorContext(ctx, "Failed to hash password", "error", err)
 		return nil, errors.New("密码加密失败")
 	}
 
@@ -38,8 +43,8 @@ func (s *UserService) Register(req *models.UserCreateRequest) (*models.UserRespo
 		Email:    req.Email,
 	}
 
-	if err := database.DB.Create(user).Error; err != nil {
-		logger.Error("Failed to create user:", err)
+	if err := s.users.Create(ctx, user); err != nil {
+		logger.ErrorContext(ctx, "Failed to create user", "error", err)
 		return nil, errors.New("用户创建失败")
 	}
 
@@ -47,13 +52,16 @@ func (s *UserService) Register(req *models.UserCreateRequest) (*models.UserRespo
 	return &response, nil
 }
 
-func (s *UserService) Login(req *models.UserLoginRequest) (*models.UserResponse, string, error) {
-	var user models.User
-	if err := database.DB.Where("username = ?", req.Username).First(&user).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
+func (s *UserService) Login(ctx context.Context, req *models.UserLoginRequest) (*models.UserResponse, string, error) {
+	ctx, span := tracing.Start(ctx, "UserService.Login")
+	defer span.End()
+
+	user, err := s.users.FindByUsername(ctx, req.Username)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
 			return nil, "", errors.New("用户名或密码错误")
 		}
-		logger.Error("Database error during login:", err)
+		logger.ErrorContext(ctx, "Database error during login", "error", err)
 		return nil, "", errors.New("登录失败")
 	}
 
@@ -61,9 +69,9 @@ func (s *UserService) Login(req *models.UserLoginRequest) (*models.UserResponse,
 		return nil, "", errors.New("用户名或密码错误")
 	}
 
-	token, err := auth.GenerateToken(user.ID, user.Username, "ktjnCkMI6GgMN3w6Nein+BFSl7YThGzlmwuomDSvkzo=")
+	token, err := s.tokens.Issue(user.ID, user.Username)
 	if err != nil {
-		logger.Error("Failed to generate token:", err)
+		logger.ErrorContext(ctx, "Failed to generate token", "error", err)
 		return nil, "", errors.New("令牌生成失败")
 	}
 
@@ -71,16 +79,51 @@ func (s *UserService) Login(req *models.UserLoginRequest) (*models.UserResponse,
 	return &response, token, nil
 }
 
-func (s *UserService) GetUserByID(id uint) (*models.UserResponse, error) {
-	var user models.User
-	if err := database.DB.First(&user, id).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
+func (s *UserService) GetUserByID(ctx context.Context, id uint) (*models.UserResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUserByID")
+	defer span.End()
+
+	user, err := s.users.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
 			return nil, errors.New("用户不存在")
 		}
-		logger.Error("Database error:", err)
+		logger.ErrorContext(ctx, "Database error", "error", err)
 		return nil, errors.New("获取用户信息失败")
 	}
 
 	response := user.ToResponse()
 	return &response, nil
 }
+
+func (s *UserService) Follow(ctx context.Context, followerID, followeeID uint) error {
+	ctx, span := tracing.Start(ctx, "UserService.Follow")
+	defer span.End()
+
+	if followerID == followeeID {
+		return errors.New("不能关注自己")
+	}
+
+	if _, err := s.GetUserByID(ctx, followeeID); err != nil {
+		return err
+	}
+
+	if err := s.users.Follow(ctx, followerID, followeeID); err != nil {
+		logger.ErrorContext(ctx, "Failed to follow user", "error", err)
+		return errors.New("关注失败")
+	}
+
+	return nil
+}
+
+func (s *UserService) Unfollow(ctx context.Context, followerID, followeeID uint) error {
+	ctx, span := tracing.Start(ctx, "UserService.Unfollow")
+	defer span.End()
+
+	if err := s.users.Unfollow(ctx, followerID, followeeID); err != nil {
+		logger.ErrorContext(ctx, "Failed to unfollow user", "error", err)
+		return errors.New("取消关注失败")
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+// Package sitemap 按 sitemaps.org 协议生成站点地图和站点地图索引
+package sitemap
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+// MaxURLs 是协议规定的单个站点地图文件的URL上限
+const MaxURLs = 50000
+
+const namespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+type URL struct {
+	Loc     string
+	LastMod time.Time
+}
+
+type urlSet struct {
+	XMLName xml.Name   `xml:"urlset"`
+	XMLNS   string     `xml:"xmlns,attr"`
+	URLs    []urlEntry `xml:"url"`
+}
+
+type urlEntry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name   `xml:"sitemapindex"`
+	XMLNS    string     `xml:"xmlns,attr"`
+	Sitemaps []urlEntry `xml:"sitemap"`
+}
+
+func formatLastMod(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func RenderURLSet(urls []URL) ([]byte, error) {
+	doc := urlSet{XMLNS: namespace, URLs: make([]urlEntry, len(urls))}
+	for i, u := range urls {
+		doc.URLs[i] = urlEntry{Loc: u.Loc, LastMod: formatLastMod(u.LastMod)}
+	}
+	return marshal(doc)
+}
+
+// RenderIndex 生成站点地图索引，sitemaps 中的 LastMod 为各文件内最新的修改时间
+func RenderIndex(sitemaps []URL) ([]byte, error) {
+	doc := sitemapIndex{XMLNS: namespace, Sitemaps: make([]urlEntry, len(sitemaps))}
+	for i, u := range sitemaps {
+		doc.Sitemaps[i] = urlEntry{Loc: u.Loc, LastMod: formatLastMod(u.LastMod)}
+	}
+	return marshal(doc)
+}
+
+func marshal(v any) ([]byte, error) {
+	body, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
@@ -0,0 +1,96 @@
+package storage_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"blog-system/internal/storage"
+)
+
+func newS3(t *testing.T, endpoint, secretKey, publicURL string) *storage.S3 {
+	t.Helper()
+	s3, err := storage.NewS3(storage.S3Config{
+		Endpoint:  endpoint,
+		Region:    "us-east-1",
+		Bucket:    "blog",
+		AccessKey: "access",
+		SecretKey: secretKey,
+		PathStyle: true,
+		PublicURL: publicURL,
+	})
+	if err != nil {
+		t.Fatalf("NewS3: %v", err)
+	}
+	return s3
+}
+
+func TestS3PutURLDelete(t *testing.T) {
+	fake := storage.NewFakeS3("us-east-1", "access", "secret")
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	s3 := newS3(t, server.URL, "secret", "")
+	ctx := context.Background()
+	key := "2024/01/a b+c.png"
+
+	if err := s3.Put(ctx, key, []byte("image"), "image/png"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	data, ok := fake.Object("blog/" + key)
+	if !ok || string(data) != "image" {
+		t.Fatalf("stored object = %q, %v; want %q", data, ok, "image")
+	}
+
+	// 没有 PublicURL 时使用存储桶地址，文件可以通过它直接下载
+	url := s3.URL(key)
+	if want := server.URL + "/blog/2024/01/a%20b%2Bc.png"; url != want {
+		t.Errorf("URL = %q, want %q", url, want)
+	}
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "image" {
+		t.Errorf("GET %s = %d %q", url, resp.StatusCode, body)
+	}
+	if got := resp.Header.Get("Content-Type"); got != "image/png" {
+		t.Errorf("Content-Type = %q, want image/png", got)
+	}
+
+	if err := s3.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if fake.Len() != 0 {
+		t.Errorf("%d objects left after Delete", fake.Len())
+	}
+	// 删除不存在的文件不算错误
+	if err := s3.Delete(ctx, key); err != nil {
+		t.Errorf("Delete missing key: %v", err)
+	}
+}
+
+func TestS3PublicURL(t *testing.T) {
+	s3 := newS3(t, "http://localhost:9000", "secret", "https://cdn.example.com/")
+	if got, want := s3.URL("a/b.png"), "https://cdn.example.com/a/b.png"; got != want {
+		t.Errorf("URL = %q, want %q", got, want)
+	}
+}
+
+func TestS3RejectsBadSignature(t *testing.T) {
+	fake := storage.NewFakeS3("us-east-1", "access", "secret")
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	s3 := newS3(t, server.URL, "wrong", "")
+	if err := s3.Put(context.Background(), "a.png", []byte("image"), "image/png"); err == nil {
+		t.Fatal("Put with a wrong secret key succeeded")
+	}
+	if fake.Len() != 0 {
+		t.Errorf("object stored despite a bad signature")
+	}
+}
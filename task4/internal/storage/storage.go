@@ -0,0 +1,18 @@
+// Package storage 保存上传的文件，提供本地文件系统和 S3 兼容两种实现
+package storage
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrNotFound = errors.New("文件不存在")
+
+type Storage interface {
+	// Put 保存文件，key 已存在时覆盖
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Delete 删除文件，文件不存在时不返回错误
+	Delete(ctx context.Context, key string) error
+	// URL 返回文件的公开访问地址
+	URL(key string) string
+}
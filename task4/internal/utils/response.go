@@ -1,11 +1,15 @@
 package utils
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
+// statusClientClosedRequest 表示客户端在响应之前断开了连接，只用于日志
+const statusClientClosedRequest = 499
+
 type Response struct {
 	Code    int         `json:"code"`
 	Message string      `json:"message"`
@@ -28,7 +32,17 @@ func SuccessWithMessage(c *gin.Context, message string, data interface{}) {
 	})
 }
 
+// Error 返回错误响应。请求超时或客户端断开后，服务层的错误来自被取消的数据库查询，
+// 分别返回 503 和只记录状态码
 func Error(c *gin.Context, code int, message string) {
+	switch c.Request.Context().Err() {
+	case context.DeadlineExceeded:
+		code, message = http.StatusServiceUnavailable, "请求处理超时，请稍后重试"
+	case context.Canceled:
+		c.AbortWithStatus(statusClientClosedRequest)
+		return
+	}
+
 	c.JSON(code, Response{
 		Code:    code,
 		Message: message,
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"time"
 
+	"blog-system/pkg/clock"
+
 	"github.com/golang-jwt/jwt/v5"
 )
 
@@ -13,28 +15,43 @@ type Claims struct {
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(userID uint, username, secret string) (string, error) {
+// TokenIssuer 用同一个密钥签发和校验令牌
+type TokenIssuer struct {
+	secret []byte
+	ttl    time.Duration
+	clock  clock.Clock
+}
+
+func NewTokenIssuer(secret string, ttl time.Duration, clk clock.Clock) *TokenIssuer {
+	if ttl <= 0 {
+		ttl = 168 * time.Hour
+	}
+	return &TokenIssuer{secret: []byte(secret), ttl: ttl, clock: clk}
+}
+
+func (i *TokenIssuer) Issue(userID uint, username string) (string, error) {
+	now := i.clock.Now()
 	claims := Claims{
 		UserID:   userID,
 		Username: username,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(168 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(now.Add(i.ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
 		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
+	return token.SignedString(i.secret)
 }
 
-func ValidateToken(tokenString, secret string) (*Claims, error) {
+func (i *TokenIssuer) Validate(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("unexpected signing method")
 		}
-		return []byte(secret), nil
-	})
+		return i.secret, nil
+	}, jwt.WithTimeFunc(i.clock.Now))
 
 	if err != nil {
 		return nil, err
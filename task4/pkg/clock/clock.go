@@ -0,0 +1,52 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock 提供当前时间，服务通过它取时间，测试时可以换成 Fixed
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// Real 返回系统时钟
+func Real() Clock {
+	return realClock{}
+}
+
+// Fixed 是手动拨动的时钟
+type Fixed struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func NewFixed(now time.Time) *Fixed {
+	return &Fixed{now: now}
+}
+
+func (c *Fixed) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Set 把时钟拨到 now
+func (c *Fixed) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+// Advance 把时钟向后拨 d
+func (c *Fixed) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
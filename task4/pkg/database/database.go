@@ -1,73 +1,248 @@
 package database
 
 import (
+	"context"
+	"embed"
+	"errors"
 	"fmt"
-	"log"
+	"io/fs"
+	"net"
+	"os"
+	"strconv"
+	"time"
 
 	"blog-system/config"
-	"blog-system/internal/models"
+	"blog-system/pkg/logger"
+	"blog-system/pkg/migrate"
+	"blog-system/pkg/tracing"
 
+	"github.com/glebarez/sqlite"
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
-var DB *gorm.DB
+const (
+	DriverPostgres = "postgres"
+	DriverMySQL    = "mysql"
+	DriverSQLite   = "sqlite"
+)
+
+const (
+	// dialTimeout 是每次尝试建立连接的超时时间
+	dialTimeout = 5 * time.Second
+	// 启动时连接失败后的重试间隔从 initialRetryDelay 开始加倍，最长为 maxRetryDelay
+	initialRetryDelay = 500 * time.Millisecond
+	maxRetryDelay     = 5 * time.Second
+)
+
+// 每种数据库的迁移文件放在以驱动命名的子目录中，版本号保持一致
+//
+//go:embed migrations/*/*.sql
+var migrationFiles embed.FS
+
+// dialector 按 DB_DRIVER 生成 GORM 使用的数据库方言
+func dialector(cfg config.DatabaseConfig) (gorm.Dialector, error) {
+	switch cfg.Driver {
+	case DriverPostgres, "":
+		dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s TimeZone=%s connect_timeout=%d statement_timeout=%d",
+			cfg.Host,
+			cfg.Port,
+			cfg.User,
+			cfg.Password,
+			cfg.Name,
+			cfg.SSLMode,
+			cfg.TimeZone,
+			int(dialTimeout.Seconds()),
+			cfg.StatementTimeout.Milliseconds(),
+		)
+		return postgres.Open(dsn), nil
+
+	case DriverMySQL:
+		loc, err := time.LoadLocation(cfg.TimeZone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid database time zone %q: %w", cfg.TimeZone, err)
+		}
+
+		dsn := mysqldriver.NewConfig()
+		dsn.User = cfg.User
+		dsn.Passwd = cfg.Password
+		dsn.Net = "tcp"
+		dsn.Addr = net.JoinHostPort(cfg.Host, cfg.Port)
+		dsn.DBName = cfg.Name
+		dsn.ParseTime = true
+		dsn.Loc = loc
+		// 迁移文件包含多条语句，需要一次执行
+		dsn.MultiStatements = true
+		dsn.Timeout = dialTimeout
+		// max_execution_time 只限制只读的 SELECT 语句，单位为毫秒
+		dsn.Params = map[string]string{
+			"charset":            "utf8mb4",
+			"max_execution_time": strconv.FormatInt(cfg.StatementTimeout.Milliseconds(), 10),
+		}
+		return mysql.Open(dsn.FormatDSN()), nil
+
+	case DriverSQLite:
+		// 写事务一开始就加锁，避免两个读事务同时升级为写事务时直接返回 SQLITE_BUSY，
+		// busy_timeout 让并发写入排队等待。不能加 _time_format 参数，驱动解析到它之后会忽略 _txlock
+		dsn := cfg.Path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate"
+		return sqlite.Open(dsn), nil
+	}
+
+	return nil, fmt.Errorf("unsupported database driver %q, expected postgres, mysql or sqlite", cfg.Driver)
+}
 
-func Connect(cfg *config.Config) error {
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable TimeZone=America/Toronto",
-		cfg.Database.Host,
-		cfg.Database.Port,
-		cfg.Database.User,
-		cfg.Database.Password,
-		cfg.Database.Name,
-	)
+// Open 按 DB_DRIVER 连接数据库并确认连接可用。数据库暂时不可用时（例如与数据库容器同时启动）
+// 按退避间隔重试，超过 DB_CONNECT_TIMEOUT 后返回最后一次的错误
+func Open(cfg *config.Config) (*gorm.DB, error) {
+	deadline := time.Now().Add(cfg.Database.ConnectTimeout)
+	delay := initialRetryDelay
+
+	for attempt := 1; ; attempt++ {
+		db, err := open(cfg.Database)
+		if err == nil {
+			logger.Info("Database connected successfully", "driver", db.Dialector.Name())
+			if err := db.Use(tracing.NewGormPlugin()); err != nil {
+				Close(db)
+				return nil, fmt.Errorf("failed to set up query tracing: %w", err)
+			}
+			if len(cfg.Database.Replicas) > 0 {
+				if err := db.Use(newReplicas(cfg.Database)); err != nil {
+					Close(db)
+					return nil, fmt.Errorf("failed to set up read replicas: %w", err)
+				}
+			}
+			return db, nil
+		}
+		var configErr *configError
+		if errors.As(err, &configErr) {
+			return nil, err
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil, fmt.Errorf("database not available after %d attempts: %w", attempt, err)
+		}
+		delay = min(delay, remaining)
+
+		logger.Warn("Database not available, retrying", "attempt", attempt, "delay", delay, "error", err)
+		time.Sleep(delay)
+		delay = min(delay*2, maxRetryDelay)
+	}
+}
+
+// configError 是配置错误，重试也不会成功
+type configError struct {
+	err error
+}
+
+func (e *configError) Error() string { return e.err.Error() }
+
+func (e *configError) Unwrap() error { return e.err }
+
+func open(cfg config.DatabaseConfig) (*gorm.DB, error) {
+	dialect, err := dialector(cfg)
+	if err != nil {
+		return nil, &configError{err}
+	}
 
-	var err error
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+	// 连接在下面确认，gorm.Open 只负责初始化
+	db, err := gorm.Open(dialect, &gorm.Config{
+		Logger:               logger.NewGorm(logger.Default(), cfg.SlowQueryThreshold),
+		DisableAutomaticPing: true,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to connect to database: %w", err)
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	sqlDB, err := DB.DB()
+	sqlDB, err := db.DB()
 	if err != nil {
-		return fmt.Errorf("failed to get database instance: %w", err)
+		return nil, fmt.Errorf("failed to get database instance: %w", err)
 	}
 
-	if err := sqlDB.Ping(); err != nil {
-		return fmt.Errorf("failed to ping database: %w", err)
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	if cfg.Driver == DriverSQLite {
+		db.ConnPool = &utcConnPool{db.ConnPool}
+		db.Statement.ConnPool = db.ConnPool
+		// 内存数据库只存在于创建它的连接中，连接也不能因为空闲或到期被关闭
+		if cfg.Path == ":memory:" {
+			sqlDB.SetMaxOpenConns(1)
+			sqlDB.SetMaxIdleConns(1)
+			sqlDB.SetConnMaxLifetime(0)
+			sqlDB.SetConnMaxIdleTime(0)
+		}
 	}
 
-	log.Println("Database connected successfully")
-	return nil
+	ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+	defer cancel()
+	if err := sqlDB.PingContext(ctx); err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+	return db, nil
 }
 
-func Migrate() error {
-	if DB == nil {
-		return fmt.Errorf("database not connected")
+// NewMigrator 返回执行内嵌迁移文件的迁移器，按连接的数据库选择对应目录中的迁移文件。
+// 引入迁移之前由 AutoMigrate 创建的数据库先由 prepareLegacySchema 补全到初始迁移的结构
+func NewMigrator(db *gorm.DB) (*migrate.Runner, error) {
+	files, err := fs.Sub(migrationFiles, "migrations/"+db.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	runner, err := migrate.NewRunner(db, files)
+	if err != nil {
+		return nil, err
+	}
+	runner.Out = os.Stdout
+	runner.Prepare = prepareLegacySchema
+	return runner, nil
+}
+
+// Migrate 执行全部未执行的迁移，多个实例同时调用时由数据库锁保证只执行一次
+func Migrate(db *gorm.DB) error {
+	runner, err := NewMigrator(db)
+	if err != nil {
+		return err
 	}
 
-	err := DB.AutoMigrate(
-		&models.User{},
-		&models.Post{},
-		&models.Comment{},
-	)
+	applied, err := runner.Up()
 	if err != nil {
 		return fmt.Errorf("failed to migrate database: %w", err)
 	}
 
-	log.Println("Database migration completed successfully")
+	logger.Info("Database migration completed successfully", "applied", applied)
+	return nil
+}
+
+// CheckMigrations 在数据库还有未执行的迁移时返回错误
+func CheckMigrations(db *gorm.DB) error {
+	runner, err := NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	pending, err := runner.Pending()
+	if err != nil {
+		return fmt.Errorf("failed to check migrations: %w", err)
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%d pending migrations, starting with %s; run \"migrate up\" first", len(pending), pending[0])
+	}
 	return nil
 }
 
-func Close() error {
-	if DB == nil {
-		return nil
+// Close 关闭数据库连接，配置了只读副本时一并关闭
+func Close(db *gorm.DB) error {
+	if replicas := ReplicasOf(db); replicas != nil {
+		replicas.close()
 	}
 
-	sqlDB, err := DB.DB()
+	sqlDB, err := db.DB()
 	if err != nil {
 		return err
 	}
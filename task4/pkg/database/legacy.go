@@ -0,0 +1,233 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"blog-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// prepareLegacySchema 接管引入版本化迁移之前由 AutoMigrate 创建的数据库。
+// 这些数据库可能停留在之前的任何版本，初始迁移中的 CREATE TABLE IF NOT EXISTS 会跳过已有的表，
+// 不会补上后来增加的列，所以先按初始迁移时的结构补全已有表的列和索引，再补全评论路径。
+// 缺少的表由初始迁移创建，之后的迁移照常执行。
+// 没有 users 表的是新数据库，不需要处理
+func prepareLegacySchema(db *gorm.DB) error {
+	if !db.Migrator().HasTable("users") {
+		return nil
+	}
+
+	for _, model := range initialSchema {
+		if !db.Migrator().HasTable(model) {
+			continue
+		}
+		if err := db.AutoMigrate(model); err != nil {
+			return fmt.Errorf("upgrade legacy table %T: %w", model, err)
+		}
+	}
+
+	if err := backfillCommentPaths(db); err != nil {
+		return fmt.Errorf("backfill comment paths: %w", err)
+	}
+	return nil
+}
+
+// initialSchema 是初始迁移 0001_initial_schema 对应的模型结构。
+// 这里的结构不能随 internal/models 修改，之后增加的列由对应的迁移添加
+var initialSchema = []any{
+	&initialUser{},
+	&initialPost{},
+	&initialTag{},
+	&initialComment{},
+	&initialCommentRevision{},
+	&initialCommentBan{},
+	&initialFollow{},
+	&initialNotification{},
+	&initialNotificationPreference{},
+	&initialReaction{},
+	&initialReadingList{},
+	&initialBookmark{},
+	&initialPostDailyView{},
+	&initialPostReferrerView{},
+	&initialAttachment{},
+}
+
+type initialUser struct {
+	ID        uint   `gorm:"primaryKey"`
+	Username  string `gorm:"unique;not null;size:50"`
+	Password  string `gorm:"not null;size:255"`
+	Email     string `gorm:"unique;not null;size:100"`
+	Role      string `gorm:"not null;size:20;default:user"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+type initialPost struct {
+	ID              uint   `gorm:"primaryKey"`
+	Title           string `gorm:"not null;size:200"`
+	Content         string `gorm:"not null;type:text"`
+	UserID          uint   `gorm:"not null"`
+	Status          string `gorm:"not null;size:20;default:published;index"`
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	DeletedAt       gorm.DeletedAt `gorm:"index"`
+	CommentCount    int64          `gorm:"not null;default:0"`
+	LastCommentedAt *time.Time
+	ViewCount       int64  `gorm:"not null;default:0"`
+	CommentsLocked  bool   `gorm:"not null;default:false"`
+	CommentPolicy   string `gorm:"not null;size:20;default:everyone"`
+}
+
+type initialTag struct {
+	ID        uint   `gorm:"primaryKey"`
+	Name      string `gorm:"not null;size:50"`
+	Slug      string `gorm:"uniqueIndex;not null;size:50"`
+	CreatedAt time.Time
+}
+
+type initialComment struct {
+	ID               uint   `gorm:"primaryKey"`
+	Content          string `gorm:"not null;type:text"`
+	UserID           uint   `gorm:"not null"`
+	PostID           uint   `gorm:"not null;index"`
+	ParentID         *uint  `gorm:"index"`
+	Depth            int    `gorm:"not null;default:0"`
+	Path             string `gorm:"not null;size:255;default:'';index"`
+	IsDeleted        bool   `gorm:"not null;default:false"`
+	EditedAt         *time.Time
+	Status           string `gorm:"not null;size:20;default:approved;index"`
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	DeletedAt        gorm.DeletedAt `gorm:"index"`
+	ModerationReason string         `gorm:"size:255"`
+}
+
+type initialCommentRevision struct {
+	ID        uint   `gorm:"primaryKey"`
+	CommentID uint   `gorm:"not null;index"`
+	Content   string `gorm:"not null;type:text"`
+	EditorID  uint   `gorm:"not null"`
+	CreatedAt time.Time
+}
+
+type initialCommentBan struct {
+	ID        uint   `gorm:"primaryKey"`
+	UserID    uint   `gorm:"not null;uniqueIndex:idx_comment_bans_user_owner"`
+	OwnerID   uint   `gorm:"not null;default:0;uniqueIndex:idx_comment_bans_user_owner"`
+	BannedBy  uint   `gorm:"not null"`
+	Reason    string `gorm:"size:255"`
+	CreatedAt time.Time
+}
+
+type initialFollow struct {
+	FollowerID uint `gorm:"primaryKey"`
+	FolloweeID uint `gorm:"primaryKey;index"`
+	CreatedAt  time.Time
+}
+
+type initialNotification struct {
+	ID        uint   `gorm:"primaryKey"`
+	UserID    uint   `gorm:"not null;index"`
+	ActorID   uint   `gorm:"not null"`
+	Type      string `gorm:"not null;size:20"`
+	PostID    uint   `gorm:"not null"`
+	CommentID *uint
+	ReadAt    *time.Time `gorm:"index"`
+	CreatedAt time.Time
+}
+
+type initialNotificationPreference struct {
+	UserID    uint   `gorm:"primaryKey"`
+	Type      string `gorm:"primaryKey;size:20"`
+	Enabled   bool   `gorm:"not null"`
+	UpdatedAt time.Time
+}
+
+type initialReaction struct {
+	ID         uint      `gorm:"primaryKey"`
+	UserID     uint      `gorm:"not null;uniqueIndex:idx_reactions_unique"`
+	TargetType string    `gorm:"not null;size:20;uniqueIndex:idx_reactions_unique;index:idx_reactions_target"`
+	TargetID   uint      `gorm:"not null;uniqueIndex:idx_reactions_unique;index:idx_reactions_target"`
+	Type       string    `gorm:"not null;size:20;uniqueIndex:idx_reactions_unique"`
+	CreatedAt  time.Time `gorm:"index"`
+}
+
+type initialReadingList struct {
+	ID        uint   `gorm:"primaryKey"`
+	UserID    uint   `gorm:"not null;uniqueIndex:idx_reading_lists_user_name"`
+	Name      string `gorm:"not null;size:100;uniqueIndex:idx_reading_lists_user_name"`
+	IsPublic  bool   `gorm:"not null;default:false"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type initialBookmark struct {
+	ID        uint  `gorm:"primaryKey"`
+	UserID    uint  `gorm:"not null;index"`
+	ListID    uint  `gorm:"not null;uniqueIndex:idx_bookmarks_list_post"`
+	PostID    uint  `gorm:"not null;uniqueIndex:idx_bookmarks_list_post;index"`
+	Position  int64 `gorm:"not null;default:0"`
+	CreatedAt time.Time
+}
+
+type initialPostDailyView struct {
+	PostID uint      `gorm:"primaryKey"`
+	Day    time.Time `gorm:"primaryKey;type:date;index"`
+	Views  int64     `gorm:"not null;default:0"`
+}
+
+type initialPostReferrerView struct {
+	PostID   uint      `gorm:"primaryKey"`
+	Day      time.Time `gorm:"primaryKey;type:date;index"`
+	Referrer string    `gorm:"primaryKey;size:255"`
+	Views    int64     `gorm:"not null;default:0"`
+}
+
+type initialAttachment struct {
+	ID           uint   `gorm:"primaryKey"`
+	UserID       uint   `gorm:"not null;index"`
+	PostID       *uint  `gorm:"index"`
+	Filename     string `gorm:"not null;size:255"`
+	ContentType  string `gorm:"not null;size:100"`
+	Size         int64  `gorm:"not null"`
+	Width        int
+	Height       int
+	Key          string    `gorm:"not null;size:255;uniqueIndex"`
+	URL          string    `gorm:"not null;size:500"`
+	ThumbnailKey string    `gorm:"size:255"`
+	ThumbnailURL string    `gorm:"size:500"`
+	CreatedAt    time.Time `gorm:"index"`
+}
+
+func (initialUser) TableName() string                   { return "users" }
+func (initialPost) TableName() string                   { return "posts" }
+func (initialTag) TableName() string                    { return "tags" }
+func (initialComment) TableName() string                { return "comments" }
+func (initialCommentRevision) TableName() string        { return "comment_revisions" }
+func (initialCommentBan) TableName() string             { return "comment_bans" }
+func (initialFollow) TableName() string                 { return "follows" }
+func (initialNotification) TableName() string           { return "notifications" }
+func (initialNotificationPreference) TableName() string { return "notification_preferences" }
+func (initialReaction) TableName() string               { return "reactions" }
+func (initialReadingList) TableName() string            { return "reading_lists" }
+func (initialBookmark) TableName() string               { return "bookmarks" }
+func (initialPostDailyView) TableName() string          { return "post_daily_views" }
+func (initialPostReferrerView) TableName() string       { return "post_referrer_views" }
+func (initialAttachment) TableName() string             { return "attachments" }
+
+// backfillCommentPaths 为引入楼中楼之前的评论补全路径，它们都是顶层评论
+func backfillCommentPaths(db *gorm.DB) error {
+	var comments []models.Comment
+	return db.Unscoped().Where("path = ?", "").FindInBatches(&comments, 500, func(tx *gorm.DB, batch int) error {
+		for i := range comments {
+			comments[i].BuildPath(nil)
+			if err := db.Unscoped().Model(&comments[i]).UpdateColumn("path", comments[i].Path).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+}
@@ -0,0 +1,208 @@
+package database
+
+import (
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"blog-system/config"
+	"blog-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// 最早的版本由 AutoMigrate 创建的三张表
+type baselineUser struct {
+	ID        uint   `gorm:"primaryKey"`
+	Username  string `gorm:"unique;not null;size:50"`
+	Password  string `gorm:"not null;size:255"`
+	Email     string `gorm:"unique;not null;size:100"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+type baselinePost struct {
+	ID        uint   `gorm:"primaryKey"`
+	Title     string `gorm:"not null;size:200"`
+	Content   string `gorm:"not null;type:text"`
+	UserID    uint   `gorm:"not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+type baselineComment struct {
+	ID        uint   `gorm:"primaryKey"`
+	Content   string `gorm:"not null;type:text"`
+	UserID    uint   `gorm:"not null"`
+	PostID    uint   `gorm:"not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+func (baselineUser) TableName() string    { return "users" }
+func (baselinePost) TableName() string    { return "posts" }
+func (baselineComment) TableName() string { return "comments" }
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	cfg, _, err := config.Load(nil)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	cfg.Database = config.DatabaseConfig{
+		Driver: DriverSQLite,
+		Path:   filepath.Join(t.TempDir(), "blog_test.db"),
+	}
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { Close(db) })
+	return db
+}
+
+func migrateTestDB(t *testing.T, db *gorm.DB) int {
+	t.Helper()
+
+	runner, err := NewMigrator(db)
+	if err != nil {
+		t.Fatalf("create migrator: %v", err)
+	}
+	runner.Out = io.Discard
+	applied, err := runner.Up()
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return applied
+}
+
+func TestMigrateUpgradesBaselineSchema(t *testing.T) {
+	db := openTestDB(t)
+
+	if err := db.AutoMigrate(&baselineUser{}, &baselinePost{}, &baselineComment{}); err != nil {
+		t.Fatalf("create baseline schema: %v", err)
+	}
+	user := &baselineUser{Username: "alice", Password: "x", Email: "alice@example.com"}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	post := &baselinePost{Title: "title", Content: "content", UserID: user.ID}
+	if err := db.Create(post).Error; err != nil {
+		t.Fatal(err)
+	}
+	comment := &baselineComment{Content: "comment", UserID: user.ID, PostID: post.ID}
+	if err := db.Create(comment).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	migrateTestDB(t, db)
+	expectApplied(t, db)
+
+	for _, column := range []struct {
+		model any
+		name  string
+	}{
+		{&models.User{}, "role"},
+		{&models.Post{}, "status"},
+		{&models.Post{}, "comment_count"},
+		{&models.Post{}, "last_commented_at"},
+		{&models.Post{}, "comments_locked"},
+		{&models.Post{}, "comment_policy"},
+		{&models.Comment{}, "path"},
+		{&models.Comment{}, "depth"},
+		{&models.Comment{}, "parent_id"},
+		{&models.Comment{}, "status"},
+		{&models.Attachment{}, "detached_at"},
+	} {
+		if !db.Migrator().HasColumn(column.model, column.name) {
+			t.Errorf("column %s was not added", column.name)
+		}
+	}
+	if !db.Migrator().HasTable(&models.Bookmark{}) {
+		t.Error("bookmarks table was not created")
+	}
+
+	var got models.Comment
+	if err := db.First(&got, comment.ID).Error; err != nil {
+		t.Fatalf("load comment: %v", err)
+	}
+	if want := "0000000001"; got.Path != want {
+		t.Errorf("comment path = %q, want %q", got.Path, want)
+	}
+	var upgraded models.Post
+	if err := db.First(&upgraded, post.ID).Error; err != nil {
+		t.Fatalf("load post: %v", err)
+	}
+	if upgraded.Status != models.PostStatusPublished {
+		t.Errorf("post status = %q, want %q", upgraded.Status, models.PostStatusPublished)
+	}
+
+	if applied := migrateTestDB(t, db); applied != 0 {
+		t.Errorf("second run applied %d migrations, want 0", applied)
+	}
+}
+
+// 引入迁移之前的最后一个版本已经有全部的表，之后的迁移要在这些表上照常执行
+func TestMigrateRunsLaterMigrationsOnLegacySchema(t *testing.T) {
+	db := openTestDB(t)
+
+	for _, model := range initialSchema {
+		if err := db.AutoMigrate(model); err != nil {
+			t.Fatalf("create legacy schema: %v", err)
+		}
+	}
+	attachment := &initialAttachment{UserID: 1, Filename: "a.png", ContentType: "image/png", Size: 1, Key: "a.png", URL: "/a.png"}
+	if err := db.Create(attachment).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	migrateTestDB(t, db)
+	expectApplied(t, db)
+
+	// detached_at 不在初始结构中，只能由 0002 添加
+	if !db.Migrator().HasColumn(&models.Attachment{}, "detached_at") {
+		t.Error("attachments.detached_at was not added by the later migration")
+	}
+	var got models.Attachment
+	if err := db.First(&got, attachment.ID).Error; err != nil {
+		t.Fatalf("load attachment: %v", err)
+	}
+	if got.DetachedAt != nil {
+		t.Errorf("detached_at = %v, want NULL", got.DetachedAt)
+	}
+}
+
+// expectApplied 检查全部迁移都记录为已执行
+func expectApplied(t *testing.T, db *gorm.DB) {
+	t.Helper()
+
+	runner, err := NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses, err := runner.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			t.Errorf("migration %s was not applied", status.Migration)
+		}
+	}
+}
+
+func TestMigrateNewDatabaseRunsMigrations(t *testing.T) {
+	db := openTestDB(t)
+
+	if applied := migrateTestDB(t, db); applied == 0 {
+		t.Fatal("no migrations applied to a new database")
+	}
+	if !db.Migrator().HasColumn(&models.Attachment{}, "detached_at") {
+		t.Error("attachments.detached_at is missing")
+	}
+}
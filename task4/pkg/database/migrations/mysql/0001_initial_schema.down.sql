@@ -0,0 +1,17 @@
+-- 删除全部业务表
+DROP TABLE IF EXISTS `attachments`;
+DROP TABLE IF EXISTS `post_referrer_views`;
+DROP TABLE IF EXISTS `post_daily_views`;
+DROP TABLE IF EXISTS `bookmarks`;
+DROP TABLE IF EXISTS `reading_lists`;
+DROP TABLE IF EXISTS `reactions`;
+DROP TABLE IF EXISTS `notification_preferences`;
+DROP TABLE IF EXISTS `notifications`;
+DROP TABLE IF EXISTS `follows`;
+DROP TABLE IF EXISTS `comment_bans`;
+DROP TABLE IF EXISTS `comment_revisions`;
+DROP TABLE IF EXISTS `comments`;
+DROP TABLE IF EXISTS `post_tags`;
+DROP TABLE IF EXISTS `tags`;
+DROP TABLE IF EXISTS `posts`;
+DROP TABLE IF EXISTS `users`;
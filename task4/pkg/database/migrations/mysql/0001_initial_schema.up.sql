@@ -0,0 +1,206 @@
+-- 初始表结构，与 PostgreSQL 的初始迁移对应。
+-- MySQL 不支持 CREATE INDEX IF NOT EXISTS，索引在建表语句中定义。
+
+CREATE TABLE IF NOT EXISTS `users` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `username` varchar(50) NOT NULL UNIQUE,
+    `password` varchar(255) NOT NULL,
+    `email` varchar(100) NOT NULL UNIQUE,
+    `role` varchar(20) NOT NULL DEFAULT 'user',
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_users_deleted_at` (`deleted_at`)
+) DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `posts` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `title` varchar(200) NOT NULL,
+    `content` text NOT NULL,
+    `user_id` bigint unsigned NOT NULL,
+    `status` varchar(20) NOT NULL DEFAULT 'published',
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `comment_count` bigint NOT NULL DEFAULT 0,
+    `last_commented_at` datetime(3) NULL,
+    `view_count` bigint NOT NULL DEFAULT 0,
+    `comments_locked` boolean NOT NULL DEFAULT false,
+    `comment_policy` varchar(20) NOT NULL DEFAULT 'everyone',
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_users_posts` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`),
+    INDEX `idx_posts_deleted_at` (`deleted_at`),
+    INDEX `idx_posts_status` (`status`)
+) DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `tags` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `name` varchar(50) NOT NULL,
+    `slug` varchar(50) NOT NULL,
+    `created_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_tags_slug` (`slug`)
+) DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `post_tags` (
+    `post_id` bigint unsigned,
+    `tag_id` bigint unsigned,
+    PRIMARY KEY (`post_id`,`tag_id`),
+    CONSTRAINT `fk_post_tags_post` FOREIGN KEY (`post_id`) REFERENCES `posts`(`id`),
+    CONSTRAINT `fk_post_tags_tag` FOREIGN KEY (`tag_id`) REFERENCES `tags`(`id`)
+) DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `comments` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `content` text NOT NULL,
+    `user_id` bigint unsigned NOT NULL,
+    `post_id` bigint unsigned NOT NULL,
+    `parent_id` bigint unsigned,
+    `depth` bigint NOT NULL DEFAULT 0,
+    `path` varchar(255) NOT NULL DEFAULT '',
+    `is_deleted` boolean NOT NULL DEFAULT false,
+    `edited_at` datetime(3) NULL,
+    `status` varchar(20) NOT NULL DEFAULT 'approved',
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `moderation_reason` varchar(255),
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_posts_comments` FOREIGN KEY (`post_id`) REFERENCES `posts`(`id`),
+    CONSTRAINT `fk_users_comments` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`),
+    INDEX `idx_comments_deleted_at` (`deleted_at`),
+    INDEX `idx_comments_status` (`status`),
+    INDEX `idx_comments_path` (`path`),
+    INDEX `idx_comments_parent_id` (`parent_id`),
+    INDEX `idx_comments_post_id` (`post_id`)
+) DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `comment_revisions` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `comment_id` bigint unsigned NOT NULL,
+    `content` text NOT NULL,
+    `editor_id` bigint unsigned NOT NULL,
+    `created_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_comment_revisions_comment_id` (`comment_id`)
+) DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `comment_bans` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `user_id` bigint unsigned NOT NULL,
+    `owner_id` bigint NOT NULL DEFAULT 0,
+    `banned_by` bigint unsigned NOT NULL,
+    `reason` varchar(255),
+    `created_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_comment_bans_user_owner` (`user_id`,`owner_id`)
+) DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `follows` (
+    `follower_id` bigint unsigned,
+    `followee_id` bigint unsigned,
+    `created_at` datetime(3) NULL,
+    PRIMARY KEY (`follower_id`,`followee_id`),
+    INDEX `idx_follows_followee_id` (`followee_id`)
+) DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `notifications` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `user_id` bigint unsigned NOT NULL,
+    `actor_id` bigint unsigned NOT NULL,
+    `type` varchar(20) NOT NULL,
+    `post_id` bigint unsigned NOT NULL,
+    `comment_id` bigint unsigned,
+    `read_at` datetime(3) NULL,
+    `created_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_notifications_actor` FOREIGN KEY (`actor_id`) REFERENCES `users`(`id`),
+    INDEX `idx_notifications_read_at` (`read_at`),
+    INDEX `idx_notifications_user_id` (`user_id`)
+) DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `notification_preferences` (
+    `user_id` bigint unsigned,
+    `type` varchar(20),
+    `enabled` boolean NOT NULL,
+    `updated_at` datetime(3) NULL,
+    PRIMARY KEY (`user_id`,`type`)
+) DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `reactions` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `user_id` bigint unsigned NOT NULL,
+    `target_type` varchar(20) NOT NULL,
+    `target_id` bigint unsigned NOT NULL,
+    `type` varchar(20) NOT NULL,
+    `created_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_reactions_unique` (`user_id`,`target_type`,`target_id`,`type`),
+    INDEX `idx_reactions_created_at` (`created_at`),
+    INDEX `idx_reactions_target` (`target_type`,`target_id`)
+) DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `reading_lists` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `user_id` bigint unsigned NOT NULL,
+    `name` varchar(100) NOT NULL,
+    `is_public` boolean NOT NULL DEFAULT false,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_reading_lists_user_name` (`user_id`,`name`)
+) DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `bookmarks` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `user_id` bigint unsigned NOT NULL,
+    `list_id` bigint unsigned NOT NULL,
+    `post_id` bigint unsigned NOT NULL,
+    `position` bigint NOT NULL DEFAULT 0,
+    `created_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_bookmarks_post` FOREIGN KEY (`post_id`) REFERENCES `posts`(`id`),
+    INDEX `idx_bookmarks_post_id` (`post_id`),
+    UNIQUE INDEX `idx_bookmarks_list_post` (`list_id`,`post_id`),
+    INDEX `idx_bookmarks_user_id` (`user_id`)
+) DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `post_daily_views` (
+    `post_id` bigint unsigned,
+    `day` date,
+    `views` bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY (`post_id`,`day`),
+    INDEX `idx_post_daily_views_day` (`day`)
+) DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `post_referrer_views` (
+    `post_id` bigint unsigned,
+    `day` date,
+    `referrer` varchar(255),
+    `views` bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY (`post_id`,`day`,`referrer`),
+    INDEX `idx_post_referrer_views_day` (`day`)
+) DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `attachments` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `user_id` bigint unsigned NOT NULL,
+    `post_id` bigint unsigned,
+    `filename` varchar(255) NOT NULL,
+    `content_type` varchar(100) NOT NULL,
+    `size` bigint NOT NULL,
+    `width` bigint,
+    `height` bigint,
+    `key` varchar(255) NOT NULL,
+    `url` varchar(500) NOT NULL,
+    `thumbnail_key` varchar(255),
+    `thumbnail_url` varchar(500),
+    `created_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_posts_attachments` FOREIGN KEY (`post_id`) REFERENCES `posts`(`id`),
+    INDEX `idx_attachments_created_at` (`created_at`),
+    UNIQUE INDEX `idx_attachments_key` (`key`),
+    INDEX `idx_attachments_post_id` (`post_id`),
+    INDEX `idx_attachments_user_id` (`user_id`)
+) DEFAULT CHARSET=utf8mb4;
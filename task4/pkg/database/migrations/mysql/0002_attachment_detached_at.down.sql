@@ -0,0 +1,3 @@
+ALTER TABLE `attachments`
+    DROP INDEX `idx_attachments_detached_at`,
+    DROP COLUMN `detached_at`;
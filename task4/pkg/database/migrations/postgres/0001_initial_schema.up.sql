@@ -0,0 +1,207 @@
+-- 初始表结构，与之前 AutoMigrate 创建的结构一致。
+-- 引入迁移之前由 AutoMigrate 创建的数据库先由 pkg/database/legacy.go 把已有的表补全到这里的结构，缺少的表由这个文件创建，
+-- 所以这里的语句都要能在已有的表上重复执行。
+
+CREATE TABLE IF NOT EXISTS "users" (
+    "id" bigserial,
+    "username" varchar(50) NOT NULL UNIQUE,
+    "password" varchar(255) NOT NULL,
+    "email" varchar(100) NOT NULL UNIQUE,
+    "role" varchar(20) NOT NULL DEFAULT 'user',
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_users_deleted_at" ON "users" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "posts" (
+    "id" bigserial,
+    "title" varchar(200) NOT NULL,
+    "content" text NOT NULL,
+    "user_id" bigint NOT NULL,
+    "status" varchar(20) NOT NULL DEFAULT 'published',
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "comment_count" bigint NOT NULL DEFAULT 0,
+    "last_commented_at" timestamptz,
+    "view_count" bigint NOT NULL DEFAULT 0,
+    "comments_locked" boolean NOT NULL DEFAULT false,
+    "comment_policy" varchar(20) NOT NULL DEFAULT 'everyone',
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_users_posts" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_posts_deleted_at" ON "posts" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_posts_status" ON "posts" ("status");
+
+CREATE TABLE IF NOT EXISTS "tags" (
+    "id" bigserial,
+    "name" varchar(50) NOT NULL,
+    "slug" varchar(50) NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_tags_slug" ON "tags" ("slug");
+
+CREATE TABLE IF NOT EXISTS "post_tags" (
+    "post_id" bigint,
+    "tag_id" bigint,
+    PRIMARY KEY ("post_id","tag_id"),
+    CONSTRAINT "fk_post_tags_post" FOREIGN KEY ("post_id") REFERENCES "posts"("id"),
+    CONSTRAINT "fk_post_tags_tag" FOREIGN KEY ("tag_id") REFERENCES "tags"("id")
+);
+
+CREATE TABLE IF NOT EXISTS "comments" (
+    "id" bigserial,
+    "content" text NOT NULL,
+    "user_id" bigint NOT NULL,
+    "post_id" bigint NOT NULL,
+    "parent_id" bigint,
+    "depth" bigint NOT NULL DEFAULT 0,
+    "path" varchar(255) NOT NULL DEFAULT '',
+    "is_deleted" boolean NOT NULL DEFAULT false,
+    "edited_at" timestamptz,
+    "status" varchar(20) NOT NULL DEFAULT 'approved',
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "moderation_reason" varchar(255),
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_posts_comments" FOREIGN KEY ("post_id") REFERENCES "posts"("id"),
+    CONSTRAINT "fk_users_comments" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_comments_deleted_at" ON "comments" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_comments_status" ON "comments" ("status");
+CREATE INDEX IF NOT EXISTS "idx_comments_path" ON "comments" ("path");
+CREATE INDEX IF NOT EXISTS "idx_comments_parent_id" ON "comments" ("parent_id");
+CREATE INDEX IF NOT EXISTS "idx_comments_post_id" ON "comments" ("post_id");
+
+CREATE TABLE IF NOT EXISTS "comment_revisions" (
+    "id" bigserial,
+    "comment_id" bigint NOT NULL,
+    "content" text NOT NULL,
+    "editor_id" bigint NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_comment_revisions_comment_id" ON "comment_revisions" ("comment_id");
+
+CREATE TABLE IF NOT EXISTS "comment_bans" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "owner_id" bigint NOT NULL DEFAULT 0,
+    "banned_by" bigint NOT NULL,
+    "reason" varchar(255),
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_comment_bans_user_owner" ON "comment_bans" ("user_id","owner_id");
+
+CREATE TABLE IF NOT EXISTS "follows" (
+    "follower_id" bigint,
+    "followee_id" bigint,
+    "created_at" timestamptz,
+    PRIMARY KEY ("follower_id","followee_id")
+);
+CREATE INDEX IF NOT EXISTS "idx_follows_followee_id" ON "follows" ("followee_id");
+
+CREATE TABLE IF NOT EXISTS "notifications" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "actor_id" bigint NOT NULL,
+    "type" varchar(20) NOT NULL,
+    "post_id" bigint NOT NULL,
+    "comment_id" bigint,
+    "read_at" timestamptz,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_notifications_actor" FOREIGN KEY ("actor_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_notifications_read_at" ON "notifications" ("read_at");
+CREATE INDEX IF NOT EXISTS "idx_notifications_user_id" ON "notifications" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "notification_preferences" (
+    "user_id" bigint,
+    "type" varchar(20),
+    "enabled" boolean NOT NULL,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("user_id","type")
+);
+
+CREATE TABLE IF NOT EXISTS "reactions" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "target_type" varchar(20) NOT NULL,
+    "target_id" bigint NOT NULL,
+    "type" varchar(20) NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_reactions_unique" ON "reactions" ("user_id","target_type","target_id","type");
+CREATE INDEX IF NOT EXISTS "idx_reactions_created_at" ON "reactions" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_reactions_target" ON "reactions" ("target_type","target_id");
+
+CREATE TABLE IF NOT EXISTS "reading_lists" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "name" varchar(100) NOT NULL,
+    "is_public" boolean NOT NULL DEFAULT false,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_reading_lists_user_name" ON "reading_lists" ("user_id","name");
+
+CREATE TABLE IF NOT EXISTS "bookmarks" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "list_id" bigint NOT NULL,
+    "post_id" bigint NOT NULL,
+    "position" bigint NOT NULL DEFAULT 0,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_bookmarks_post" FOREIGN KEY ("post_id") REFERENCES "posts"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_bookmarks_post_id" ON "bookmarks" ("post_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_bookmarks_list_post" ON "bookmarks" ("list_id","post_id");
+CREATE INDEX IF NOT EXISTS "idx_bookmarks_user_id" ON "bookmarks" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "post_daily_views" (
+    "post_id" bigint,
+    "day" date,
+    "views" bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY ("post_id","day")
+);
+CREATE INDEX IF NOT EXISTS "idx_post_daily_views_day" ON "post_daily_views" ("day");
+
+CREATE TABLE IF NOT EXISTS "post_referrer_views" (
+    "post_id" bigint,
+    "day" date,
+    "referrer" varchar(255),
+    "views" bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY ("post_id","day","referrer")
+);
+CREATE INDEX IF NOT EXISTS "idx_post_referrer_views_day" ON "post_referrer_views" ("day");
+
+CREATE TABLE IF NOT EXISTS "attachments" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "post_id" bigint,
+    "filename" varchar(255) NOT NULL,
+    "content_type" varchar(100) NOT NULL,
+    "size" bigint NOT NULL,
+    "width" bigint,
+    "height" bigint,
+    "key" varchar(255) NOT NULL,
+    "url" varchar(500) NOT NULL,
+    "thumbnail_key" varchar(255),
+    "thumbnail_url" varchar(500),
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_posts_attachments" FOREIGN KEY ("post_id") REFERENCES "posts"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_attachments_created_at" ON "attachments" ("created_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_attachments_key" ON "attachments" ("key");
+CREATE INDEX IF NOT EXISTS "idx_attachments_post_id" ON "attachments" ("post_id");
+CREATE INDEX IF NOT EXISTS "idx_attachments_user_id" ON "attachments" ("user_id");
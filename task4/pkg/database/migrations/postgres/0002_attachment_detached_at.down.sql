@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS "idx_attachments_detached_at";
+ALTER TABLE "attachments" DROP COLUMN IF EXISTS "detached_at";
@@ -0,0 +1,195 @@
+-- 初始表结构，与 PostgreSQL 的初始迁移对应。
+-- SQLite 没有布尔和带时区的时间类型，分别使用 numeric 和 datetime。
+
+CREATE TABLE IF NOT EXISTS "users" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "username" varchar(50) NOT NULL UNIQUE,
+    "password" varchar(255) NOT NULL,
+    "email" varchar(100) NOT NULL UNIQUE,
+    "role" varchar(20) NOT NULL DEFAULT 'user',
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime
+);
+CREATE INDEX IF NOT EXISTS "idx_users_deleted_at" ON "users" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "posts" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "title" varchar(200) NOT NULL,
+    "content" text NOT NULL,
+    "user_id" integer NOT NULL,
+    "status" varchar(20) NOT NULL DEFAULT 'published',
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime,
+    "comment_count" integer NOT NULL DEFAULT 0,
+    "last_commented_at" datetime,
+    "view_count" integer NOT NULL DEFAULT 0,
+    "comments_locked" numeric NOT NULL DEFAULT false,
+    "comment_policy" varchar(20) NOT NULL DEFAULT 'everyone',
+    CONSTRAINT "fk_users_posts" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_posts_deleted_at" ON "posts" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_posts_status" ON "posts" ("status");
+
+CREATE TABLE IF NOT EXISTS "tags" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "name" varchar(50) NOT NULL,
+    "slug" varchar(50) NOT NULL,
+    "created_at" datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_tags_slug" ON "tags" ("slug");
+
+CREATE TABLE IF NOT EXISTS "post_tags" (
+    "post_id" integer,
+    "tag_id" integer,
+    PRIMARY KEY ("post_id","tag_id"),
+    CONSTRAINT "fk_post_tags_post" FOREIGN KEY ("post_id") REFERENCES "posts"("id"),
+    CONSTRAINT "fk_post_tags_tag" FOREIGN KEY ("tag_id") REFERENCES "tags"("id")
+);
+
+CREATE TABLE IF NOT EXISTS "comments" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "content" text NOT NULL,
+    "user_id" integer NOT NULL,
+    "post_id" integer NOT NULL,
+    "parent_id" integer,
+    "depth" integer NOT NULL DEFAULT 0,
+    "path" varchar(255) NOT NULL DEFAULT '',
+    "is_deleted" numeric NOT NULL DEFAULT false,
+    "edited_at" datetime,
+    "status" varchar(20) NOT NULL DEFAULT 'approved',
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime,
+    "moderation_reason" varchar(255),
+    CONSTRAINT "fk_posts_comments" FOREIGN KEY ("post_id") REFERENCES "posts"("id"),
+    CONSTRAINT "fk_users_comments" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_comments_deleted_at" ON "comments" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_comments_status" ON "comments" ("status");
+CREATE INDEX IF NOT EXISTS "idx_comments_path" ON "comments" ("path");
+CREATE INDEX IF NOT EXISTS "idx_comments_parent_id" ON "comments" ("parent_id");
+CREATE INDEX IF NOT EXISTS "idx_comments_post_id" ON "comments" ("post_id");
+
+CREATE TABLE IF NOT EXISTS "comment_revisions" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "comment_id" integer NOT NULL,
+    "content" text NOT NULL,
+    "editor_id" integer NOT NULL,
+    "created_at" datetime
+);
+CREATE INDEX IF NOT EXISTS "idx_comment_revisions_comment_id" ON "comment_revisions" ("comment_id");
+
+CREATE TABLE IF NOT EXISTS "comment_bans" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "user_id" integer NOT NULL,
+    "owner_id" integer NOT NULL DEFAULT 0,
+    "banned_by" integer NOT NULL,
+    "reason" varchar(255),
+    "created_at" datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_comment_bans_user_owner" ON "comment_bans" ("user_id","owner_id");
+
+CREATE TABLE IF NOT EXISTS "follows" (
+    "follower_id" integer,
+    "followee_id" integer,
+    "created_at" datetime,
+    PRIMARY KEY ("follower_id","followee_id")
+);
+CREATE INDEX IF NOT EXISTS "idx_follows_followee_id" ON "follows" ("followee_id");
+
+CREATE TABLE IF NOT EXISTS "notifications" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "user_id" integer NOT NULL,
+    "actor_id" integer NOT NULL,
+    "type" varchar(20) NOT NULL,
+    "post_id" integer NOT NULL,
+    "comment_id" integer,
+    "read_at" datetime,
+    "created_at" datetime,
+    CONSTRAINT "fk_notifications_actor" FOREIGN KEY ("actor_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_notifications_read_at" ON "notifications" ("read_at");
+CREATE INDEX IF NOT EXISTS "idx_notifications_user_id" ON "notifications" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "notification_preferences" (
+    "user_id" integer,
+    "type" varchar(20),
+    "enabled" numeric NOT NULL,
+    "updated_at" datetime,
+    PRIMARY KEY ("user_id","type")
+);
+
+CREATE TABLE IF NOT EXISTS "reactions" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "user_id" integer NOT NULL,
+    "target_type" varchar(20) NOT NULL,
+    "target_id" integer NOT NULL,
+    "type" varchar(20) NOT NULL,
+    "created_at" datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_reactions_unique" ON "reactions" ("user_id","target_type","target_id","type");
+CREATE INDEX IF NOT EXISTS "idx_reactions_created_at" ON "reactions" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_reactions_target" ON "reactions" ("target_type","target_id");
+
+CREATE TABLE IF NOT EXISTS "reading_lists" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "user_id" integer NOT NULL,
+    "name" varchar(100) NOT NULL,
+    "is_public" numeric NOT NULL DEFAULT false,
+    "created_at" datetime,
+    "updated_at" datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_reading_lists_user_name" ON "reading_lists" ("user_id","name");
+
+CREATE TABLE IF NOT EXISTS "bookmarks" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "user_id" integer NOT NULL,
+    "list_id" integer NOT NULL,
+    "post_id" integer NOT NULL,
+    "position" integer NOT NULL DEFAULT 0,
+    "created_at" datetime,
+    CONSTRAINT "fk_bookmarks_post" FOREIGN KEY ("post_id") REFERENCES "posts"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_bookmarks_post_id" ON "bookmarks" ("post_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_bookmarks_list_post" ON "bookmarks" ("list_id","post_id");
+CREATE INDEX IF NOT EXISTS "idx_bookmarks_user_id" ON "bookmarks" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "post_daily_views" (
+    "post_id" integer,
+    "day" date,
+    "views" integer NOT NULL DEFAULT 0,
+    PRIMARY KEY ("post_id","day")
+);
+CREATE INDEX IF NOT EXISTS "idx_post_daily_views_day" ON "post_daily_views" ("day");
+
+CREATE TABLE IF NOT EXISTS "post_referrer_views" (
+    "post_id" integer,
+    "day" date,
+    "referrer" varchar(255),
+    "views" integer NOT NULL DEFAULT 0,
+    PRIMARY KEY ("post_id","day","referrer")
+);
+CREATE INDEX IF NOT EXISTS "idx_post_referrer_views_day" ON "post_referrer_views" ("day");
+
+CREATE TABLE IF NOT EXISTS "attachments" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "user_id" integer NOT NULL,
+    "post_id" integer,
+    "filename" varchar(255) NOT NULL,
+    "content_type" varchar(100) NOT NULL,
+    "size" integer NOT NULL,
+    "width" integer,
+    "height" integer,
+    "key" varchar(255) NOT NULL,
+    "url" varchar(500) NOT NULL,
+    "thumbnail_key" varchar(255),
+    "thumbnail_url" varchar(500),
+    "created_at" datetime,
+    CONSTRAINT "fk_posts_attachments" FOREIGN KEY ("post_id") REFERENCES "posts"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_attachments_created_at" ON "attachments" ("created_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_attachments_key" ON "attachments" ("key");
+CREATE INDEX IF NOT EXISTS "idx_attachments_post_id" ON "attachments" ("post_id");
+CREATE INDEX IF NOT EXISTS "idx_attachments_user_id" ON "attachments" ("user_id");
@@ -0,0 +1,4 @@
+-- 记录附件被移出文章的时间，清理未使用的附件时从这个时间开始计算保留时长。
+-- 之前移出的附件没有记录，按上传时间处理
+ALTER TABLE "attachments" ADD COLUMN "detached_at" datetime;
+CREATE INDEX IF NOT EXISTS "idx_attachments_detached_at" ON "attachments" ("detached_at");
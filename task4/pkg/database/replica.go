@@ -0,0 +1,398 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"blog-system/config"
+	"blog-system/pkg/logger"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const replicasPluginName = "blog:replicas"
+
+// notChecked 是副本在第一次健康检查之前的状态
+const notChecked = "not checked yet"
+
+type preferReplicaKey struct{}
+
+// PreferReplica 标记 ctx 中的查询可以走只读副本。只有事务之外的 SELECT 会被路由，
+// 写入和事务中的查询始终使用主库，没有可用的副本时也回到主库
+func PreferReplica(ctx context.Context) context.Context {
+	return context.WithValue(ctx, preferReplicaKey{}, true)
+}
+
+func prefersReplica(ctx context.Context) bool {
+	prefer, _ := ctx.Value(preferReplicaKey{}).(bool)
+	return prefer
+}
+
+type writesKey struct{}
+
+// TrackWrites 返回记录写入的 ctx，之后可以用 Wrote 判断其中的查询是否修改过主库的数据
+func TrackWrites(ctx context.Context) context.Context {
+	return context.WithValue(ctx, writesKey{}, new(atomic.Bool))
+}
+
+// Wrote 判断 TrackWrites 返回的 ctx 中是否有写入修改了数据，只在配置了副本时记录
+func Wrote(ctx context.Context) bool {
+	wrote, ok := ctx.Value(writesKey{}).(*atomic.Bool)
+	return ok && wrote.Load()
+}
+
+// ReplicaStatus 是一个只读副本最近一次健康检查的结果
+type ReplicaStatus struct {
+	Name    string  `json:"name"`
+	Healthy bool    `json:"healthy"`
+	Lag     float64 `json:"lag_seconds"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// Replicas 以 GORM 插件的形式注册到主库连接上，定期检查副本的连接和复制延迟，
+// 把标记了 PreferReplica 的查询轮流分配给健康的副本
+type Replicas struct {
+	cfg      config.DatabaseConfig
+	replicas []*replica
+	next     atomic.Uint64
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+type replica struct {
+	name string
+	cfg  config.DatabaseConfig
+
+	mu     sync.RWMutex
+	db     *gorm.DB
+	status ReplicaStatus
+}
+
+func newReplicas(cfg config.DatabaseConfig) *Replicas {
+	r := &Replicas{cfg: cfg, stop: make(chan struct{}), done: make(chan struct{})}
+	for i, address := range cfg.Replicas {
+		replicaCfg := cfg
+		replicaCfg.Replicas = nil
+		if cfg.Driver == DriverSQLite {
+			replicaCfg.Path = address
+		} else {
+			host, port, err := net.SplitHostPort(address)
+			if err != nil {
+				host, port = address, cfg.Port
+			}
+			replicaCfg.Host, replicaCfg.Port = host, port
+		}
+
+		name := fmt.Sprintf("replica-%d", i+1)
+		logger.Info("Database replica configured", "name", name, "address", address)
+		r.replicas = append(r.replicas, &replica{
+			name:   name,
+			cfg:    replicaCfg,
+			status: ReplicaStatus{Name: name, Error: notChecked},
+		})
+	}
+	return r
+}
+
+func (r *Replicas) Name() string {
+	return replicasPluginName
+}
+
+// Initialize 注册查询路由和记录写入的回调，并在后台开始健康检查
+func (r *Replicas) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Query().Before("gorm:query").Register("blog:replica_query", r.route); err != nil {
+		return err
+	}
+	if err := db.Callback().Row().Before("gorm:row").Register("blog:replica_row", r.route); err != nil {
+		return err
+	}
+	if err := db.Callback().Create().Before("gorm:create").Register("blog:primary_create", r.primary); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().Before("gorm:update").Register("blog:primary_update", r.primary); err != nil {
+		return err
+	}
+	if err := db.Callback().Delete().Before("gorm:delete").Register("blog:primary_delete", r.primary); err != nil {
+		return err
+	}
+	if err := db.Callback().Raw().Before("gorm:raw").Register("blog:primary_raw", r.primary); err != nil {
+		return err
+	}
+	if err := db.Callback().Create().After("gorm:create").Register("blog:wrote_create", markWrite); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().After("gorm:update").Register("blog:wrote_update", markWrite); err != nil {
+		return err
+	}
+	if err := db.Callback().Delete().After("gorm:delete").Register("blog:wrote_delete", markWrite); err != nil {
+		return err
+	}
+	if err := db.Callback().Raw().After("gorm:raw").Register("blog:wrote_raw", markWrite); err != nil {
+		return err
+	}
+
+	// 第一次检查完成之前副本视为不可用，查询使用主库，不阻塞启动
+	go r.run()
+	return nil
+}
+
+// markWrite 在 TrackWrites 的 ctx 上记录修改了数据的写入
+func markWrite(db *gorm.DB) {
+	if db.Error != nil || db.RowsAffected == 0 || db.Statement.Context == nil {
+		return
+	}
+	if wrote, ok := db.Statement.Context.Value(writesKey{}).(*atomic.Bool); ok {
+		wrote.Store(true)
+	}
+}
+
+// route 在执行查询之前把连接换成一个健康的副本，并在这条 SQL 的 span 上记录副本名
+func (r *Replicas) route(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Context == nil || !prefersReplica(db.Statement.Context) {
+		return
+	}
+	// 事务中的连接实现了 Commit，SELECT ... FOR UPDATE 需要在主库加锁
+	if _, inTx := db.Statement.ConnPool.(gorm.TxCommitter); inTx {
+		return
+	}
+	if _, locking := db.Statement.Clauses["FOR"]; locking {
+		return
+	}
+
+	if rep, pool := r.pick(); pool != nil {
+		db.Statement.ConnPool = pool
+		trace.SpanFromContext(db.Statement.Context).SetAttributes(attribute.String("db.replica", rep.name))
+	}
+}
+
+// primary 保证写入使用主库，同一个查询链之前的读取可能已经把连接换成了副本
+func (r *Replicas) primary(db *gorm.DB) {
+	for _, rep := range r.replicas {
+		if rep.pool() != nil && db.Statement.ConnPool == rep.pool() {
+			db.Statement.ConnPool = db.ConnPool
+			return
+		}
+	}
+}
+
+func (r *Replicas) pick() (*replica, gorm.ConnPool) {
+	n := len(r.replicas)
+	start := r.next.Add(1)
+	for i := 0; i < n; i++ {
+		rep := r.replicas[(start+uint64(i))%uint64(n)]
+		rep.mu.RLock()
+		healthy, db := rep.status.Healthy, rep.db
+		rep.mu.RUnlock()
+		if healthy && db != nil {
+			return rep, db.ConnPool
+		}
+	}
+	return nil, nil
+}
+
+// Status 返回每个副本最近一次健康检查的结果
+func (r *Replicas) Status() []ReplicaStatus {
+	statuses := make([]ReplicaStatus, 0, len(r.replicas))
+	for _, rep := range r.replicas {
+		rep.mu.RLock()
+		statuses = append(statuses, rep.status)
+		rep.mu.RUnlock()
+	}
+	return statuses
+}
+
+func (r *Replicas) run() {
+	defer close(r.done)
+
+	r.checkAll()
+
+	ticker := time.NewTicker(r.cfg.ReplicaCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			r.checkAll()
+		}
+	}
+}
+
+func (r *Replicas) checkAll() {
+	var wg sync.WaitGroup
+	for _, rep := range r.replicas {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.check(rep)
+		}()
+	}
+	wg.Wait()
+}
+
+// check 连接副本并查询复制延迟，连接失败或延迟超过 DB_REPLICA_MAX_LAG 时不再分配查询，
+// 状态变化时记录日志
+func (r *Replicas) check(rep *replica) {
+	status := ReplicaStatus{Name: rep.name}
+
+	db := rep.current()
+	if db == nil {
+		opened, err := open(rep.cfg)
+		if err != nil {
+			status.Error = err.Error()
+			rep.update(status)
+			return
+		}
+		rep.mu.Lock()
+		rep.db = opened
+		rep.mu.Unlock()
+		db = opened
+	}
+
+	lag, err := replicationLag(db)
+	switch {
+	case err != nil:
+		status.Error = err.Error()
+	case r.cfg.ReplicaMaxLag > 0 && lag > r.cfg.ReplicaMaxLag:
+		status.Lag = lag.Seconds()
+		status.Error = fmt.Sprintf("replication lag %s exceeds %s", lag, r.cfg.ReplicaMaxLag)
+	default:
+		status.Lag = lag.Seconds()
+		status.Healthy = true
+	}
+	rep.update(status)
+}
+
+func (rep *replica) current() *gorm.DB {
+	rep.mu.RLock()
+	defer rep.mu.RUnlock()
+	return rep.db
+}
+
+func (rep *replica) pool() gorm.ConnPool {
+	if db := rep.current(); db != nil {
+		return db.ConnPool
+	}
+	return nil
+}
+
+func (rep *replica) update(status ReplicaStatus) {
+	rep.mu.Lock()
+	previous := rep.status
+	rep.status = status
+	rep.mu.Unlock()
+
+	if previous.Healthy != status.Healthy || previous.Error == notChecked {
+		if status.Healthy {
+			logger.Info("Database replica is healthy", "name", rep.name, "lag_seconds", status.Lag)
+		} else {
+			logger.Warn("Database replica is unavailable, reads fall back to the primary", "name", rep.name, "error", status.Error)
+		}
+	}
+}
+
+// replicationLag 查询副本落后主库的时间。健康检查直接使用 database/sql，不输出 SQL 日志
+func replicationLag(db *gorm.DB) (time.Duration, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+	defer cancel()
+
+	switch db.Dialector.Name() {
+	case DriverPostgres:
+		// 已经回放完收到的全部 WAL 时没有延迟，否则按最后一个回放的事务计算；不是备库时为 0
+		var seconds sql.NullFloat64
+		err := sqlDB.QueryRowContext(ctx, `SELECT CASE
+    WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+    ELSE EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp())
+END`).Scan(&seconds)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(seconds.Float64 * float64(time.Second)), nil
+
+	case DriverMySQL:
+		return mysqlReplicationLag(ctx, sqlDB)
+
+	default:
+		// SQLite 的副本是由外部工具同步的文件，只检查能否连接
+		return 0, sqlDB.PingContext(ctx)
+	}
+}
+
+// mysqlReplicationLag 从 SHOW REPLICA STATUS 读取 Seconds_Behind_Source，
+// 8.0.22 之前的版本列名为 Seconds_Behind_Master
+func mysqlReplicationLag(ctx context.Context, sqlDB *sql.DB) (time.Duration, error) {
+	rows, err := sqlDB.QueryContext(ctx, "SHOW REPLICA STATUS")
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	if !rows.Next() {
+		// 没有配置复制，通常是直接指向了主库
+		return 0, rows.Err()
+	}
+
+	values := make([]sql.RawBytes, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return 0, err
+	}
+
+	for i, column := range columns {
+		if column != "Seconds_Behind_Source" && column != "Seconds_Behind_Master" {
+			continue
+		}
+		if values[i] == nil {
+			return 0, errors.New("replication is not running")
+		}
+		var seconds int64
+		if _, err := fmt.Sscan(string(values[i]), &seconds); err != nil {
+			return 0, fmt.Errorf("invalid replication lag %q", values[i])
+		}
+		return time.Duration(seconds) * time.Second, nil
+	}
+	return 0, errors.New("replication lag is not reported")
+}
+
+// close 停止健康检查并关闭副本连接
+func (r *Replicas) close() {
+	close(r.stop)
+	<-r.done
+	for _, rep := range r.replicas {
+		if db := rep.current(); db != nil {
+			if sqlDB, err := db.DB(); err == nil {
+				sqlDB.Close()
+			}
+		}
+	}
+}
+
+// ReplicasOf 返回注册在连接上的只读副本，没有配置 DB_REPLICAS 时返回 nil
+func ReplicasOf(db *gorm.DB) *Replicas {
+	if plugin, ok := db.Config.Plugins[replicasPluginName]; ok {
+		return plugin.(*Replicas)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SQLite 把时间保存为带时区偏移的文本，比较和排序都按文本进行，
+// 同一字段中混有不同偏移的时间时顺序就会出错。utcConnPool 在执行前把参数中的时间统一转换为 UTC
+type utcConnPool struct {
+	gorm.ConnPool
+}
+
+func (p *utcConnPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return p.ConnPool.ExecContext(ctx, query, utcArgs(args)...)
+}
+
+func (p *utcConnPool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return p.ConnPool.QueryContext(ctx, query, utcArgs(args)...)
+}
+
+func (p *utcConnPool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return p.ConnPool.QueryRowContext(ctx, query, utcArgs(args)...)
+}
+
+func (p *utcConnPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	beginner, ok := p.ConnPool.(gorm.TxBeginner)
+	if !ok {
+		return nil, gorm.ErrInvalidTransaction
+	}
+	tx, err := beginner.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &utcTx{utcConnPool{tx}}, nil
+}
+
+// GetDBConn 让 db.DB() 仍然返回底层的 *sql.DB
+func (p *utcConnPool) GetDBConn() (*sql.DB, error) {
+	if db, ok := p.ConnPool.(*sql.DB); ok {
+		return db, nil
+	}
+	return nil, errors.New("sqlite connection pool is not a *sql.DB")
+}
+
+// utcTx 是事务中的连接。只有事务实现 Commit 和 Rollback，
+// GORM 据此判断当前是否已经在事务中
+type utcTx struct {
+	utcConnPool
+}
+
+func (t *utcTx) Commit() error {
+	return t.ConnPool.(gorm.TxCommitter).Commit()
+}
+
+func (t *utcTx) Rollback() error {
+	return t.ConnPool.(gorm.TxCommitter).Rollback()
+}
+
+func utcArgs(args []interface{}) []interface{} {
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case time.Time:
+			args[i] = v.UTC()
+		case *time.Time:
+			if v != nil {
+				args[i] = v.UTC()
+			}
+		case sql.NullTime:
+			if v.Valid {
+				args[i] = v.Time.UTC()
+			}
+		case gorm.DeletedAt:
+			if v.Valid {
+				args[i] = v.Time.UTC()
+			}
+		}
+	}
+	return args
+}
@@ -0,0 +1,100 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// gormLogger 把 GORM 的日志写到 Logger：出错的语句记为 error，超过慢查询阈值的记为 warn，
+// 其他语句只在 debug 级别输出
+type gormLogger struct {
+	logger        *Logger
+	slowThreshold time.Duration
+	level         gormlogger.LogLevel
+}
+
+// NewGorm 返回 GORM 使用的日志适配器，slowThreshold 为 0 时不记录慢查询
+func NewGorm(l *Logger, slowThreshold time.Duration) gormlogger.Interface {
+	return &gormLogger{logger: l, slowThreshold: slowThreshold, level: gormlogger.Info}
+}
+
+func (g *gormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	copied := *g
+	copied.level = level
+	return &copied
+}
+
+func (g *gormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if g.level >= gormlogger.Info {
+		g.log(ctx, slog.LevelInfo, fmt.Sprintf(msg, data...))
+	}
+}
+
+func (g *gormLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if g.level >= gormlogger.Warn {
+		g.log(ctx, slog.LevelWarn, fmt.Sprintf(msg, data...))
+	}
+}
+
+func (g *gormLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if g.level >= gormlogger.Error {
+		g.log(ctx, slog.LevelError, fmt.Sprintf(msg, data...))
+	}
+}
+
+// Trace 在每条语句执行之后调用，fc 返回的 SQL 不带参数值
+func (g *gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && g.level >= gormlogger.Error:
+		sql, rows := fc()
+		g.log(ctx, slog.LevelError, "Database query failed", "sql", sql, "rows", rows, "elapsed", elapsed, "error", err)
+	case g.slowThreshold > 0 && elapsed > g.slowThreshold && g.level >= gormlogger.Warn:
+		sql, rows := fc()
+		g.log(ctx, slog.LevelWarn, "Slow database query", "sql", sql, "rows", rows, "elapsed", elapsed, "threshold", g.slowThreshold)
+	case g.level >= gormlogger.Info && g.logger.Enabled(ctx, slog.LevelDebug):
+		sql, rows := fc()
+		g.log(ctx, slog.LevelDebug, "Database query", "sql", sql, "rows", rows, "elapsed", elapsed)
+	}
+}
+
+// ParamsFilter 去掉参数值，SQL 中保留占位符，避免密码哈希、令牌和用户内容写入日志
+func (g *gormLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	return sql, nil
+}
+
+// log 用执行查询的业务代码作为日志的源码位置
+func (g *gormLogger) log(ctx context.Context, level slog.Level, msg string, args ...any) {
+	if !g.logger.Enabled(ctx, level) {
+		return
+	}
+
+	r := slog.NewRecord(time.Now(), level, msg, queryCaller())
+	r.Add(args...)
+	_ = g.logger.Handler().Handle(ctx, r)
+}
+
+// queryCaller 跳过 GORM 和本包的调用栈，返回第一个业务代码的位置
+func queryCaller() uintptr {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "gorm.io/") && !strings.HasPrefix(frame.Function, "blog-system/pkg/logger.") {
+			return frame.PC
+		}
+		if !more {
+			return 0
+		}
+	}
+}
@@ -1,24 +1,184 @@
 package logger
 
 import (
-	"log"
+	"context"
+	"io"
+	"log/slog"
 	"os"
-)
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
 
-var (
-	InfoLogger  *log.Logger
-	ErrorLogger *log.Logger
+	"go.opentelemetry.io/otel/trace"
 )
 
+// Options 是日志的输出格式和级别
+type Options struct {
+	// Level 为 debug、info、warn 或 error
+	Level string
+	// Format 为 text 或 json
+	Format string
+}
+
+// Logger 是带请求 ID 和脱敏处理的 slog.Logger
+type Logger struct {
+	*slog.Logger
+}
+
+// New 按 opts 创建写到 w 的 Logger。每条日志附带调用位置，
+// 在请求中记录的日志附带请求 ID，密码、令牌等字段的值会被替换为 [REDACTED]
+func New(w io.Writer, opts Options) *Logger {
+	handlerOpts := &slog.HandlerOptions{
+		AddSource:   true,
+		Level:       ParseLevel(opts.Level),
+		ReplaceAttr: replaceAttr,
+	}
+
+	var handler slog.Handler
+	if strings.EqualFold(opts.Format, "json") {
+		handler = slog.NewJSONHandler(w, handlerOpts)
+	} else {
+		handler = slog.NewTextHandler(w, handlerOpts)
+	}
+	return &Logger{slog.New(contextHandler{handler})}
+}
+
+// ParseLevel 解析 debug、info、warn 或 error，其他值按 info 处理
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// log 记录调用包级函数的位置而不是这里
+func (l *Logger) log(ctx context.Context, level slog.Level, msg string, args ...any) {
+	if !l.Enabled(ctx, level) {
+		return
+	}
+
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+	r := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	r.Add(args...)
+	_ = l.Handler().Handle(ctx, r)
+}
+
+type requestIDKey struct{}
+
+// WithRequestID 把请求 ID 放入 ctx，之后用这个 ctx 记录的日志都会带上它
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID 返回 ctx 中的请求 ID，没有时返回空字符串
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// contextHandler 从 ctx 中取出请求 ID 和链路 ID 加到每条日志上，日志可以和链路中的 span 对应起来
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// sensitiveKeys 是需要脱敏的字段名，字段名包含其中任意一个时不输出值
+var sensitiveKeys = []string{"password", "token", "secret", "authorization", "cookie"}
+
+func replaceAttr(groups []string, a slog.Attr) slog.Attr {
+	if a.Key == slog.SourceKey {
+		// 与之前的 log.Lshortfile 一样只保留文件名和行号
+		source, ok := a.Value.Any().(*slog.Source)
+		if !ok {
+			return a
+		}
+		if source.File == "" {
+			return slog.Attr{}
+		}
+		return slog.String(slog.SourceKey, filepath.Base(source.File)+":"+strconv.Itoa(source.Line))
+	}
+
+	key := strings.ToLower(a.Key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return slog.String(a.Key, "[REDACTED]")
+		}
+	}
+	return a
+}
+
+var std = New(os.Stdout, Options{})
+
+// Init 使用默认配置初始化日志，读取配置之后再用 SetDefault 替换
 func Init() {
-	InfoLogger = log.New(os.Stdout, "INFO: ", log.Ldate|log.Ltime|log.Lshortfile)
-	ErrorLogger = log.New(os.Stderr, "ERROR: ", log.Ldate|log.Ltime|log.Lshortfile)
+	SetDefault(New(os.Stdout, Options{}))
+}
+
+// Default 返回包级函数使用的 Logger
+func Default() *Logger {
+	return std
+}
+
+// SetDefault 替换包级函数使用的 Logger，同时作为 slog 和标准库 log 的默认输出，需要在启动时调用
+func SetDefault(l *Logger) {
+	std = l
+	slog.SetDefault(l.Logger)
+}
+
+func Debug(msg string, args ...any) {
+	std.log(context.Background(), slog.LevelDebug, msg, args...)
+}
+
+func Info(msg string, args ...any) {
+	std.log(context.Background(), slog.LevelInfo, msg, args...)
+}
+
+func Warn(msg string, args ...any) {
+	std.log(context.Background(), slog.LevelWarn, msg, args...)
+}
+
+func Error(msg string, args ...any) {
+	std.log(context.Background(), slog.LevelError, msg, args...)
+}
+
+func DebugContext(ctx context.Context, msg string, args ...any) {
+	std.log(ctx, slog.LevelDebug, msg, args...)
+}
+
+func InfoContext(ctx context.Context, msg string, args ...any) {
+	std.log(ctx, slog.LevelInfo, msg, args...)
 }
 
-func Info(v ...interface{}) {
-	InfoLogger.Println(v...)
+func WarnContext(ctx context.Context, msg string, args ...any) {
+	std.log(ctx, slog.LevelWarn, msg, args...)
 }
 
-func Error(v ...interface{}) {
-	ErrorLogger.Println(v...)
+func ErrorContext(ctx context.Context, msg string, args ...any) {
+	std.log(ctx, slog.LevelError, msg, args...)
 }
@@ -0,0 +1,407 @@
+// Package migrate 按版本号执行 SQL 迁移，已执行的版本记录在 schema_migrations 表中
+package migrate
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// lockKey 和 lockName 分别是 PostgreSQL 咨询锁和 MySQL 命名锁的键，多个实例同时启动时只有一个执行迁移
+const (
+	lockKey  int64 = 0x626c6f67 // "blog"
+	lockName       = "blog_schema_migrations"
+)
+
+// noTransactionMarker 出现在迁移文件中时不使用事务执行，用于 CREATE INDEX CONCURRENTLY 等语句
+const noTransactionMarker = "-- migrate:no-transaction"
+
+var fileNamePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+type appliedMigration struct {
+	Version   int64
+	Name      string
+	AppliedAt time.Time
+}
+
+func (appliedMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Load 读取目录中形如 0001_name.up.sql 和 0001_name.down.sql 的迁移文件，每个版本必须同时有两个文件
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := fileNamePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %q", entry.Name())
+		}
+
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d has different names %q and %q", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %s must have both up and down files", m)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+type Runner struct {
+	db         *gorm.DB
+	migrations []Migration
+
+	// DryRun 为 true 时只输出将要执行的 SQL，不修改数据库
+	DryRun bool
+	Out    io.Writer
+
+	// Prepare 在数据库还没有 schema_migrations 表时、执行迁移之前调用，用于接管引入迁移之前已经存在的数据库。
+	// 它需要把已有的结构补全到第一个迁移执行后的状态，之后全部迁移照常执行
+	Prepare func(db *gorm.DB) error
+}
+
+func NewRunner(db *gorm.DB, fsys fs.FS) (*Runner, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Runner{db: db, migrations: migrations, Out: io.Discard}, nil
+}
+
+func (r *Runner) ensureTable(db *gorm.DB) error {
+	// SQLite 只有声明为 datetime 的字段才会读回 time.Time
+	timeType := "timestamptz"
+	switch db.Dialector.Name() {
+	case "mysql":
+		timeType = "datetime(3)"
+	case "sqlite":
+		timeType = "datetime"
+	}
+
+	return db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+    version bigint PRIMARY KEY,
+    name varchar(255) NOT NULL,
+    applied_at ` + timeType + ` NOT NULL
+)`).Error
+}
+
+func (r *Runner) applied(db *gorm.DB) (map[int64]appliedMigration, error) {
+	if !db.Migrator().HasTable(&appliedMigration{}) {
+		return map[int64]appliedMigration{}, nil
+	}
+
+	var rows []appliedMigration
+	if err := db.Order("version").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int64]appliedMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// Status 返回全部迁移及其执行时间，数据库中有但本地没有的版本也会列出
+func (r *Runner) Status() ([]Status, error) {
+	applied, err := r.applied(r.db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(r.migrations))
+	for _, m := range r.migrations {
+		status := Status{Migration: m}
+		if row, ok := applied[m.Version]; ok {
+			status.AppliedAt = &row.AppliedAt
+			delete(applied, m.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, row := range applied {
+		appliedAt := row.AppliedAt
+		statuses = append(statuses, Status{Migration: Migration{Version: row.Version, Name: row.Name}, AppliedAt: &appliedAt})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+func (r *Runner) Pending() ([]Migration, error) {
+	return r.pending(r.db)
+}
+
+func (r *Runner) pending(db *gorm.DB) ([]Migration, error) {
+	applied, err := r.applied(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, m := range r.migrations {
+		if _, ok := applied[m.Version]; !ok {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// Up 按版本顺序执行全部未执行的迁移，返回执行的数量
+func (r *Runner) Up() (int, error) {
+	if r.DryRun {
+		pending, err := r.Pending()
+		if err != nil {
+			return 0, err
+		}
+		for _, m := range pending {
+			fmt.Fprintf(r.Out, "-- up %s\n%s\n", m, m.Up)
+		}
+		return len(pending), nil
+	}
+
+	count := 0
+	err := r.withLock(func(db *gorm.DB) error {
+		if r.Prepare != nil && !db.Migrator().HasTable(&appliedMigration{}) {
+			// Prepare 在新的会话中执行，它的语句不会影响之后执行迁移
+			if err := r.Prepare(db.Session(&gorm.Session{NewDB: true})); err != nil {
+				return fmt.Errorf("prepare existing schema: %w", err)
+			}
+		}
+
+		if err := r.ensureTable(db); err != nil {
+			return err
+		}
+
+		// 获得锁之后重新查询，其他实例可能已经执行过
+		pending, err := r.pending(db)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range pending {
+			fmt.Fprintf(r.Out, "Applying %s\n", m)
+			if err := r.run(db, m.Up, func(tx *gorm.DB) error {
+				return tx.Create(&appliedMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+			}); err != nil {
+				return fmt.Errorf("apply %s: %w", m, err)
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Down 按版本倒序回滚最近执行的 steps 个迁移，返回回滚的数量
+func (r *Runner) Down(steps int) (int, error) {
+	if steps <= 0 {
+		return 0, nil
+	}
+
+	byVersion := make(map[int64]Migration, len(r.migrations))
+	for _, m := range r.migrations {
+		byVersion[m.Version] = m
+	}
+
+	targets := func(db *gorm.DB) ([]Migration, error) {
+		applied, err := r.applied(db)
+		if err != nil {
+			return nil, err
+		}
+
+		versions := make([]int64, 0, len(applied))
+		for version := range applied {
+			versions = append(versions, version)
+		}
+		sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+
+		var migrations []Migration
+		for _, version := range versions[:min(steps, len(versions))] {
+			m, ok := byVersion[version]
+			if !ok {
+				return nil, fmt.Errorf("migration version %d is applied but its files are missing", version)
+			}
+			migrations = append(migrations, m)
+		}
+		return migrations, nil
+	}
+
+	if r.DryRun {
+		migrations, err := targets(r.db)
+		if err != nil {
+			return 0, err
+		}
+		for _, m := range migrations {
+			fmt.Fprintf(r.Out, "-- down %s\n%s\n", m, m.Down)
+		}
+		return len(migrations), nil
+	}
+
+	count := 0
+	err := r.withLock(func(db *gorm.DB) error {
+		migrations, err := targets(db)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range migrations {
+			fmt.Fprintf(r.Out, "Rolling back %s\n", m)
+			if err := r.run(db, m.Down, func(tx *gorm.DB) error {
+				return tx.Delete(&appliedMigration{}, "version = ?", m.Version).Error
+			}); err != nil {
+				return fmt.Errorf("roll back %s: %w", m, err)
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// run 在同一个事务中执行迁移 SQL 和版本记录，标记为不使用事务的迁移依次执行。
+// MySQL 的 DDL 语句会隐式提交事务，迁移失败时已执行的建表语句不会回滚
+func (r *Runner) run(db *gorm.DB, script string, record func(tx *gorm.DB) error) error {
+	if strings.Contains(script, noTransactionMarker) {
+		if err := db.Exec(script).Error; err != nil {
+			return err
+		}
+		return record(db)
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(script).Error; err != nil {
+			return err
+		}
+		return record(tx)
+	})
+}
+
+// withLock 在单独的连接上持有数据库锁执行 fn，会话级的锁必须在同一个连接上释放。
+// SQLite 同一时间只有一个写事务，不需要额外加锁
+func (r *Runner) withLock(fn func(db *gorm.DB) error) error {
+	return r.db.Connection(func(conn *gorm.DB) error {
+		switch conn.Dialector.Name() {
+		case "postgres":
+			// 等待锁和执行迁移都可能超过 DB_STATEMENT_TIMEOUT，在这个连接上暂时取消限制
+			if err := conn.Exec("SET statement_timeout = 0").Error; err != nil {
+				return fmt.Errorf("disable statement timeout: %w", err)
+			}
+			defer conn.Exec("RESET statement_timeout")
+
+			if err := conn.Exec("SELECT pg_advisory_lock(?)", lockKey).Error; err != nil {
+				return fmt.Errorf("acquire migration lock: %w", err)
+			}
+			defer conn.Exec("SELECT pg_advisory_unlock(?)", lockKey)
+
+		case "mysql":
+			var timeout int64
+			if err := conn.Raw("SELECT @@SESSION.max_execution_time").Row().Scan(&timeout); err != nil {
+				return fmt.Errorf("read statement timeout: %w", err)
+			}
+			if err := conn.Exec("SET SESSION max_execution_time = 0").Error; err != nil {
+				return fmt.Errorf("disable statement timeout: %w", err)
+			}
+			defer conn.Exec("SET SESSION max_execution_time = ?", timeout)
+
+			// 超时为 -1 时一直等待，与 pg_advisory_lock 一致
+			var acquired sql.NullInt64
+			if err := conn.Raw("SELECT GET_LOCK(?, -1)", lockName).Row().Scan(&acquired); err != nil {
+				return fmt.Errorf("acquire migration lock: %w", err)
+			}
+			if acquired.Int64 != 1 {
+				return errors.New("acquire migration lock: GET_LOCK failed")
+			}
+			defer conn.Exec("SELECT RELEASE_LOCK(?)", lockName)
+		}
+
+		return fn(conn)
+	})
+}
+
+// Create 在 dir 中创建下一个版本的空迁移文件，返回创建的文件路径
+func Create(dir, name string) ([]string, error) {
+	name = strings.Trim(regexp.MustCompile(`[^a-z0-9]+`).ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return nil, errors.New("migration name is required")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var next int64 = 1
+	for _, entry := range entries {
+		if match := fileNamePattern.FindStringSubmatch(entry.Name()); match != nil {
+			if version, _ := strconv.ParseInt(match[1], 10, 64); version >= next {
+				next = version + 1
+			}
+		}
+	}
+
+	var paths []string
+	for _, direction := range []string{"up", "down"} {
+		path := filepath.Join(dir, fmt.Sprintf("%04d_%s.%s.sql", next, name, direction))
+		content := fmt.Sprintf("-- %s: %s\n", direction, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			return paths, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
@@ -0,0 +1,123 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const gormPluginName = "blog:tracing"
+
+// spanKey 和 parentKey 是 span 和原来的 context 在 gorm.Statement 中的存放位置
+const (
+	spanKey   = "blog:tracing_span"
+	parentKey = "blog:tracing_parent"
+)
+
+// GormPlugin 为每条 SQL 创建一个 span，名称为操作和表名，例如 Preload("Comments.User")
+// 会产生 "SELECT comments" 和 "SELECT users" 两个 span。
+// db.query.text 中只有占位符，不记录参数值；记录不存在不算错误
+type GormPlugin struct{}
+
+// NewGormPlugin 返回注册到 gorm.DB 上的链路插件
+func NewGormPlugin() *GormPlugin {
+	return &GormPlugin{}
+}
+
+func (p *GormPlugin) Name() string {
+	return gormPluginName
+}
+
+// Initialize 在每类回调的最前和最后注册，span 覆盖整条语句的执行
+func (p *GormPlugin) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Create().Before("*").Register("blog:trace_before_create", p.before); err != nil {
+		return err
+	}
+	if err := db.Callback().Create().After("*").Register("blog:trace_after_create", p.after); err != nil {
+		return err
+	}
+	if err := db.Callback().Query().Before("*").Register("blog:trace_before_query", p.before); err != nil {
+		return err
+	}
+	if err := db.Callback().Query().After("*").Register("blog:trace_after_query", p.after); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().Before("*").Register("blog:trace_before_update", p.before); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().After("*").Register("blog:trace_after_update", p.after); err != nil {
+		return err
+	}
+	if err := db.Callback().Delete().Before("*").Register("blog:trace_before_delete", p.before); err != nil {
+		return err
+	}
+	if err := db.Callback().Delete().After("*").Register("blog:trace_after_delete", p.after); err != nil {
+		return err
+	}
+	if err := db.Callback().Row().Before("*").Register("blog:trace_before_row", p.before); err != nil {
+		return err
+	}
+	if err := db.Callback().Row().After("*").Register("blog:trace_after_row", p.after); err != nil {
+		return err
+	}
+	if err := db.Callback().Raw().Before("*").Register("blog:trace_before_raw", p.before); err != nil {
+		return err
+	}
+	return db.Callback().Raw().After("*").Register("blog:trace_after_raw", p.after)
+}
+
+func (p *GormPlugin) before(db *gorm.DB) {
+	ctx := db.Statement.Context
+	if ctx == nil || !trace.SpanFromContext(ctx).SpanContext().IsValid() {
+		// 不在请求中的查询（迁移、后台任务）不单独成为链路
+		return
+	}
+
+	// Preload 的查询在这条语句的回调中执行，会成为它的子 span
+	spanCtx, span := Tracer().Start(ctx, "gorm", trace.WithSpanKind(trace.SpanKindClient))
+	db.Statement.Context = spanCtx
+	db.InstanceSet(spanKey, span)
+	db.InstanceSet(parentKey, ctx)
+}
+
+func (p *GormPlugin) after(db *gorm.DB) {
+	value, ok := db.InstanceGet(spanKey)
+	if !ok {
+		return
+	}
+	span := value.(trace.Span)
+	defer span.End()
+
+	// 复用同一个查询链执行下一条语句时，新的 span 仍然挂在原来的父 span 下
+	if parent, ok := db.InstanceGet(parentKey); ok {
+		db.Statement.Context = parent.(context.Context)
+	}
+
+	sql := db.Statement.SQL.String()
+	operation := strings.ToUpper(strings.SplitN(strings.TrimSpace(sql), " ", 2)[0])
+	name := operation
+	if db.Statement.Table != "" {
+		name += " " + db.Statement.Table
+	}
+	span.SetName(name)
+	span.SetAttributes(
+		semconv.DBSystemKey.String(db.Dialector.Name()),
+		semconv.DBQueryText(sql),
+		semconv.DBOperationName(operation),
+		attribute.Int64("db.rows_affected", db.Statement.RowsAffected),
+	)
+	if db.Statement.Table != "" {
+		span.SetAttributes(semconv.DBCollectionName(db.Statement.Table))
+	}
+
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+}